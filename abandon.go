@@ -0,0 +1,56 @@
+package jsonrpc2
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// AbandonedHandler is a handler which did not return within ServerConfig.TimeoutGrace after its timeout, see OnAbandon.
+type AbandonedHandler struct {
+	Method string
+	// ID is nil for a notification
+	ID json.RawMessage
+}
+
+// OnAbandon calls fn with the handlers abandoned after their timeout, e.g. to find the handlers ignoring the
+// cancellation of their context. Without OnAbandon, they are logged at WARN level by the logger of the request, see
+// LoggerFromContext. The abandoned handlers which have not returned yet are counted by WithExpvarMetrics, and awaited
+// by Server.Wait.
+//	server := jsonrpc2.NewServer(jsonrpc2.OnAbandon(func(h jsonrpc2.AbandonedHandler) {
+//		abandoned.WithLabelValues(h.Method).Inc()
+//	}))
+//	server.SetDefaultTimeout(5 * time.Second)
+//	server.SetTimeoutGrace(time.Second)
+func OnAbandon(fn func(AbandonedHandler)) ServerOption {
+	return func(s *server) {
+		s.onAbandon = fn
+	}
+}
+
+func (s *server) SetTimeoutGrace(d time.Duration) {
+	s.updateConfig(func(cfg *ServerConfig) {
+		cfg.TimeoutGrace = d
+	})
+}
+
+// ============ Private members below =================
+
+// abandon reports the handler of ctx as abandoned until wait returns
+func (s *server) abandon(ctx context.Context, wait func()) {
+	metrics := s.metrics
+	metrics.abandon(1)
+	go func() {
+		wait()
+		metrics.abandon(-1)
+	}()
+	var h AbandonedHandler
+	if info := MethodCallInfo(ctx); info != nil {
+		h = AbandonedHandler{Method: info.Method, ID: info.RequestID}
+	}
+	if s.onAbandon != nil {
+		s.onAbandon(h)
+		return
+	}
+	LoggerFromContext(ctx).Warn("jsonrpc2: abandoned handler")
+}
@@ -0,0 +1,61 @@
+package jsonrpc2
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestServer_SetTimeoutGrace(t *testing.T) {
+	clock := MockClock()
+	release := make(chan struct{})
+	abandoned := make(chan AbandonedHandler, 1)
+	namespace := uniqueExpvarName("test.grace")
+	server := NewServer(WithClock(clock), WithExpvarMetrics(namespace), OnAbandon(func(h AbandonedHandler) {
+		abandoned <- h
+	}))
+	server.SetDefaultTimeout(time.Second)
+	server.SetTimeoutGrace(100 * time.Millisecond)
+	server.DefineMethod("respectful", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		<-ctx.Done()
+		ClockFromContext(ctx).Sleep(50 * time.Millisecond) // cleanup
+		return nil, NewError(-32001, "cancelled")
+	})
+	server.DefineMethod("ignoring", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		<-release
+		return "too late", nil
+	})
+	serve := func(method string) <-chan json.RawMessage {
+		rsp := make(chan json.RawMessage, 1)
+		go func() {
+			rsp <- server.ServeRequest(json.RawMessage(`{"jsonrpc": "2.0", "method": "` + method + `", "id": 1}`))
+		}()
+		return rsp
+	}
+
+	t.Run("handler respecting ctx", func(t *testing.T) {
+		rsp := serve("respectful")
+		clock.BlockUntil(1)
+		clock.Advance(time.Second)
+		clock.BlockUntil(2) // grace and cleanup timers
+		clock.Advance(50 * time.Millisecond)
+		require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "error": {"code": -32001, "message": "cancelled"}}`, string(<-rsp))
+		require.Empty(t, abandoned)
+	})
+	t.Run("handler ignoring ctx", func(t *testing.T) {
+		rsp := serve("ignoring")
+		clock.BlockUntil(1)
+		clock.Advance(time.Second)
+		clock.BlockUntil(1) // grace timer
+		clock.Advance(100 * time.Millisecond)
+		require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "error": {"code": -32000, "message": "context deadline exceeded"}}`, string(<-rsp))
+		require.Equal(t, AbandonedHandler{Method: "ignoring", ID: json.RawMessage(`1`)}, <-abandoned)
+		require.Equal(t, "1", expvar.Get(namespace + ".abandoned").String())
+		close(release)
+		server.Wait()
+		waitFor(t, func() bool { return expvar.Get(namespace + ".abandoned").String() == "0" })
+	})
+}
@@ -0,0 +1,161 @@
+package jsonrpc2
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"math/rand"
+	"path"
+	"sync"
+	"sync/atomic"
+)
+
+type (
+	// LogPolicy is the probability of logging a request, see LogConfig.
+	LogPolicy float64
+
+	// LogConfig selects the requests logged by an AccessLog. The zero LogConfig logs nothing.
+	// Usage:
+	//	jsonrpc2.LogConfig{
+	//		Exclude: []string{"rpc.ping", "telemetry.*"},
+	//		Errors:  jsonrpc2.LogAll,
+	//		Success: jsonrpc2.Sample(0.01),
+	//	}
+	LogConfig struct {
+		// Exclude are the patterns of the methods never logged, as path.Match, e.g. "telemetry.*"
+		Exclude []string
+		// Include are the patterns of the methods logged, all if empty
+		Include []string
+		// Errors and Success are the policies of the requests responding an error and a result
+		Errors  LogPolicy
+		Success LogPolicy
+		// PerMethod overrides the policy of the successful requests of the methods matching its patterns,
+		// an exact method name takes precedence over patterns
+		PerMethod map[string]LogPolicy
+		// Seed makes the sampling reproducible
+		Seed int64
+	}
+
+	// AccessLog logs the requests selected by its LogConfig to a slog.Logger, see WithAccessLog.
+	AccessLog struct {
+		logger *slog.Logger
+		cfg    atomic.Pointer[accessLogConfig]
+	}
+)
+
+// The policies logging no request and all requests.
+const (
+	LogNone LogPolicy = 0
+	LogAll  LogPolicy = 1
+)
+
+// Sample returns the policy logging a request with probability rate.
+func Sample(rate float64) LogPolicy {
+	return LogPolicy(rate)
+}
+
+// NewAccessLog returns an AccessLog writing to logger, the successful requests at INFO level and the errors at WARN.
+func NewAccessLog(logger *slog.Logger, cfg LogConfig) *AccessLog {
+	l := &AccessLog{logger: logger}
+	l.SetConfig(cfg)
+	return l
+}
+
+// SetConfig replaces the config while serving requests, e.g. to log more during an incident.
+func (l *AccessLog) SetConfig(cfg LogConfig) {
+	l.cfg.Store(&accessLogConfig{LogConfig: cfg, rand: rand.New(rand.NewSource(cfg.Seed))})
+}
+
+// Middleware returns the middleware logging the requests. The filters are applied before the log attributes are built.
+func (l *AccessLog) Middleware() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+			info := MethodCallInfo(ctx)
+			cfg := l.cfg.Load()
+			if info == nil || !cfg.includes(info.Method) {
+				return next(ctx, params)
+			}
+			clock := ClockFromContext(ctx)
+			start := clock.Now()
+			result, err := next(ctx, params)
+			if !cfg.sample(info.Method, err != nil) {
+				return result, err
+			}
+			attrs := []slog.Attr{
+				slog.String("method", info.Method),
+				slog.String("id", string(info.RequestID)),
+				slog.Duration("duration", clock.Now().Sub(start)),
+			}
+			if err == nil {
+				l.logger.LogAttrs(ctx, slog.LevelInfo, "jsonrpc2: request", attrs...)
+				return result, err
+			}
+			if code, ok := CodeOf(err); ok {
+				attrs = append(attrs, slog.Int("code", code))
+			}
+			attrs = append(attrs, slog.String("error", err.Error()))
+			l.logger.LogAttrs(ctx, slog.LevelWarn, "jsonrpc2: request", attrs...)
+			return result, err
+		}
+	}
+}
+
+// WithAccessLog logs the requests to l, see NewAccessLog.
+//	accessLog := jsonrpc2.NewAccessLog(slog.Default(), jsonrpc2.LogConfig{Errors: jsonrpc2.LogAll})
+//	server := jsonrpc2.NewServer(jsonrpc2.WithAccessLog(accessLog))
+func WithAccessLog(l *AccessLog) ServerOption {
+	return func(s *server) {
+		s.Use(l.Middleware())
+	}
+}
+
+// ============ Private members below =================
+
+// accessLogConfig is a LogConfig with the random source of its sampling
+type accessLogConfig struct {
+	LogConfig
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+func (cfg *accessLogConfig) includes(method string) bool {
+	if matchAny(cfg.Exclude, method) {
+		return false
+	}
+	return len(cfg.Include) == 0 || matchAny(cfg.Include, method)
+}
+
+// sample draws whether to log a request of method
+func (cfg *accessLogConfig) sample(method string, failed bool) bool {
+	p := cfg.Success
+	if failed {
+		p = cfg.Errors
+	} else if override, ok := cfg.PerMethod[method]; ok {
+		p = override
+	} else {
+		for pattern, override := range cfg.PerMethod {
+			if match, _ := path.Match(pattern, method); match {
+				p = override
+				break
+			}
+		}
+	}
+	if p <= LogNone {
+		return false
+	}
+	if p >= LogAll {
+		return true
+	}
+	cfg.mu.Lock()
+	defer cfg.mu.Unlock()
+	return cfg.rand.Float64() < float64(p)
+}
+
+func matchAny(patterns []string, method string) bool {
+	for _, pattern := range patterns {
+		if match, _ := path.Match(pattern, method); match {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,81 @@
+package jsonrpc2
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/require"
+	"log/slog"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestAccessLog(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey || a.Key == "duration" {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+	accessLog := NewAccessLog(logger, LogConfig{
+		Exclude: []string{"rpc.ping", "telemetry.*"},
+		Errors:  LogAll,
+	})
+	server := NewServer(WithAccessLog(accessLog), AllowReserved())
+	ok := func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		return "ok", nil
+	}
+	fail := func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		return nil, errors.New("failed")
+	}
+	server.DefineMethod("rpc.ping", fail)
+	server.DefineMethod("telemetry.report", fail)
+	server.DefineMethod("ok", ok)
+	server.DefineMethod("fail", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		return nil, NewError(-32050, "Out of stock")
+	})
+	call := func(method string, id int) {
+		server.ServeRequest(json.RawMessage(`{"jsonrpc": "2.0", "method": "` + method + `", "id": ` + strconv.Itoa(id) + `}`))
+	}
+	lines := func() []string {
+		defer buf.Reset()
+		return strings.Split(strings.TrimSpace(buf.String()), "\n")
+	}
+
+	t.Run("excluded methods", func(t *testing.T) {
+		call("rpc.ping", 1)
+		call("telemetry.report", 2)
+		require.Empty(t, buf.String())
+	})
+	t.Run("errors only", func(t *testing.T) {
+		call("ok", 1)
+		call("fail", 2)
+		require.Equal(t, []string{
+			`level=WARN msg="jsonrpc2: request" method=fail id=2 code=-32050 error="Out of stock"`,
+		}, lines())
+	})
+	t.Run("sampling", func(t *testing.T) {
+		accessLog.SetConfig(LogConfig{Success: Sample(0.5), Seed: 1})
+		for i := 0; i < 100; i++ {
+			call("ok", 1)
+		}
+		n := len(lines())
+		require.Greater(t, n, 30)
+		require.Less(t, n, 70)
+		accessLog.SetConfig(LogConfig{Success: Sample(0.5), Seed: 1})
+		for i := 0; i < 100; i++ {
+			call("ok", 1)
+		}
+		require.Len(t, lines(), n, "same seed, same samples")
+	})
+	t.Run("per method", func(t *testing.T) {
+		accessLog.SetConfig(LogConfig{Success: LogNone, PerMethod: map[string]LogPolicy{"o*": LogAll}})
+		call("ok", 3)
+		require.Equal(t, []string{`level=INFO msg="jsonrpc2: request" method=ok id=3`}, lines())
+	})
+}
@@ -0,0 +1,148 @@
+package jsonrpc2
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// AdaptiveTimeout derives the timeout of a method from its recent latencies, so it loosens during a database failover
+// and tightens in steady state. The effective timeout is Factor × the p99 of the last Window latencies, bounded by
+// Floor and Ceiling. It is recalculated every Interval rather than per request, so a burst of slow calls does not move
+// it at once. Until MinSamples calls have completed the static timeout of the method is used.
+// Usage:
+//	jsonrpc2.DefineMethodConfig(server, jsonrpc2.MethodConfig{
+//		Name:            "orders.get",
+//		Handler:         getOrder,
+//		Timeout:         5 * time.Second,
+//		AdaptiveTimeout: &jsonrpc2.AdaptiveTimeout{Floor: 100 * time.Millisecond, Ceiling: 10 * time.Second},
+//	})
+// The chosen timeout is CallInfo.Timeout.
+type AdaptiveTimeout struct {
+	Floor   time.Duration
+	Ceiling time.Duration
+	// Factor is the multiplier of the p99 latency, 3 by default
+	Factor float64
+	// MinSamples is the number of latencies required before adapting, 100 by default
+	MinSamples int
+	// Window is the number of recent latencies the p99 is computed from, 1000 by default
+	Window int
+	// Interval is the time between recalculations, 10s by default
+	Interval time.Duration
+}
+
+// ============ Private members below =================
+
+type adaptiveTimeout struct {
+	cfg AdaptiveTimeout
+
+	mu sync.Mutex
+	// latencies are the last Window latencies
+	latencies *latencyWindow
+	// timeout is calculated at updated, zero until MinSamples latencies are observed
+	timeout time.Duration
+	updated time.Time
+}
+
+func newAdaptiveTimeout(cfg AdaptiveTimeout) *adaptiveTimeout {
+	if cfg.Factor == 0 {
+		cfg.Factor = 3
+	}
+	if cfg.MinSamples == 0 {
+		cfg.MinSamples = 100
+	}
+	if cfg.Window == 0 {
+		cfg.Window = 1000
+	}
+	if cfg.MinSamples > cfg.Window {
+		cfg.Window = cfg.MinSamples
+	}
+	if cfg.Interval == 0 {
+		cfg.Interval = 10 * time.Second
+	}
+	return &adaptiveTimeout{cfg: cfg, latencies: newLatencyWindow(cfg.Window)}
+}
+
+// check returns why cfg is invalid, if it is
+func (cfg AdaptiveTimeout) check() error {
+	if cfg.Floor < 0 || cfg.Ceiling < 0 || cfg.Interval < 0 {
+		return errors.New("negative duration")
+	}
+	if cfg.Ceiling > 0 && cfg.Ceiling < cfg.Floor {
+		return errors.New("ceiling below floor")
+	}
+	if cfg.Factor < 0 || cfg.MinSamples < 0 || cfg.Window < 0 {
+		return errors.New("negative factor or sample count")
+	}
+	return nil
+}
+
+// get returns the adapted timeout, or static until enough latencies are observed
+func (a *adaptiveTimeout) get(static time.Duration) time.Duration {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.updated.IsZero() {
+		return static
+	}
+	return a.timeout
+}
+
+// observe records the latency of a call and recalculates the timeout if it is due
+func (a *adaptiveTimeout) observe(latency time.Duration, now time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.latencies.add(latency)
+	if a.latencies.len() < a.cfg.MinSamples || (!a.updated.IsZero() && now.Sub(a.updated) < a.cfg.Interval) {
+		return
+	}
+	p99 := a.latencies.percentiles(0.99)[0]
+	timeout := time.Duration(a.cfg.Factor * float64(p99))
+	if timeout < a.cfg.Floor {
+		timeout = a.cfg.Floor
+	}
+	if a.cfg.Ceiling > 0 && timeout > a.cfg.Ceiling {
+		timeout = a.cfg.Ceiling
+	}
+	a.timeout = timeout
+	a.updated = now
+}
+
+// latencyWindow is a ring of the last latencies, to estimate their percentiles
+type latencyWindow struct {
+	samples []time.Duration
+	// next is the index of the next sample once the ring is full
+	next int
+}
+
+func newLatencyWindow(size int) *latencyWindow {
+	return &latencyWindow{samples: make([]time.Duration, 0, size)}
+}
+
+func (w *latencyWindow) add(latency time.Duration) {
+	if len(w.samples) < cap(w.samples) {
+		w.samples = append(w.samples, latency)
+		return
+	}
+	w.samples[w.next] = latency
+	w.next = (w.next + 1) % len(w.samples)
+}
+
+func (w *latencyWindow) len() int {
+	return len(w.samples)
+}
+
+// percentiles returns the percentiles qs of the samples, by the nearest rank, zero if there is no sample
+func (w *latencyWindow) percentiles(qs ...float64) []time.Duration {
+	ps := make([]time.Duration, len(qs))
+	if len(w.samples) == 0 {
+		return ps
+	}
+	sorted := append([]time.Duration(nil), w.samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	for i, q := range qs {
+		ps[i] = sorted[int(math.Ceil(q*float64(len(sorted))))-1]
+	}
+	return ps
+}
@@ -0,0 +1,65 @@
+package jsonrpc2
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestAdaptiveTimeout(t *testing.T) {
+	clock := MockClock()
+	server := NewServer(WithClock(clock))
+	timeouts := make(chan time.Duration, 1)
+	DefineMethodConfig(server, MethodConfig{
+		Name:    "work",
+		Timeout: 5 * time.Second,
+		AdaptiveTimeout: &AdaptiveTimeout{
+			Floor:      200 * time.Millisecond,
+			Ceiling:    500 * time.Millisecond,
+			MinSamples: 2,
+			Interval:   time.Second,
+		},
+		Handler: func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+			timeouts <- MethodCallInfo(ctx).Timeout
+			var ms int
+			_ = json.Unmarshal(params, &ms)
+			ClockFromContext(ctx).Sleep(time.Duration(ms) * time.Millisecond)
+			return ms, nil
+		},
+	})
+	// call calls work taking ms and returns its timeout
+	call := func(ms int) time.Duration {
+		rsp := make(chan json.RawMessage)
+		go func() {
+			rsp <- server.ServeRequest(json.RawMessage(`{"jsonrpc": "2.0", "method": "work", "params": ` + strconv.Itoa(ms) + `, "id": 1}`))
+		}()
+		timeout := <-timeouts
+		// the timeout timer and the sleep
+		clock.BlockUntil(2)
+		clock.Advance(time.Duration(ms) * time.Millisecond)
+		require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "result": `+strconv.Itoa(ms)+`}`, string(<-rsp))
+		return timeout
+	}
+
+	// static until enough samples
+	require.Equal(t, 5*time.Second, call(50))
+	require.Equal(t, 5*time.Second, call(50))
+	// 3 × p99 bounded by the floor
+	require.Equal(t, 200*time.Millisecond, call(100))
+	// recalculated after the interval only
+	require.Equal(t, 200*time.Millisecond, call(100))
+	clock.Advance(time.Second)
+	require.Equal(t, 200*time.Millisecond, call(150))
+	require.Equal(t, 450*time.Millisecond, call(150))
+	// bounded by the ceiling
+	clock.Advance(time.Second)
+	require.Equal(t, 450*time.Millisecond, call(190))
+	require.Equal(t, 500*time.Millisecond, call(10))
+
+	require.PanicsWithValue(t, `jsonrpc2: invalid adaptive timeout of method "bad": ceiling below floor`, func() {
+		DefineMethodConfig(server, MethodConfig{Name: "bad", Handler: func(ctx context.Context, params json.RawMessage) (interface{}, error) { return nil, nil }, AdaptiveTimeout: &AdaptiveTimeout{Floor: time.Second, Ceiling: time.Millisecond}})
+	})
+}
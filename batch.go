@@ -0,0 +1,133 @@
+package jsonrpc2
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// BatchProgress is the progress of a batch request being served, see OnBatchProgress.
+type BatchProgress struct {
+	// Completed counts the elements served, of the Total elements of the batch
+	Completed int
+	Total     int
+	// Elapsed is the time since the batch is served
+	Elapsed time.Duration
+}
+
+// BatchSummary is the outcome of a batch request, see OnBatchComplete.
+type BatchSummary struct {
+	// Size is the number of elements of the batch
+	Size int
+	// Successes and Errors count the responses, Notifications the elements without response
+	Successes     int
+	Errors        int
+	Notifications int
+	// Duration is the time to serve the whole batch
+	Duration time.Duration
+	// SlowestMethod is the method of the element served the longest, empty if it is not a valid request
+	SlowestMethod string
+}
+
+// OnBatchComplete calls fn with the summary of each batch request once it is served, even if all its elements are
+// notifications, e.g. to alert on batches which are half failing:
+//	server := jsonrpc2.NewServer(jsonrpc2.OnBatchComplete(func(b jsonrpc2.BatchSummary) {
+//		if b.Errors > 0 && b.Successes > 0 {
+//			log.Printf("batch of %d: %d errors, slowest %s", b.Size, b.Errors, b.SlowestMethod)
+//		}
+//	}))
+// fn is called by the goroutine serving the batch, before its response is returned.
+func OnBatchComplete(fn func(BatchSummary)) ServerOption {
+	return func(s *server) {
+		s.onBatchComplete = fn
+	}
+}
+
+// OnBatchProgress calls fn with the progress of the batch requests while they are served, every time every more elements
+// are completed or interval elapsed since the last call, whichever comes first, and once all the elements are
+// completed. 0 disables either cadence. The calls of a batch are sequential, by the goroutines serving its elements.
+//	server := jsonrpc2.NewServer(jsonrpc2.OnBatchProgress(1000, 10*time.Second, func(p jsonrpc2.BatchProgress) {
+//		log.Printf("batch: %d/%d in %s", p.Completed, p.Total, p.Elapsed)
+//	}))
+func OnBatchProgress(every int, interval time.Duration, fn func(BatchProgress)) ServerOption {
+	return func(s *server) {
+		s.onBatchProgress = &batchProgressHook{every: every, interval: interval, fn: fn}
+	}
+}
+
+// ============ Private members below =================
+
+type (
+	batchProgressHook struct {
+		every    int
+		interval time.Duration
+		fn       func(BatchProgress)
+	}
+
+	// batchProgress reports the progress of a batch to its hook
+	batchProgress struct {
+		hook  *batchProgressHook
+		clock Clock
+		start time.Time
+
+		mu        sync.Mutex
+		completed int
+		total     int
+		// reported and last are the completed elements and the time of the last report
+		reported int
+		last     time.Time
+	}
+)
+
+// newBatchProgress returns the progress of a batch of total elements, nil without hook
+func (s *server) newBatchProgress(total int) *batchProgress {
+	if s.onBatchProgress == nil {
+		return nil
+	}
+	now := s.clock.Now()
+	return &batchProgress{hook: s.onBatchProgress, clock: s.clock, start: now, total: total, last: now}
+}
+
+// complete counts n more elements completed and reports the progress if due
+func (p *batchProgress) complete(n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.completed += n
+	now := p.clock.Now()
+	due := p.completed == p.total ||
+		p.hook.every > 0 && p.completed-p.reported >= p.hook.every ||
+		p.hook.interval > 0 && now.Sub(p.last) >= p.hook.interval
+	if !due {
+		return
+	}
+	p.reported, p.last = p.completed, now
+	p.hook.fn(BatchProgress{Completed: p.completed, Total: p.total, Elapsed: now.Sub(p.start)})
+}
+
+// summarizeBatch counts the responses rsps of the batch rs, durations are the times to serve the elements,
+// 0 for the coalesced elements
+func summarizeBatch(rs, rsps []json.RawMessage, durations []time.Duration, total time.Duration) BatchSummary {
+	b := BatchSummary{Size: len(rs), Duration: total}
+	slowest := -1
+	for i, rsp := range rsps {
+		if rsp == nil {
+			b.Notifications++
+		} else {
+			var r rawResponse
+			if json.Unmarshal(rsp, &r) == nil && r.Error == nil {
+				b.Successes++
+			} else {
+				b.Errors++
+			}
+		}
+		if slowest < 0 || durations[i] > durations[slowest] {
+			slowest = i
+		}
+	}
+	if slowest >= 0 {
+		var r request
+		json.Unmarshal(rs[slowest], &r)
+		b.SlowestMethod = r.Method
+	}
+	return b
+}
@@ -0,0 +1,138 @@
+package jsonrpc2
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOnBatchComplete(t *testing.T) {
+	var summaries []BatchSummary
+	clock := MockClock()
+	server := NewServer(WithClock(clock), OnBatchComplete(func(b BatchSummary) {
+		summaries = append(summaries, b)
+	}))
+	server.DefineMethod("echo", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		return params, nil
+	})
+	server.DefineMethod("slow", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		ClockFromContext(ctx).Sleep(time.Second)
+		return "done", nil
+	})
+	server.DefineMethod("panic", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		panic("boom")
+	})
+
+	rsp := make(chan json.RawMessage)
+	go func() {
+		rsp <- server.ServeRequest(json.RawMessage(`[
+			{ "jsonrpc": "2.0", "method": "slow", "id": 1 },
+			{ "jsonrpc": "2.0", "method": "echo", "params": 1, "id": 2 },
+			{ "jsonrpc": "2.0", "method": "panic", "id": 3 },
+			{ "jsonrpc": "2.0", "method": "unknown", "id": 4 },
+			{ "jsonrpc": "2.0", "method": "echo", "params": 2 }
+		]`))
+	}()
+	clock.BlockUntil(1)
+	clock.Advance(time.Second)
+	require.JSONEq(t, `[
+		{"id": 1, "jsonrpc": "2.0", "result": "done"},
+		{"id": 2, "jsonrpc": "2.0", "result": 1},
+		{"id": 3, "jsonrpc": "2.0", "error": {"code": -32603, "message": "Internal server error"}},
+		{"id": 4, "jsonrpc": "2.0", "error": {"code": -32601, "message": "Method not found"}}
+	]`, string(<-rsp))
+	require.Equal(t, []BatchSummary{{
+		Size:          5,
+		Successes:     2,
+		Errors:        2,
+		Notifications: 1,
+		Duration:      time.Second,
+		SlowestMethod: "slow",
+	}}, summaries)
+
+	summaries = nil
+	require.Nil(t, server.ServeRequest(json.RawMessage(`[
+		{ "jsonrpc": "2.0", "method": "echo", "params": 1 },
+		{ "jsonrpc": "2.0", "method": "panic" }
+	]`)))
+	require.Len(t, summaries, 1)
+	require.Equal(t, 2, summaries[0].Notifications)
+}
+
+func TestOnBatchProgress(t *testing.T) {
+	echo := func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		return params, nil
+	}
+	t.Run("every n", func(t *testing.T) {
+		var progress []BatchProgress
+		server := NewServer(OnBatchProgress(100, 0, func(p BatchProgress) {
+			progress = append(progress, p)
+		}))
+		server.DefineMethod("echo", echo)
+		reqs := make([]string, 1000)
+		for i := range reqs {
+			reqs[i] = `{"jsonrpc": "2.0", "method": "echo", "params": ` + strconv.Itoa(i) + `, "id": ` + strconv.Itoa(i) + `}`
+		}
+		server.ServeRequest(json.RawMessage("[" + strings.Join(reqs, ",") + "]"))
+		require.Len(t, progress, 10)
+		for i, p := range progress {
+			require.Equal(t, 100*(i+1), p.Completed)
+			require.Equal(t, 1000, p.Total)
+		}
+
+		// the last element is always reported
+		progress = nil
+		server.ServeRequest(json.RawMessage("[" + strings.Join(reqs[:150], ",") + "]"))
+		require.Equal(t, []int{100, 150}, []int{progress[0].Completed, progress[1].Completed})
+	})
+	t.Run("every interval", func(t *testing.T) {
+		clock := MockClock()
+		progress := make(chan BatchProgress, 10)
+		server := NewServer(WithClock(clock), OnBatchProgress(0, time.Second, func(p BatchProgress) {
+			progress <- p
+		}))
+		release := map[string]chan struct{}{"1": make(chan struct{}), "2": make(chan struct{}), "3": make(chan struct{})}
+		started := make(chan struct{}, 3)
+		server.DefineMethod("wait", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+			started <- struct{}{}
+			<-release[string(params)]
+			return nil, nil
+		})
+		rsp := make(chan json.RawMessage)
+		go func() {
+			rsp <- server.ServeRequest(json.RawMessage(`[
+				{"jsonrpc": "2.0", "method": "wait", "params": 1, "id": 1},
+				{"jsonrpc": "2.0", "method": "wait", "params": 2, "id": 2},
+				{"jsonrpc": "2.0", "method": "wait", "params": 3, "id": 3}
+			]`))
+		}()
+		for i := 0; i < 3; i++ {
+			<-started
+		}
+		clock.Advance(time.Second)
+		close(release["1"])
+		require.Equal(t, BatchProgress{Completed: 1, Total: 3, Elapsed: time.Second}, <-progress)
+		// less than the interval since the last report
+		close(release["2"])
+		close(release["3"])
+		<-rsp
+		require.Equal(t, BatchProgress{Completed: 3, Total: 3, Elapsed: time.Second}, <-progress)
+		require.Empty(t, progress)
+	})
+}
+
+func TestServer_RecoversHandlerPanic(t *testing.T) {
+	server := NewServer()
+	server.DefineMethod("panic", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		panic("boom")
+	})
+	rsp := server.ServeRequest(json.RawMessage(`{ "jsonrpc": "2.0", "method": "panic", "id": 1 }`))
+	require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "error": {"code": -32603, "message": "Internal server error"}}`, string(rsp))
+	server.SetDefaultTimeout(time.Second)
+	rsp = server.ServeRequest(json.RawMessage(`{ "jsonrpc": "2.0", "method": "panic", "id": 1 }`))
+	require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "error": {"code": -32603, "message": "Internal server error"}}`, string(rsp))
+}
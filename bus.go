@@ -0,0 +1,207 @@
+package jsonrpc2
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+type (
+	// A Connection is a client connection the server can push notifications to, e.g. a websocket.
+	Connection interface {
+		Notifier
+	}
+
+	// NotificationBus broadcasts server-side events as notifications to the registered connections.
+	// Usage:
+	//	bus := jsonrpc2.NewNotificationBus()
+	//	unregister := bus.RegisterWithGroup(conn, "room:1")
+	//	defer unregister()
+	//
+	//	errs := bus.PublishToGroup(ctx, "room:1", "message", msg)
+	// Each connection receives its notifications in publishing order, one at a time.
+	NotificationBus struct {
+		mu    sync.RWMutex
+		conns map[*busEntry]struct{}
+		// sequenced wraps the params in SequencedParams
+		sequenced bool
+	}
+
+	// BusOption configures the bus created by NewNotificationBus.
+	BusOption func(b *NotificationBus)
+
+	// SequencedParams are the params of the notifications of a bus created WithSequence.
+	//	{"jsonrpc": "2.0", "method": "message", "params": {"seq": 42, "params": {...}}}
+	SequencedParams struct {
+		// Seq increases by one for each notification published to the connection, starting at 1
+		Seq    uint64      `json:"seq"`
+		Params interface{} `json:"params,omitempty"`
+	}
+)
+
+func NewNotificationBus(opts ...BusOption) *NotificationBus {
+	b := &NotificationBus{
+		conns: map[*busEntry]struct{}{},
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// WithSequence wraps the params of the notifications in SequencedParams, so clients can detect missed notifications.
+func WithSequence() BusOption {
+	return func(b *NotificationBus) {
+		b.sequenced = true
+	}
+}
+
+// Register adds conn to the bus. The returned function removes it.
+func (b *NotificationBus) Register(conn Connection) func() {
+	return b.RegisterWithGroup(conn)
+}
+
+// RegisterWithGroup adds conn to the bus tagged with groups. The returned function removes it.
+func (b *NotificationBus) RegisterWithGroup(conn Connection, groups ...string) func() {
+	e := &busEntry{
+		conn:      conn,
+		groups:    make(map[string]struct{}, len(groups)),
+		sequenced: b.sequenced,
+		queue:     make(chan *busWrite, busQueueSize),
+		done:      make(chan struct{}),
+	}
+	for _, g := range groups {
+		e.groups[g] = struct{}{}
+	}
+	go e.write()
+	b.mu.Lock()
+	b.conns[e] = struct{}{}
+	b.mu.Unlock()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.conns, e)
+			b.mu.Unlock()
+			close(e.done)
+		})
+	}
+}
+
+// Publish sends the notification to all connections concurrently and returns the errors of the failed connections.
+func (b *NotificationBus) Publish(ctx context.Context, method string, params interface{}) []error {
+	return b.publish(ctx, method, params, func(e *busEntry) bool {
+		return true
+	})
+}
+
+// Notify is Publish returning the errors of the failed connections joined, so a bus is a Notifier.
+func (b *NotificationBus) Notify(ctx context.Context, method string, params interface{}) error {
+	return errors.Join(b.Publish(ctx, method, params)...)
+}
+
+// PublishToGroup sends the notification to the connections of group concurrently and returns the errors of the failed connections.
+func (b *NotificationBus) PublishToGroup(ctx context.Context, group string, method string, params interface{}) []error {
+	return b.publish(ctx, method, params, func(e *busEntry) bool {
+		_, ok := e.groups[group]
+		return ok
+	})
+}
+
+// ============ Private members below =================
+
+// busQueueSize is the max number of notifications queued per connection
+const busQueueSize = 64
+
+type (
+	busEntry struct {
+		conn      Connection
+		groups    map[string]struct{}
+		sequenced bool
+
+		// mu orders the notifications in queue as their seq
+		mu  sync.Mutex
+		seq uint64
+		// queue is consumed by the single writer of conn
+		queue chan *busWrite
+		// done is closed when the connection is unregistered
+		done chan struct{}
+	}
+
+	busWrite struct {
+		ctx    context.Context
+		method string
+		params interface{}
+		err    chan error
+	}
+)
+
+// notify queues the notification for the writer and waits for it to be sent
+func (e *busEntry) notify(ctx context.Context, method string, params interface{}) error {
+	w := &busWrite{ctx: ctx, method: method, err: make(chan error, 1)}
+	e.mu.Lock()
+	e.seq++
+	if e.sequenced {
+		w.params = SequencedParams{Seq: e.seq, Params: params}
+	} else {
+		w.params = params
+	}
+	select {
+	case e.queue <- w:
+	case <-e.done:
+		e.mu.Unlock()
+		return errConnectionClosed
+	case <-ctx.Done():
+		e.mu.Unlock()
+		return ctx.Err()
+	}
+	e.mu.Unlock()
+	select {
+	case err := <-w.err:
+		return err
+	case <-e.done:
+		return errConnectionClosed
+	}
+}
+
+// write sends the queued notifications to conn in order until the connection is unregistered
+func (e *busEntry) write() {
+	for {
+		select {
+		case w := <-e.queue:
+			w.err <- e.conn.Notify(w.ctx, w.method, w.params)
+		case <-e.done:
+			return
+		}
+	}
+}
+
+func (b *NotificationBus) publish(ctx context.Context, method string, params interface{}, match func(e *busEntry) bool) []error {
+	b.mu.RLock()
+	conns := make([]*busEntry, 0, len(b.conns))
+	for e := range b.conns {
+		if match(e) {
+			conns = append(conns, e)
+		}
+	}
+	b.mu.RUnlock()
+
+	errs := make([]error, len(conns))
+	var wg sync.WaitGroup
+	for i := range conns {
+		wg.Add(1)
+		go func(i int) {
+			errs[i] = conns[i].notify(ctx, method, params)
+			wg.Done()
+		}(i)
+	}
+	wg.Wait()
+
+	result := make([]error, 0)
+	for _, err := range errs {
+		if err != nil {
+			result = append(result, err)
+		}
+	}
+	return result
+}
@@ -0,0 +1,132 @@
+package jsonrpc2
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"testing"
+)
+
+type testConnection struct {
+	mu            sync.Mutex
+	notifications []string
+	params        []interface{}
+	err           error
+}
+
+func (c *testConnection) Notify(ctx context.Context, method string, params interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return c.err
+	}
+	c.notifications = append(c.notifications, method)
+	c.params = append(c.params, params)
+	return nil
+}
+
+func TestNotificationBus(t *testing.T) {
+	ctx := context.Background()
+	t.Run("publish to all connections", func(t *testing.T) {
+		bus := NewNotificationBus()
+		c1, c2 := &testConnection{}, &testConnection{}
+		bus.Register(c1)
+		bus.RegisterWithGroup(c2, "a")
+		require.Empty(t, bus.Publish(ctx, "event", 1))
+		require.Equal(t, []string{"event"}, c1.notifications)
+		require.Equal(t, []string{"event"}, c2.notifications)
+	})
+	t.Run("publish to group", func(t *testing.T) {
+		bus := NewNotificationBus()
+		c1, c2, c3 := &testConnection{}, &testConnection{}, &testConnection{}
+		bus.Register(c1)
+		bus.RegisterWithGroup(c2, "a")
+		bus.RegisterWithGroup(c3, "a", "b")
+		require.Empty(t, bus.PublishToGroup(ctx, "b", "event", 1))
+		require.Empty(t, c1.notifications)
+		require.Empty(t, c2.notifications)
+		require.Equal(t, []string{"event"}, c3.notifications)
+	})
+	t.Run("unregister", func(t *testing.T) {
+		bus := NewNotificationBus()
+		c := &testConnection{}
+		unregister := bus.Register(c)
+		unregister()
+		require.Empty(t, bus.Publish(ctx, "event", 1))
+		require.Empty(t, c.notifications)
+	})
+	t.Run("collect errors", func(t *testing.T) {
+		bus := NewNotificationBus()
+		err := errors.New("closed")
+		c1, c2 := &testConnection{}, &testConnection{err: err}
+		bus.Register(c1)
+		bus.Register(c2)
+		require.Equal(t, []error{err}, bus.Publish(ctx, "event", 1))
+		require.Equal(t, []string{"event"}, c1.notifications)
+	})
+	t.Run("notify", func(t *testing.T) {
+		bus := NewNotificationBus()
+		err := errors.New("closed")
+		c1, c2 := &testConnection{}, &testConnection{err: err}
+		bus.Register(c1)
+		var notifier Notifier = bus
+		require.NoError(t, notifier.Notify(ctx, "event", 1))
+		bus.Register(c2)
+		require.ErrorIs(t, notifier.Notify(ctx, "event", 2), err)
+		require.Equal(t, []string{"event", "event"}, c1.notifications)
+	})
+}
+
+func TestNotificationBus_Ordering(t *testing.T) {
+	ctx := context.Background()
+	t.Run("monotonic seq with concurrent producers", func(t *testing.T) {
+		bus := NewNotificationBus(WithSequence())
+		c1, c2 := &testConnection{}, &testConnection{}
+		bus.Register(c1)
+		bus.Register(c2)
+		var wg sync.WaitGroup
+		for p := 0; p < 10; p++ {
+			wg.Add(1)
+			go func(p int) {
+				defer wg.Done()
+				for i := 0; i < 100; i++ {
+					bus.Publish(ctx, "event", []int{p, i})
+				}
+			}(p)
+		}
+		wg.Wait()
+		for _, c := range []*testConnection{c1, c2} {
+			require.Len(t, c.params, 1000)
+			// the events of each producer are delivered in order too
+			last := map[int]int{}
+			for i, params := range c.params {
+				sp := params.(SequencedParams)
+				require.Equal(t, uint64(i+1), sp.Seq)
+				event := sp.Params.([]int)
+				if prev, ok := last[event[0]]; ok {
+					require.Equal(t, prev+1, event[1])
+				}
+				last[event[0]] = event[1]
+			}
+		}
+	})
+	t.Run("no envelope by default", func(t *testing.T) {
+		bus := NewNotificationBus()
+		c := &testConnection{}
+		bus.Register(c)
+		bus.Publish(ctx, "event", 1)
+		require.Equal(t, []interface{}{1}, c.params)
+	})
+	t.Run("unregistered connection", func(t *testing.T) {
+		bus := NewNotificationBus()
+		unregister := bus.Register(&testConnection{})
+		require.Len(t, bus.conns, 1)
+		for e := range bus.conns {
+			unregister()
+			unregister()
+			require.Equal(t, errConnectionClosed, e.notify(ctx, "event", 1))
+		}
+	})
+}
+
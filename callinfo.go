@@ -0,0 +1,58 @@
+package jsonrpc2
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// CallInfo describes the method call being handled.
+type CallInfo struct {
+	Method string
+	// RequestID is nil for a notification
+	RequestID json.RawMessage
+	// Attempt is 1 on the first call of the handler and incremented by the Retry middleware
+	Attempt int
+	// BatchIndex is the index of the request in its batch request, or -1 if not in a batch
+	BatchIndex int
+	StartTime  time.Time
+	// Timeout is the timeout chosen for the call, 0 if none, see MethodConfig.Timeout and AdaptiveTimeout
+	Timeout time.Duration
+}
+
+// MethodCallInfo returns the CallInfo of the method call of a handler context, or nil.
+// Usage:
+//	if jsonrpc2.MethodCallInfo(ctx).Attempt > 1 {
+//		// skip non-idempotent side effects done by a previous attempt
+//	}
+func MethodCallInfo(ctx context.Context) *CallInfo {
+	info, _ := ctx.Value(callInfoContextKey{}).(*CallInfo)
+	return info
+}
+
+// Retry calls the handler again, up to maxAttempts calls in total, while it returns an error which is not a
+// jsonrpc2.Error and the context is not done. Errors of type jsonrpc2.Error are considered final.
+func Retry(maxAttempts int) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+			info := MethodCallInfo(ctx)
+			for attempt := 1; ; attempt++ {
+				if info != nil {
+					info.Attempt = attempt
+				}
+				result, err := next(ctx, params)
+				if err == nil || attempt >= maxAttempts || ctx.Err() != nil {
+					return result, err
+				}
+				if _, ok := CodeOf(err); ok {
+					return result, err
+				}
+			}
+		}
+	}
+}
+
+// ============ Private members below =================
+
+// callInfoContextKey is the context key of the *CallInfo of the request being served.
+type callInfoContextKey struct{}
@@ -0,0 +1,77 @@
+package jsonrpc2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestMethodCallInfo(t *testing.T) {
+	var infos []CallInfo
+	server := NewServer()
+	server.DefineMethod("info", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		infos = append(infos, *MethodCallInfo(ctx))
+		return nil, nil
+	})
+	t.Run("single request", func(t *testing.T) {
+		infos = nil
+		start := time.Now()
+		server.ServeRequest(json.RawMessage(`{ "jsonrpc": "2.0", "method": "info", "id": 1 }`))
+		require.Len(t, infos, 1)
+		require.Equal(t, "info", infos[0].Method)
+		require.Equal(t, json.RawMessage(`1`), infos[0].RequestID)
+		require.Equal(t, 1, infos[0].Attempt)
+		require.Equal(t, -1, infos[0].BatchIndex)
+		require.False(t, infos[0].StartTime.Before(start))
+	})
+	t.Run("batch request", func(t *testing.T) {
+		infos = nil
+		server.ServeRequest(json.RawMessage(`[{ "jsonrpc": "2.0", "method": "info" }]`))
+		require.Len(t, infos, 1)
+		require.Nil(t, infos[0].RequestID)
+		require.Equal(t, 0, infos[0].BatchIndex)
+	})
+	t.Run("no call info", func(t *testing.T) {
+		require.Nil(t, MethodCallInfo(context.Background()))
+	})
+}
+
+func TestRetry(t *testing.T) {
+	var attempts []int
+	server := NewServer()
+	server.Use(Retry(3))
+	server.DefineMethod("flaky", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		attempt := MethodCallInfo(ctx).Attempt
+		attempts = append(attempts, attempt)
+		var succeedAt int
+		json.Unmarshal(params, &succeedAt)
+		if attempt < succeedAt {
+			return nil, errors.New("unavailable")
+		}
+		return attempt, nil
+	})
+	server.DefineMethod("invalid", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		attempts = append(attempts, MethodCallInfo(ctx).Attempt)
+		return nil, ErrInvalidParams
+	})
+	t.Run("attempt is incremented", func(t *testing.T) {
+		attempts = nil
+		rsp := server.ServeRequest(json.RawMessage(`{ "jsonrpc": "2.0", "method": "flaky", "params": 2, "id": 1 }`))
+		require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "result": 2}`, string(rsp))
+		require.Equal(t, []int{1, 2}, attempts)
+	})
+	t.Run("max attempts", func(t *testing.T) {
+		attempts = nil
+		rsp := server.ServeRequest(json.RawMessage(`{ "jsonrpc": "2.0", "method": "flaky", "params": 5, "id": 1 }`))
+		require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "error": {"code": -32000, "message": "unavailable"}}`, string(rsp))
+		require.Equal(t, []int{1, 2, 3}, attempts)
+	})
+	t.Run("rpc errors are not retried", func(t *testing.T) {
+		attempts = nil
+		server.ServeRequest(json.RawMessage(`{ "jsonrpc": "2.0", "method": "invalid", "id": 1 }`))
+		require.Equal(t, []int{1}, attempts)
+	})
+}
@@ -0,0 +1,157 @@
+package jsonrpc2
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// CanonicalizeJSON returns the canonical form of raw, so equivalent json values, e.g. params, have identical bytes
+// and can be compared or hashed. The canonical form is:
+//	- no whitespace
+//	- object keys sorted by their UTF-8 bytes, recursively. Duplicate keys keep the last value, as encoding/json.
+//	- strings escape only '"', '\' and control characters, using \b \f \n \r \t or \u00xx. Other characters,
+//	  including escaped ones like "é", are written as UTF-8.
+//	- numbers are compared by decimal value, without float conversion, so 1, 1.0, 10e-1 are all 1 and big numbers
+//	  keep all their digits. They are formatted like JavaScript: integers up to 21 digits in full,
+//	  e.g. 100, 0.001, 1.5, otherwise in exponent form, e.g. 1e+21, 1.5e-7.
+func CanonicalizeJSON(raw json.RawMessage) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("jsonrpc2: invalid data after top-level value")
+	}
+	var buf bytes.Buffer
+	writeCanonical(&buf, v)
+	return buf.Bytes(), nil
+}
+
+// ============ Private members below =================
+
+func writeCanonical(buf *bytes.Buffer, v interface{}) {
+	switch v := v.(type) {
+	case nil:
+		buf.WriteString("null")
+	case bool:
+		buf.WriteString(strconv.FormatBool(v))
+	case json.Number:
+		buf.WriteString(canonicalNumber(string(v)))
+	case string:
+		writeCanonicalString(buf, v)
+	case []interface{}:
+		buf.WriteByte('[')
+		for i, e := range v {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writeCanonical(buf, e)
+		}
+		buf.WriteByte(']')
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		buf.WriteByte('{')
+		for i, k := range keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writeCanonicalString(buf, k)
+			buf.WriteByte(':')
+			writeCanonical(buf, v[k])
+		}
+		buf.WriteByte('}')
+	}
+}
+
+func writeCanonicalString(buf *bytes.Buffer, s string) {
+	const hex = "0123456789abcdef"
+	buf.WriteByte('"')
+	for _, c := range s {
+		switch {
+		case c == '"' || c == '\\':
+			buf.WriteByte('\\')
+			buf.WriteRune(c)
+		case c == '\b':
+			buf.WriteString(`\b`)
+		case c == '\f':
+			buf.WriteString(`\f`)
+		case c == '\n':
+			buf.WriteString(`\n`)
+		case c == '\r':
+			buf.WriteString(`\r`)
+		case c == '\t':
+			buf.WriteString(`\t`)
+		case c < 0x20:
+			buf.WriteString(`\u00`)
+			buf.WriteByte(hex[c>>4])
+			buf.WriteByte(hex[c&0xf])
+		default:
+			buf.WriteRune(c)
+		}
+	}
+	buf.WriteByte('"')
+}
+
+// canonicalNumber formats a valid json number literal by its decimal value
+func canonicalNumber(n string) string {
+	neg := strings.HasPrefix(n, "-")
+	n = strings.TrimPrefix(n, "-")
+	mantissa, exp := n, 0
+	if i := strings.IndexAny(n, "eE"); i >= 0 {
+		mantissa = n[:i]
+		// the exponent of a json number fits unless absurdly long
+		exp, _ = strconv.Atoi(strings.TrimPrefix(n[i+1:], "+"))
+	}
+	digits := mantissa
+	if i := strings.IndexByte(mantissa, '.'); i >= 0 {
+		digits = mantissa[:i] + mantissa[i+1:]
+		exp -= len(mantissa) - i - 1
+	}
+	// value = digits × 10^exp, normalize digits to have no leading nor trailing zeros
+	digits = strings.TrimLeft(digits, "0")
+	if digits == "" {
+		return "0"
+	}
+	trimmed := strings.TrimRight(digits, "0")
+	exp += len(digits) - len(trimmed)
+	digits = trimmed
+
+	// k digits, value = 0.digits × 10^pos, formatted as JavaScript Number::toString
+	k := len(digits)
+	pos := exp + k
+	var s string
+	switch {
+	case k <= pos && pos <= 21:
+		s = digits + strings.Repeat("0", pos-k)
+	case 0 < pos && pos <= 21:
+		s = digits[:pos] + "." + digits[pos:]
+	case -6 < pos && pos <= 0:
+		s = "0." + strings.Repeat("0", -pos) + digits
+	default:
+		s = digits[:1]
+		if k > 1 {
+			s += "." + digits[1:]
+		}
+		if pos-1 >= 0 {
+			s += "e+" + strconv.Itoa(pos-1)
+		} else {
+			s += "e-" + strconv.Itoa(1-pos)
+		}
+	}
+	if neg {
+		s = "-" + s
+	}
+	return s
+}
+
@@ -0,0 +1,42 @@
+package jsonrpc2
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestCanonicalizeJSON(t *testing.T) {
+	cases := []struct {
+		name      string
+		in        string
+		canonical string
+	}{
+		{"key ordering", `{"b": 2, "a": 1}`, `{"a":1,"b":2}`},
+		{"nested", `[ {"z": {"y": [3, {"b": null, "a": true}]}, "x": false} ]`, `[{"x":false,"z":{"y":[3,{"a":true,"b":null}]}}]`},
+		{"unicode escapes", `"\u00e9\u4E2D\ud83d\ude00é"`, `"é中😀é"`},
+		{"escapes", `"\"\\\/\b\f\n\r\t\u0001\u001F<>& "`, "\"\\\"\\\\/\\b\\f\\n\\r\\t\\u0001\\u001f<>& \""},
+		{"escaped keys", `{"\u0062": 2, "a": 1}`, `{"a":1,"b":2}`},
+		{"integer", `100`, `100`},
+		{"equivalent numbers", `[1, 1.0, 1.000, 10e-1, 0.1e1, 1E0]`, `[1,1,1,1,1,1]`},
+		{"zero", `[0, -0, 0.0, 0e10]`, `[0,0,0,0]`},
+		{"negative", `-1.50`, `-1.5`},
+		{"fraction", `[0.001, 123.45, 0.0000001]`, `[0.001,123.45,1e-7]`},
+		{"exponent", `[1e21, 1.5e300, 1e-7, 123e-10]`, `[1e+21,1.5e+300,1e-7,1.23e-8]`},
+		{"big numbers", `[12345678901234567890, 123456789012345678901234567890]`, `[12345678901234567890,1.2345678901234567890123456789e+29]`},
+		{"precise decimals", `0.10000000000000000000000000001`, `0.10000000000000000000000000001`},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			canonical, err := CanonicalizeJSON(json.RawMessage(c.in))
+			require.NoError(t, err)
+			require.Equal(t, c.canonical, string(canonical))
+		})
+	}
+	t.Run("invalid json", func(t *testing.T) {
+		_, err := CanonicalizeJSON(json.RawMessage(`{"a":`))
+		require.Error(t, err)
+		_, err = CanonicalizeJSON(json.RawMessage(`{} {}`))
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,96 @@
+package jsonrpc2
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// A Capability is a server feature negotiated with the client by the built-in "rpc.initialize" method, as in LSP.
+// The client sends its capabilities keyed by name:
+//	--> {"jsonrpc": "2.0", "method": "rpc.initialize", "params": {"capabilities": {"maxBatchSize": 50}}, "id": 1}
+//	<-- {"jsonrpc": "2.0", "result": {"capabilities": {"batch": true, "maxBatchSize": 50, "notification": true}}, "id": 1}
+type Capability interface {
+	Name() string
+	// Negotiate returns the capability agreed from the client capability, which is nil if the client sent none.
+	Negotiate(clientCap json.RawMessage) json.RawMessage
+}
+
+var (
+	// BatchCapability tells the server accepts batch requests, unless the client sends false.
+	BatchCapability Capability = boolCapability("batch")
+	// NotificationCapability tells the server accepts notifications, unless the client sends false.
+	NotificationCapability Capability = boolCapability("notification")
+)
+
+// MaxBatchSizeCapability tells the max number of requests in a batch, the smaller of n and the client max batch size.
+func MaxBatchSizeCapability(n int) Capability {
+	return maxBatchSizeCapability(n)
+}
+
+func (s *server) RegisterCapability(cap Capability) {
+	s.methodsMu.Lock()
+	first := len(s.capabilities) == 0
+	s.capabilities = append(s.capabilities, cap)
+	s.methodsMu.Unlock()
+	if first {
+		s.defineBuiltins(MethodConfig{Name: initializeMethod, Handler: s.initialize})
+	}
+}
+
+// ============ Private members below =================
+
+type (
+	boolCapability string
+
+	maxBatchSizeCapability int
+
+	// initializeParams are the params and result of "rpc.initialize"
+	initializeParams struct {
+		Capabilities map[string]json.RawMessage `json:"capabilities"`
+	}
+)
+
+const initializeMethod = "rpc.initialize"
+
+func (c boolCapability) Name() string {
+	return string(c)
+}
+
+func (c boolCapability) Negotiate(clientCap json.RawMessage) json.RawMessage {
+	var enabled bool
+	if err := json.Unmarshal(clientCap, &enabled); err == nil && !enabled {
+		return json.RawMessage("false")
+	}
+	return json.RawMessage("true")
+}
+
+func (c maxBatchSizeCapability) Name() string {
+	return "maxBatchSize"
+}
+
+func (c maxBatchSizeCapability) Negotiate(clientCap json.RawMessage) json.RawMessage {
+	n := int(c)
+	var clientN int
+	if err := json.Unmarshal(clientCap, &clientN); err == nil && clientN > 0 && clientN < n {
+		n = clientN
+	}
+	b, _ := json.Marshal(n)
+	return b
+}
+
+func (s *server) initialize(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var client initializeParams
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &client); err != nil {
+			return nil, ErrInvalidParams
+		}
+	}
+	s.methodsMu.RLock()
+	capabilities := s.capabilities
+	s.methodsMu.RUnlock()
+	negotiated := initializeParams{Capabilities: make(map[string]json.RawMessage, len(capabilities))}
+	for _, cap := range capabilities {
+		negotiated.Capabilities[cap.Name()] = cap.Negotiate(client.Capabilities[cap.Name()])
+	}
+	return negotiated, nil
+}
@@ -0,0 +1,68 @@
+package jsonrpc2
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestServer_RegisterCapability(t *testing.T) {
+	server := NewServer()
+	var changes [][]string
+	server.OnMethodsChanged(func(added, removed []string) {
+		changes = append(changes, added)
+	})
+	require.False(t, server.MethodExists("rpc.initialize"))
+	// registered while serving requests
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 10; i++ {
+			server.ServeRequest(json.RawMessage(`{ "jsonrpc": "2.0", "method": "rpc.initialize", "id": 1 }`))
+		}
+	}()
+	server.RegisterCapability(BatchCapability)
+	server.RegisterCapability(NotificationCapability)
+	server.RegisterCapability(MaxBatchSizeCapability(100))
+	<-done
+	require.True(t, server.MethodExists("rpc.initialize"))
+	require.Equal(t, [][]string{{"rpc.initialize"}}, changes)
+	t.Run("without client capabilities", func(t *testing.T) {
+		rsp := server.ServeRequest(json.RawMessage(`{ "jsonrpc": "2.0", "method": "rpc.initialize", "id": 1 }`))
+		require.JSONEq(t, `{
+			"id": 1,
+			"jsonrpc": "2.0",
+			"result": {"capabilities": {"batch": true, "notification": true, "maxBatchSize": 100}}
+		}`, string(rsp))
+	})
+	t.Run("with client capabilities", func(t *testing.T) {
+		rsp := server.ServeRequest(json.RawMessage(`{
+			"jsonrpc": "2.0",
+			"method": "rpc.initialize",
+			"params": {"capabilities": {"batch": false, "maxBatchSize": 20, "unknown": {}}},
+			"id": 1
+		}`))
+		require.JSONEq(t, `{
+			"id": 1,
+			"jsonrpc": "2.0",
+			"result": {"capabilities": {"batch": false, "notification": true, "maxBatchSize": 20}}
+		}`, string(rsp))
+	})
+	t.Run("client max batch size is larger", func(t *testing.T) {
+		rsp := server.ServeRequest(json.RawMessage(`{
+			"jsonrpc": "2.0",
+			"method": "rpc.initialize",
+			"params": {"capabilities": {"maxBatchSize": 1000}},
+			"id": 1
+		}`))
+		require.JSONEq(t, `{
+			"id": 1,
+			"jsonrpc": "2.0",
+			"result": {"capabilities": {"batch": true, "notification": true, "maxBatchSize": 100}}
+		}`, string(rsp))
+	})
+	t.Run("invalid params", func(t *testing.T) {
+		rsp := server.ServeRequest(json.RawMessage(`{ "jsonrpc": "2.0", "method": "rpc.initialize", "params": [1], "id": 1 }`))
+		require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "error": {"code": -32602, "message": "Invalid Params"}}`, string(rsp))
+	})
+}
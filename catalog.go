@@ -0,0 +1,63 @@
+package jsonrpc2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrorCatalog holds the messages of the error codes by locale, e.g.
+//	jsonrpc2.ErrorCatalog{
+//		"fr": {jsonrpc2.CodeMethodNotFound: "Méthode introuvable", -32050: "Plus que {left} en stock"},
+//	}
+// The {name} placeholders are replaced by the members of the error data, e.g. {"left": 2}.
+type ErrorCatalog map[string]map[int]string
+
+// SetErrorCatalog translates the messages of the error responses to the locale returned by localeFromContext.
+// The code and data of the errors are unchanged, and the errors without translation keep their message.
+// localeFromContext receives the context of the request, which has the raw request, see RawRequestFromContext.
+//	server.SetErrorCatalog(catalog, func(ctx context.Context) string {
+//		var p struct{ Locale string `json:"locale"` }
+//		json.Unmarshal(jsonrpc2.RawRequestFromContext(ctx), &p)
+//		return p.Locale
+//	})
+func (s *server) SetErrorCatalog(catalog ErrorCatalog, localeFromContext func(ctx context.Context) string) {
+	s.catalog = catalog
+	s.localeFromContext = localeFromContext
+}
+
+// ============ Private members below =================
+
+// localize returns err with the message of the catalog for the locale of ctx, or err if there is none
+func (s *server) localize(ctx context.Context, err error) error {
+	if err == nil || s.catalog == nil || s.localeFromContext == nil {
+		return err
+	}
+	code, data := CodeServerError, interface{}(nil)
+	var e Error
+	if errors.As(err, &e) {
+		code, data = e.Code(), errorData(e)
+	}
+	msg, ok := s.catalog[s.localeFromContext(ctx)][code]
+	if !ok {
+		return err
+	}
+	return NewErrorWithData(code, expandMessage(msg, data), data)
+}
+
+// expandMessage replaces the {name} placeholders of msg by the members of data
+func expandMessage(msg string, data interface{}) string {
+	if data == nil || !strings.Contains(msg, "{") {
+		return msg
+	}
+	var members map[string]interface{}
+	if b, err := json.Marshal(data); err != nil || json.Unmarshal(b, &members) != nil {
+		return msg
+	}
+	for name, v := range members {
+		msg = strings.ReplaceAll(msg, "{"+name+"}", fmt.Sprint(v))
+	}
+	return msg
+}
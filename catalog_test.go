@@ -0,0 +1,44 @@
+package jsonrpc2
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestSetErrorCatalog(t *testing.T) {
+	server := NewServer()
+	server.DefineMethod("buy", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		return nil, NewErrorWithData(-32050, "Only few left in stock", map[string]int{"left": 2})
+	})
+	server.SetErrorCatalog(ErrorCatalog{
+		"fr": {
+			CodeMethodNotFound: "Méthode introuvable",
+			-32050:             "Plus que {left} en stock",
+		},
+		"de": {
+			CodeMethodNotFound: "Methode nicht gefunden",
+		},
+	}, func(ctx context.Context) string {
+		var p struct {
+			Locale string `json:"locale"`
+		}
+		json.Unmarshal(RawRequestFromContext(ctx), &p)
+		return p.Locale
+	})
+	rsp := server.ServeRequest(json.RawMessage(`[
+		{ "jsonrpc": "2.0", "method": "unknown", "id": 1, "locale": "fr" },
+		{ "jsonrpc": "2.0", "method": "unknown", "id": 2, "locale": "de" },
+		{ "jsonrpc": "2.0", "method": "buy", "id": 3, "locale": "fr" },
+		{ "jsonrpc": "2.0", "method": "buy", "id": 4, "locale": "de" },
+		{ "jsonrpc": "2.0", "method": "buy", "id": 5 }
+	]`))
+	require.JSONEq(t, `[
+		{"id": 1, "jsonrpc": "2.0", "error": {"code": -32601, "message": "Méthode introuvable"}},
+		{"id": 2, "jsonrpc": "2.0", "error": {"code": -32601, "message": "Methode nicht gefunden"}},
+		{"id": 3, "jsonrpc": "2.0", "error": {"code": -32050, "message": "Plus que 2 en stock", "data": {"left": 2}}},
+		{"id": 4, "jsonrpc": "2.0", "error": {"code": -32050, "message": "Only few left in stock", "data": {"left": 2}}},
+		{"id": 5, "jsonrpc": "2.0", "error": {"code": -32050, "message": "Only few left in stock", "data": {"left": 2}}}
+	]`, string(rsp))
+}
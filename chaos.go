@@ -0,0 +1,99 @@
+package jsonrpc2
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// ChaosConfig sets the probabilities of the faults injected by WithChaos.
+type ChaosConfig struct {
+	// ErrorRate is the probability of responding ErrInjectedFault instead of calling the handler
+	ErrorRate float64
+	// LatencyJitter is the max random delay before calling the handler
+	LatencyJitter time.Duration
+	// DropNotifications is the probability of not calling the handler of a notification
+	DropNotifications float64
+	// PerMethod overrides the config of some methods. The PerMethod and Seed of the overrides are ignored.
+	PerMethod map[string]ChaosConfig
+	// Seed makes the faults reproducible
+	Seed int64
+}
+
+// WithChaos injects faults in the requests to test the resilience of clients: random delays, ErrInjectedFault errors
+// and dropped notifications. The faults are off until ServerConfig.Chaos is enabled, so it can be toggled at runtime:
+//	server := jsonrpc2.NewServer(jsonrpc2.WithChaos(jsonrpc2.ChaosConfig{ErrorRate: 0.1, Seed: 1}))
+//	cfg := server.Config()
+//	cfg.Chaos = true
+//	server.ApplyConfig(cfg)
+// With the same seed, sequential requests get the same faults. Delays are measured by the server clock.
+func WithChaos(cfg ChaosConfig) ServerOption {
+	return func(s *server) {
+		// the option is applied again by Reset, which restarts the sequence of faults
+		c := &chaos{cfg: cfg, rand: rand.New(rand.NewSource(cfg.Seed))}
+		s.Use(func(next Handler) Handler {
+			return func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+				info := MethodCallInfo(ctx)
+				if !s.loadConfig().Chaos || info == nil {
+					return next(ctx, params)
+				}
+				f := c.fault(info.Method, info.RequestID == nil)
+				if f.drop {
+					return nil, nil
+				}
+				if f.delay > 0 {
+					select {
+					case <-ClockFromContext(ctx).After(f.delay):
+					case <-ctx.Done():
+						return nil, ctx.Err()
+					}
+				}
+				if f.err {
+					return nil, ErrInjectedFault
+				}
+				return next(ctx, params)
+			}
+		})
+	}
+}
+
+// ============ Private members below =================
+
+type (
+	chaos struct {
+		cfg  ChaosConfig
+		mu   sync.Mutex
+		rand *rand.Rand
+	}
+
+	// chaosFault is the fault drawn for a request
+	chaosFault struct {
+		drop  bool
+		delay time.Duration
+		err   bool
+	}
+)
+
+// fault draws the fault of a request, in order: drop, delay and error
+func (c *chaos) fault(method string, notification bool) chaosFault {
+	cfg := c.cfg
+	if override, ok := c.cfg.PerMethod[method]; ok {
+		cfg = override
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var f chaosFault
+	if notification && cfg.DropNotifications > 0 && c.rand.Float64() < cfg.DropNotifications {
+		f.drop = true
+		return f
+	}
+	if cfg.LatencyJitter > 0 {
+		f.delay = time.Duration(c.rand.Int63n(int64(cfg.LatencyJitter) + 1))
+	}
+	if cfg.ErrorRate > 0 {
+		f.err = c.rand.Float64() < cfg.ErrorRate
+	}
+	return f
+}
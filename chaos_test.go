@@ -0,0 +1,78 @@
+package jsonrpc2
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestWithChaos(t *testing.T) {
+	newChaosServer := func(cfg ChaosConfig) (Server, *int) {
+		calls := 0
+		server := NewServer(WithChaos(cfg))
+		server.DefineMethod("echo", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+			calls++
+			return params, nil
+		})
+		server.DefineMethod("fail", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+			return params, nil
+		})
+		return server, &calls
+	}
+	enable := func(server Server, enabled bool) {
+		cfg := server.Config()
+		cfg.Chaos = enabled
+		require.NoError(t, server.ApplyConfig(cfg))
+	}
+	// outcomes serves n requests sequentially and returns "E" for each ErrInjectedFault and "." for each result
+	outcomes := func(server Server, method string, n int) string {
+		s := ""
+		for i := 0; i < n; i++ {
+			_, rpcErr, err := ExtractResult(server.ServeRequest(json.RawMessage(`{ "jsonrpc": "2.0", "method": "` + method + `", "id": 1 }`)))
+			require.NoError(t, err)
+			if rpcErr != nil {
+				require.Equal(t, ErrInjectedFault.Code(), rpcErr.Code())
+				s += "E"
+			} else {
+				s += "."
+			}
+		}
+		return s
+	}
+	t.Run("off unless enabled", func(t *testing.T) {
+		server, _ := newChaosServer(ChaosConfig{ErrorRate: 1})
+		require.Equal(t, "...", outcomes(server, "echo", 3))
+		enable(server, true)
+		require.Equal(t, "EEE", outcomes(server, "echo", 3))
+		enable(server, false)
+		require.Equal(t, "...", outcomes(server, "echo", 3))
+	})
+	t.Run("errors with fixed seed", func(t *testing.T) {
+		server, _ := newChaosServer(ChaosConfig{ErrorRate: 0.5, Seed: 42})
+		enable(server, true)
+		require.Equal(t, "EE.EEE.EE.", outcomes(server, "echo", 10))
+	})
+	t.Run("drop notifications with fixed seed", func(t *testing.T) {
+		server, calls := newChaosServer(ChaosConfig{DropNotifications: 0.5, Seed: 42})
+		enable(server, true)
+		s := ""
+		for i := 0; i < 10; i++ {
+			before := *calls
+			require.Nil(t, server.ServeRequest(json.RawMessage(`{ "jsonrpc": "2.0", "method": "echo" }`)))
+			if *calls == before {
+				s += "D"
+			} else {
+				s += "."
+			}
+		}
+		require.Equal(t, "DD.DDD.DD.", s)
+		require.Equal(t, "..........", outcomes(server, "echo", 10))
+	})
+	t.Run("per method", func(t *testing.T) {
+		server, _ := newChaosServer(ChaosConfig{PerMethod: map[string]ChaosConfig{"fail": {ErrorRate: 1}}})
+		enable(server, true)
+		require.Equal(t, "...", outcomes(server, "echo", 3))
+		require.Equal(t, "EEE", outcomes(server, "fail", 3))
+	})
+}
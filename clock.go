@@ -0,0 +1,258 @@
+package jsonrpc2
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type (
+	// A Clock tells the time to the server: request timeouts, CallInfo.StartTime, nonce windows and initializer ETAs.
+	// Tests can set a MockClock to trigger timeouts without waiting.
+	Clock interface {
+		Now() time.Time
+		NewTimer(d time.Duration) Timer
+		After(d time.Duration) <-chan time.Time
+		Sleep(d time.Duration)
+	}
+
+	// A Timer sends the time on C once its duration elapsed, as time.Timer.
+	Timer interface {
+		C() <-chan time.Time
+		// Stop prevents the timer from firing. It returns false if the timer already fired or was stopped.
+		Stop() bool
+	}
+
+	// ManualClock is a Clock whose time only moves by Advance or Set, so timeouts trigger without waiting.
+	// Usage:
+	//	clock := jsonrpc2.MockClock()
+	//	server := jsonrpc2.NewServer(jsonrpc2.WithClock(clock))
+	//	go server.ServeRequest(req)
+	//	clock.BlockUntil(1) // the request timeout timer is started
+	//	clock.Advance(time.Second)
+	ManualClock struct {
+		mu      sync.Mutex
+		changed *sync.Cond
+		now     time.Time
+		timers  []*manualTimer
+	}
+)
+
+// WithClock sets the clock of the server, see Server.SetClock.
+func WithClock(c Clock) ServerOption {
+	return func(s *server) {
+		s.SetClock(c)
+	}
+}
+
+// RealClock returns the Clock of the real time, the default clock of servers.
+func RealClock() Clock {
+	return realClock{}
+}
+
+// MockClock returns a ManualClock starting at the current time.
+func MockClock() *ManualClock {
+	c := &ManualClock{now: time.Now()}
+	c.changed = sync.NewCond(&c.mu)
+	return c
+}
+
+// ClockFromContext returns the clock of the server serving ctx, or the real clock, e.g. to measure durations in
+// middlewares.
+func ClockFromContext(ctx context.Context) Clock {
+	if c, ok := ctx.Value(clockContextKey{}).(Clock); ok {
+		return c
+	}
+	return realClock{}
+}
+
+// SetClock sets the clock of the server, the real time if c is nil.
+func (s *server) SetClock(c Clock) {
+	if c == nil {
+		c = realClock{}
+	}
+	s.clock = c
+}
+
+func (c *ManualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *ManualClock) NewTimer(d time.Duration) Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := &manualTimer{clock: c, at: c.now.Add(d), c: make(chan time.Time, 1)}
+	if d <= 0 {
+		t.c <- c.now
+		return t
+	}
+	c.timers = append(c.timers, t)
+	c.changed.Broadcast()
+	return t
+}
+
+func (c *ManualClock) After(d time.Duration) <-chan time.Time {
+	return c.NewTimer(d).C()
+}
+
+// Sleep blocks until the time is advanced by d.
+func (c *ManualClock) Sleep(d time.Duration) {
+	<-c.After(d)
+}
+
+// Advance moves the time forward by d and fires the timers due.
+func (c *ManualClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.set(c.now.Add(d))
+}
+
+// Set sets the time and fires the timers due.
+func (c *ManualClock) Set(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.set(now)
+}
+
+// BlockUntil waits until at least n timers are pending, e.g. the timeout timers of the requests being served.
+func (c *ManualClock) BlockUntil(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for len(c.timers) < n {
+		c.changed.Wait()
+	}
+}
+
+// ============ Private members below =================
+
+type (
+	realClock struct{}
+
+	realTimer struct {
+		*time.Timer
+	}
+
+	manualTimer struct {
+		clock *ManualClock
+		at    time.Time
+		c     chan time.Time
+	}
+
+	// clockContextKey is the context key of the server clock, used by the middlewares of the package
+	clockContextKey struct{}
+
+	// timeoutContext is the parent context done with inner, which is cancelled when the timer of its clock fires,
+	// reporting context.DeadlineExceeded then. Its values are the ones of the parent, so the contexts derived from it
+	// watch its Done channel and copy its Err rather than the ones of inner.
+	timeoutContext struct {
+		context.Context
+		inner    context.Context
+		deadline time.Time
+
+		mu  sync.Mutex
+		err error
+	}
+)
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) NewTimer(d time.Duration) Timer {
+	return realTimer{time.NewTimer(d)}
+}
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+func (realClock) Sleep(d time.Duration) {
+	time.Sleep(d)
+}
+
+func (t realTimer) C() <-chan time.Time {
+	return t.Timer.C
+}
+
+// set sets the time and fires the timers due, c.mu must be held
+func (c *ManualClock) set(now time.Time) {
+	c.now = now
+	pending := c.timers[:0]
+	for _, t := range c.timers {
+		if t.at.After(c.now) {
+			pending = append(pending, t)
+		} else {
+			t.c <- c.now
+		}
+	}
+	c.timers = pending
+	c.changed.Broadcast()
+}
+
+func (t *manualTimer) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *manualTimer) Stop() bool {
+	c := t.clock
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, pending := range c.timers {
+		if pending == t {
+			c.timers = append(c.timers[:i], c.timers[i+1:]...)
+			c.changed.Broadcast()
+			return true
+		}
+	}
+	return false
+}
+
+// withTimeout is context.WithTimeout measured by clock
+func withTimeout(parent context.Context, clock Clock, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if _, ok := clock.(realClock); ok {
+		return context.WithTimeout(parent, timeout)
+	}
+	inner, cancel := context.WithCancel(parent)
+	ctx := &timeoutContext{Context: parent, inner: inner, deadline: clock.Now().Add(timeout)}
+	timer := clock.NewTimer(timeout)
+	go func() {
+		select {
+		case <-timer.C():
+			ctx.mu.Lock()
+			if inner.Err() == nil {
+				ctx.err = context.DeadlineExceeded
+			}
+			ctx.mu.Unlock()
+			cancel()
+		case <-inner.Done():
+			timer.Stop()
+		}
+	}()
+	// stop the timer at once, so it is not pending after the request is served
+	return ctx, func() {
+		timer.Stop()
+		cancel()
+	}
+}
+
+func (c *timeoutContext) Deadline() (time.Time, bool) {
+	if deadline, ok := c.Context.Deadline(); ok && deadline.Before(c.deadline) {
+		return deadline, true
+	}
+	return c.deadline, true
+}
+
+func (c *timeoutContext) Done() <-chan struct{} {
+	return c.inner.Done()
+}
+
+func (c *timeoutContext) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return c.err
+	}
+	return c.inner.Err()
+}
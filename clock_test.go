@@ -0,0 +1,284 @@
+package jsonrpc2_test
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"github/brianso/go-jsonrpc2"
+	"github/brianso/go-jsonrpc2/jsonrpc2test"
+	"testing"
+	"time"
+)
+
+// wait returns a handler responding "ok" once release is closed, or the error of its context. A nil release never closes.
+func wait(release <-chan struct{}) jsonrpc2.Handler {
+	return func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		select {
+		case <-release:
+			return "ok", nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// serve serves req in background, the response is sent on the returned channel
+func serve(server jsonrpc2.Server, req string) <-chan string {
+	rsp := make(chan string, 1)
+	go func() {
+		rsp <- string(server.ServeRequest(json.RawMessage(req)))
+	}()
+	return rsp
+}
+
+func TestServer_ServeRequestWithTimeout(t *testing.T) {
+	clock := jsonrpc2test.NewFakeClock(time.Now())
+	release := make(chan struct{})
+	server := jsonrpc2.NewServer()
+	server.SetClock(clock)
+	server.SetDefaultTimeout(5 * time.Millisecond)
+	server.DefineMethod("wait", wait(nil))
+	server.DefineMethod("echo", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		deadline, _ := ctx.Deadline()
+		require.Equal(t, clock.Now().Add(5*time.Millisecond), deadline)
+		return params, nil
+	})
+	t.Run("should not timeout", func(t *testing.T) {
+		rsp := server.ServeRequest(json.RawMessage(`{ "jsonrpc": "2.0", "method": "echo", "params": "ok", "id": "1" }`))
+		require.JSONEq(t, `{"id": "1", "jsonrpc": "2.0", "result": "ok"}`, string(rsp))
+	})
+	t.Run("should timeout", func(t *testing.T) {
+		rsp := serve(server, `{ "jsonrpc": "2.0", "method": "wait", "id": "1" }`)
+		clock.BlockUntil(1)
+		clock.Advance(4 * time.Millisecond)
+		select {
+		case <-rsp:
+			t.Fatal("timed out early")
+		default:
+		}
+		clock.Advance(time.Millisecond)
+		require.JSONEq(t, `{
+			"id": "1",
+			"jsonrpc": "2.0",
+			"error": { "code": -32000, "message":"context deadline exceeded" }
+		}`, <-rsp)
+	})
+	t.Run("should 1 timeout and 1 success in batch", func(t *testing.T) {
+		jsonrpc2.DefineMethodConfig(server, jsonrpc2.MethodConfig{Name: "waitLong", Handler: wait(release), Timeout: time.Hour})
+		rsp := serve(server, `[
+			{ "jsonrpc": "2.0", "method": "wait", "id": "1" },
+			{ "jsonrpc": "2.0", "method": "waitLong", "id": "2" }
+		]`)
+		clock.BlockUntil(2)
+		clock.Advance(5 * time.Millisecond)
+		close(release)
+		require.JSONEq(t, `[{
+			"id": "1",
+			"jsonrpc": "2.0",
+			"error": { "code": -32000, "message":"context deadline exceeded" }
+		}, {
+			"id": "2",
+			"jsonrpc": "2.0",
+			"result": "ok"
+		}]`, <-rsp)
+	})
+}
+
+func TestDefineMethodConfig_Timeout(t *testing.T) {
+	clock := jsonrpc2test.NewFakeClock(time.Now())
+	release := make(chan struct{})
+	server := jsonrpc2.NewServer()
+	server.SetClock(clock)
+	server.SetDefaultTimeout(5 * time.Millisecond)
+	jsonrpc2.DefineMethodConfig(server, jsonrpc2.MethodConfig{Name: "wait", Handler: wait(release), Timeout: time.Second})
+	rsp := serve(server, `{ "jsonrpc": "2.0", "method": "wait", "id": 1 }`)
+	clock.BlockUntil(1)
+	clock.Advance(5 * time.Millisecond)
+	close(release)
+	require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "result": "ok"}`, <-rsp)
+}
+
+func TestNonceMiddleware_Window(t *testing.T) {
+	clock := jsonrpc2test.NewFakeClock(time.Now())
+	server := jsonrpc2.NewServer()
+	server.SetClock(clock)
+	server.Use(jsonrpc2.NonceMiddleware(jsonrpc2.NewMemoryNonceStore(100), time.Minute))
+	server.DefineMethod("echo", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		return params, nil
+	})
+	req := json.RawMessage(`{ "jsonrpc": "2.0", "method": "echo", "params": "hi", "nonce": "a", "id": 1 }`)
+	require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "result": "hi"}`, string(server.ServeRequest(req)))
+	clock.Advance(time.Minute - time.Nanosecond)
+	require.JSONEq(t, `{
+		"id": 1,
+		"jsonrpc": "2.0",
+		"error": {"code": -32016, "message": "Replay detected"}
+	}`, string(server.ServeRequest(req)))
+	clock.Advance(time.Nanosecond)
+	require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "result": "hi"}`, string(server.ServeRequest(req)))
+}
+
+func TestMethodInitializer_ETA(t *testing.T) {
+	clock := jsonrpc2test.NewFakeClock(time.Now())
+	release := make(chan struct{})
+	defer close(release)
+	server := jsonrpc2.NewServer()
+	server.SetClock(clock)
+	jsonrpc2.DefineMethodConfig(server, jsonrpc2.MethodConfig{
+		Name:    "predict",
+		Handler: wait(nil),
+		Initializer: func(ctx context.Context) error {
+			<-release
+			return nil
+		},
+		InitDuration: time.Hour,
+	})
+	req := json.RawMessage(`{ "jsonrpc": "2.0", "method": "predict", "id": 1 }`)
+	require.JSONEq(t, `{
+		"id": 1,
+		"jsonrpc": "2.0",
+		"error": {"code": -32019, "message": "Warming up", "data": {"etaMs": 3600000}}
+	}`, string(server.ServeRequest(req)))
+	clock.Advance(10 * time.Minute)
+	require.JSONEq(t, `{
+		"id": 1,
+		"jsonrpc": "2.0",
+		"error": {"code": -32019, "message": "Warming up", "data": {"etaMs": 3000000}}
+	}`, string(server.ServeRequest(req)))
+}
+
+func TestMethodCallInfo_StartTime(t *testing.T) {
+	clock := jsonrpc2test.NewFakeClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	server := jsonrpc2.NewServer()
+	server.SetClock(clock)
+	server.DefineMethod("start", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		return jsonrpc2.MethodCallInfo(ctx).StartTime, nil
+	})
+	rsp := server.ServeRequest(json.RawMessage(`{ "jsonrpc": "2.0", "method": "start", "id": 1 }`))
+	require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "result": "2020-01-01T00:00:00Z"}`, string(rsp))
+}
+
+func TestWithChaos_LatencyJitter(t *testing.T) {
+	clock := jsonrpc2test.NewFakeClock(time.Now())
+	server := jsonrpc2.NewServer(jsonrpc2.WithChaos(jsonrpc2.ChaosConfig{LatencyJitter: 100, Seed: 42}))
+	server.SetClock(clock)
+	server.ApplyConfig(jsonrpc2.ServerConfig{Chaos: true})
+	server.DefineMethod("echo", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		return params, nil
+	})
+	// the first delay drawn with seed 42 is 6ns
+	rsp := serve(server, `{ "jsonrpc": "2.0", "method": "echo", "params": "hi", "id": 1 }`)
+	clock.BlockUntil(1)
+	clock.Advance(5)
+	select {
+	case <-rsp:
+		t.Fatal("responded before the delay")
+	default:
+	}
+	clock.Advance(1)
+	require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "result": "hi"}`, <-rsp)
+}
+
+func TestServer_SetServerTiming(t *testing.T) {
+	clock := jsonrpc2test.NewFakeClock(time.Now())
+	server := jsonrpc2.NewServer()
+	server.SetClock(clock)
+	server.DefineMethod("work", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		clock.Advance(37500 * time.Microsecond)
+		return "ok", nil
+	})
+	req := json.RawMessage(`{ "jsonrpc": "2.0", "method": "work", "id": 1 }`)
+	t.Run("disabled by default", func(t *testing.T) {
+		require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "result": "ok"}`, string(server.ServeRequest(req)))
+	})
+	t.Run("enabled", func(t *testing.T) {
+		server.SetServerTiming(true)
+		require.JSONEq(t, `{
+			"id": 1,
+			"jsonrpc": "2.0",
+			"result": "ok",
+			"serverTiming": {"queueMs": 0, "handlerMs": 37.5}
+		}`, string(server.ServeRequest(req)))
+		rsp := server.ServeRequest(json.RawMessage(`{ "jsonrpc": "2.0", "method": "unknown", "id": 1 }`))
+		require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "error": {"code": -32601, "message": "Method not found"}}`, string(rsp))
+	})
+	t.Run("disabled", func(t *testing.T) {
+		server.SetServerTiming(false)
+		require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "result": "ok"}`, string(server.ServeRequest(req)))
+	})
+}
+
+func TestManualClock(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	t.Run("advance fires due timers", func(t *testing.T) {
+		clock := jsonrpc2.MockClock()
+		clock.Set(start)
+		short, long := clock.NewTimer(time.Second), clock.NewTimer(time.Minute)
+		clock.Advance(time.Second)
+		require.Equal(t, start.Add(time.Second), clock.Now())
+		require.Equal(t, start.Add(time.Second), <-short.C())
+		select {
+		case <-long.C():
+			t.Fatal("timer fired early")
+		default:
+		}
+		clock.Set(start.Add(time.Hour))
+		require.Equal(t, start.Add(time.Hour), <-long.C())
+	})
+	t.Run("stop", func(t *testing.T) {
+		clock := jsonrpc2.MockClock()
+		timer := clock.NewTimer(time.Second)
+		require.True(t, timer.Stop())
+		require.False(t, timer.Stop())
+		clock.Advance(time.Second)
+		select {
+		case <-timer.C():
+			t.Fatal("stopped timer fired")
+		default:
+		}
+	})
+	t.Run("after and sleep", func(t *testing.T) {
+		clock := jsonrpc2.MockClock()
+		now := clock.Now()
+		require.Equal(t, now, <-clock.After(0))
+		slept := make(chan struct{})
+		go func() {
+			clock.Sleep(time.Second)
+			close(slept)
+		}()
+		clock.BlockUntil(1)
+		clock.Advance(time.Second)
+		<-slept
+	})
+}
+
+func TestWithClock(t *testing.T) {
+	clock := jsonrpc2.MockClock()
+	server := jsonrpc2.NewServer(jsonrpc2.WithClock(clock))
+	server.SetDefaultTimeout(time.Second)
+	server.DefineMethod("wait", wait(nil))
+	rsp := serve(server, `{ "jsonrpc": "2.0", "method": "wait", "id": 1 }`)
+	clock.BlockUntil(1)
+	clock.Advance(time.Second)
+	require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "error": {"code": -32000, "message": "context deadline exceeded"}}`, <-rsp)
+	require.Equal(t, jsonrpc2.RealClock(), jsonrpc2.ClockFromContext(context.Background()))
+
+	// the contexts derived by the handlers time out as with the real clock
+	type derivedErrs [2]error
+	errs := make(chan derivedErrs, 1)
+	server.DefineMethod("derive", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		cancelCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		timeoutCtx, cancelTimeout := context.WithTimeout(ctx, time.Hour)
+		defer cancelTimeout()
+		<-cancelCtx.Done()
+		<-timeoutCtx.Done()
+		errs <- derivedErrs{cancelCtx.Err(), timeoutCtx.Err()}
+		return nil, cancelCtx.Err()
+	})
+	rsp = serve(server, `{ "jsonrpc": "2.0", "method": "derive", "id": 1 }`)
+	clock.BlockUntil(1)
+	clock.Advance(time.Second)
+	require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "error": {"code": -32000, "message": "context deadline exceeded"}}`, <-rsp)
+	require.Equal(t, derivedErrs{context.DeadlineExceeded, context.DeadlineExceeded}, <-errs)
+}
@@ -0,0 +1,86 @@
+package jsonrpc2
+
+import (
+	"encoding/json"
+)
+
+// WithBatchCoalescing executes the identical elements of a batch request once, e.g. from an aggregation layer.
+// Elements are identical if they have the same method and params once canonicalized, see CanonicalizeJSON;
+// their other members, like extensions, are ignored. The result or error is responded to each id, and identical
+// notifications are executed once.
+func WithBatchCoalescing() ServerOption {
+	return func(s *server) {
+		s.coalesce = true
+	}
+}
+
+// ============ Private members below =================
+
+// rawResponse is a marshaled response, to change its id
+type rawResponse struct {
+	ID           json.RawMessage `json:"id"`
+	Version      string          `json:"jsonrpc"`
+	Result       json.RawMessage `json:"result,omitempty"`
+	Error        json.RawMessage `json:"error,omitempty"`
+	ServerTiming json.RawMessage `json:"serverTiming,omitempty"`
+}
+
+// batchLeaders returns the index of the element executed for each element of rs, its own index unless coalesced.
+// The leader of identical elements is the first with an id, so its response can be copied.
+func (s *server) batchLeaders(rs []json.RawMessage) []int {
+	leaders := make([]int, len(rs))
+	for i := range rs {
+		leaders[i] = i
+	}
+	if !s.coalesce {
+		return leaders
+	}
+	groups := map[string]int{}
+	hasID := make([]bool, len(rs))
+	keys := make([]string, len(rs))
+	for i, raw := range rs {
+		var r request
+		if err := json.Unmarshal(raw, &r); err != nil || s.validateRequest(r) != nil {
+			continue
+		}
+		key := r.Method
+		if r.Params != nil {
+			params, err := CanonicalizeJSON(r.Params)
+			if err != nil {
+				continue
+			}
+			key += "\n" + string(params)
+		}
+		keys[i], hasID[i] = key, r.ID != nil
+		if l, ok := groups[key]; !ok || !hasID[l] && hasID[i] {
+			groups[key] = i
+		}
+	}
+	for i, key := range keys {
+		if key != "" {
+			leaders[i] = groups[key]
+		}
+	}
+	return leaders
+}
+
+// requestID returns the id of the request raw, nil for a notification
+func requestID(raw json.RawMessage) json.RawMessage {
+	var r request
+	json.Unmarshal(raw, &r)
+	return r.ID
+}
+
+// withID returns rsp responded to the request with id, nil for a notification
+func withID(rsp json.RawMessage, id json.RawMessage) json.RawMessage {
+	if rsp == nil || id == nil {
+		return nil
+	}
+	var r rawResponse
+	if err := json.Unmarshal(rsp, &r); err != nil {
+		return rsp
+	}
+	r.ID = id
+	b, _ := json.Marshal(r)
+	return b
+}
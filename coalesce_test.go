@@ -0,0 +1,68 @@
+package jsonrpc2
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"sync/atomic"
+	"testing"
+)
+
+func TestWithBatchCoalescing(t *testing.T) {
+	var calls int32
+	counting := func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		atomic.AddInt32(&calls, 1)
+		if string(params) == `"fail"` {
+			return nil, ErrInvalidParams
+		}
+		return params, nil
+	}
+	batch := json.RawMessage(`[
+		{ "jsonrpc": "2.0", "method": "get", "params": {"a": 1, "b": 2}, "id": 1 },
+		{ "jsonrpc": "2.0", "method": "get", "params": {"b": 2, "a": 1.0}, "id": 2 },
+		{ "jsonrpc": "2.0", "method": "get", "params": {"a": 1, "b": 2}, "id": "x" },
+		{ "jsonrpc": "2.0", "method": "get", "params": "fail", "id": 3 },
+		{ "jsonrpc": "2.0", "method": "get", "params": "fail", "id": 4 },
+		{ "jsonrpc": "2.0", "method": "get", "params": "fail" },
+		{ "jsonrpc": "2.0", "method": "get", "params": [1], "id": 5 },
+		{ "jsonrpc": "2.0", "method": "get", "params": [1], "id": 6 },
+		{ "jsonrpc": "2.0", "method": "get", "params": [1] },
+		{ "jsonrpc": "2.0", "method": "get", "params": [ 1 ], "id": 7 }
+	]`)
+	expected := `[
+		{"id": 1, "jsonrpc": "2.0", "result": {"a": 1, "b": 2}},
+		{"id": 2, "jsonrpc": "2.0", "result": {"a": 1, "b": 2}},
+		{"id": "x", "jsonrpc": "2.0", "result": {"a": 1, "b": 2}},
+		{"id": 3, "jsonrpc": "2.0", "error": {"code": -32602, "message": "Invalid Params"}},
+		{"id": 4, "jsonrpc": "2.0", "error": {"code": -32602, "message": "Invalid Params"}},
+		{"id": 5, "jsonrpc": "2.0", "result": [1]},
+		{"id": 6, "jsonrpc": "2.0", "result": [1]},
+		{"id": 7, "jsonrpc": "2.0", "result": [1]}
+	]`
+	t.Run("execute identical elements once", func(t *testing.T) {
+		atomic.StoreInt32(&calls, 0)
+		server := NewServer(WithBatchCoalescing())
+		server.DefineMethod("get", counting)
+		require.JSONEq(t, expected, string(server.ServeRequest(batch)))
+		require.Equal(t, int32(3), atomic.LoadInt32(&calls))
+	})
+	t.Run("notifications are executed once", func(t *testing.T) {
+		atomic.StoreInt32(&calls, 0)
+		server := NewServer(WithBatchCoalescing())
+		server.DefineMethod("get", counting)
+		rsp := server.ServeRequest(json.RawMessage(`[
+			{ "jsonrpc": "2.0", "method": "get", "params": 1 },
+			{ "jsonrpc": "2.0", "method": "get", "params": 1 },
+			{ "jsonrpc": "2.0", "method": "get", "params": 2 }
+		]`))
+		require.Nil(t, rsp)
+		require.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	})
+	t.Run("disabled by default", func(t *testing.T) {
+		atomic.StoreInt32(&calls, 0)
+		server := NewServer()
+		server.DefineMethod("get", counting)
+		require.JSONEq(t, expected, string(server.ServeRequest(batch)))
+		require.Equal(t, int32(10), atomic.LoadInt32(&calls))
+	})
+}
@@ -0,0 +1,138 @@
+package jsonrpc2
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ConflictPolicy tells how a Builder resolves the methods defined by several sources.
+type ConflictPolicy int
+
+const (
+	// ConflictError fails the build, listing the conflicting methods and their sources
+	ConflictError ConflictPolicy = iota
+	// ConflictFirstWins keeps the method of the first source added, the others are dropped
+	ConflictFirstWins
+	// ConflictPrefix defines the conflicting methods of each source as "<source>.<method>"
+	ConflictPrefix
+)
+
+// Builder composes a server from the methods of several sources, e.g. the Register functions of packages, detecting
+// the methods defined by several sources instead of silently overwriting them. Each source defines its methods on its
+// own staging Registrar, they are defined on the server by Build. The source of each method is exported by
+// Server.ExportManifest.
+//	server, err := jsonrpc2.NewBuilder(jsonrpc2.ConflictPrefix).
+//		Add("billing", billing.Register).
+//		Add("crm", crm.Register).
+//		Build()
+type Builder struct {
+	policy  ConflictPolicy
+	opts    []ServerOption
+	sources []methodSource
+}
+
+// Compose returns a server with the methods of sources, failing if several sources define the same method. The
+// sources are named by their index, see Builder.
+func Compose(sources ...func(Registrar) error) (Server, error) {
+	b := NewBuilder(ConflictError)
+	for i, register := range sources {
+		b.Add(strconv.Itoa(i), register)
+	}
+	return b.Build()
+}
+
+// NewBuilder returns a Builder resolving the conflicts by policy, building its server with opts.
+func NewBuilder(policy ConflictPolicy, opts ...ServerOption) *Builder {
+	return &Builder{policy: policy, opts: opts}
+}
+
+// Add adds the methods defined by register, under the source name.
+func (b *Builder) Add(name string, register func(Registrar) error) *Builder {
+	b.sources = append(b.sources, methodSource{name: name, register: register})
+	return b
+}
+
+// Build returns the server with the methods of the sources. It fails on the first error of a source, on an invalid
+// method name, and on conflicts with ConflictError.
+func (b *Builder) Build() (_ Server, err error) {
+	staged := make([]map[string]MethodConfig, len(b.sources))
+	definedBy := map[string][]int{}
+	for i, src := range b.sources {
+		// the names are checked by the built server, with its options
+		staging := NewServer(AllowReserved(), WithMethodValidator(nil)).(*server)
+		if err := src.registerSafely(staging); err != nil {
+			return nil, fmt.Errorf("jsonrpc2: source %q: %w", src.name, err)
+		}
+		staged[i] = staging.methods
+		for method := range staging.methods {
+			definedBy[method] = append(definedBy[method], i)
+		}
+	}
+
+	conflicts := []string{}
+	for method, sources := range definedBy {
+		if len(sources) > 1 {
+			conflicts = append(conflicts, method)
+		}
+	}
+	sort.Strings(conflicts)
+	if len(conflicts) > 0 && b.policy == ConflictError {
+		described := make([]string, len(conflicts))
+		for i, method := range conflicts {
+			names := make([]string, len(definedBy[method]))
+			for j, src := range definedBy[method] {
+				names[j] = strconv.Quote(b.sources[src].name)
+			}
+			described[i] = fmt.Sprintf("%s by %s", method, strings.Join(names, ", "))
+		}
+		return nil, fmt.Errorf("jsonrpc2: methods defined by several sources: %s", strings.Join(described, "; "))
+	}
+
+	var methods []MethodConfig
+	for i, src := range b.sources {
+		for method, cfg := range staged[i] {
+			sources := definedBy[method]
+			if len(sources) > 1 {
+				if b.policy == ConflictFirstWins && sources[0] != i {
+					continue
+				}
+				if b.policy == ConflictPrefix {
+					cfg.Name = src.name + "." + method
+				}
+			}
+			cfg.source = src.name
+			methods = append(methods, cfg)
+		}
+	}
+	sort.Slice(methods, func(i, j int) bool {
+		return methods[i].Name < methods[j].Name
+	})
+	s := NewServer(b.opts...).(*server)
+	// the invalid names panic, as in DefineMethod
+	defer func() {
+		if v := recover(); v != nil {
+			err = fmt.Errorf("%v", v)
+		}
+	}()
+	s.defineMethods(methods)
+	return s, nil
+}
+
+// ============ Private members below =================
+
+type methodSource struct {
+	name     string
+	register func(Registrar) error
+}
+
+// registerSafely calls the register function of src, returning its panics as errors, e.g. a nil handler
+func (src methodSource) registerSafely(r Registrar) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = fmt.Errorf("%v", v)
+		}
+	}()
+	return src.register(r)
+}
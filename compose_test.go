@@ -0,0 +1,103 @@
+package jsonrpc2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestBuilder(t *testing.T) {
+	name := func(name string) Handler {
+		return func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+			return name, nil
+		}
+	}
+	billing := func(r Registrar) error {
+		r.DefineMethod("status", name("billing status"))
+		r.Namespace("invoice").DefineMethod("get", name("billing invoice.get"))
+		return nil
+	}
+	crm := func(r Registrar) error {
+		r.DefineMethod("status", name("crm status"))
+		r.DefineMethod("contact.get", name("crm contact.get"))
+		return nil
+	}
+	call := func(server Server, method string) string {
+		rsp := server.ServeRequest(json.RawMessage(`{"jsonrpc": "2.0", "method": "` + method + `", "id": 1}`))
+		var r struct {
+			Result string `json:"result"`
+		}
+		require.NoError(t, json.Unmarshal(rsp, &r), string(rsp))
+		return r.Result
+	}
+	sources := func(server Server) map[string]string {
+		b, err := server.ExportManifest()
+		require.NoError(t, err)
+		var m ServerManifest
+		require.NoError(t, json.Unmarshal(b, &m))
+		sources := map[string]string{}
+		for _, method := range m.Methods {
+			sources[method.Name] = method.Source
+		}
+		return sources
+	}
+
+	t.Run("error", func(t *testing.T) {
+		_, err := NewBuilder(ConflictError).Add("billing", billing).Add("crm", crm).Build()
+		require.EqualError(t, err, `jsonrpc2: methods defined by several sources: status by "billing", "crm"`)
+		_, err = Compose(billing, crm)
+		require.EqualError(t, err, `jsonrpc2: methods defined by several sources: status by "0", "1"`)
+	})
+	t.Run("first wins", func(t *testing.T) {
+		server, err := NewBuilder(ConflictFirstWins).Add("billing", billing).Add("crm", crm).Build()
+		require.NoError(t, err)
+		require.Equal(t, "billing status", call(server, "status"))
+		require.Equal(t, "crm contact.get", call(server, "contact.get"))
+		require.Equal(t, map[string]string{
+			"status":      "billing",
+			"invoice.get": "billing",
+			"contact.get": "crm",
+		}, sources(server))
+	})
+	t.Run("prefix", func(t *testing.T) {
+		server, err := NewBuilder(ConflictPrefix).Add("billing", billing).Add("crm", crm).Build()
+		require.NoError(t, err)
+		require.Equal(t, "billing status", call(server, "billing.status"))
+		require.Equal(t, "crm status", call(server, "crm.status"))
+		require.False(t, server.MethodExists("status"))
+		require.Equal(t, map[string]string{
+			"billing.status": "billing",
+			"crm.status":     "crm",
+			"invoice.get":    "billing",
+			"contact.get":    "crm",
+		}, sources(server))
+	})
+	t.Run("no conflict", func(t *testing.T) {
+		server, err := Compose(billing)
+		require.NoError(t, err)
+		require.Equal(t, []string{"invoice.get", "status"}, server.Methods())
+	})
+	t.Run("invalid sources", func(t *testing.T) {
+		_, err := Compose(billing, func(r Registrar) error {
+			return errors.New("no database")
+		})
+		require.EqualError(t, err, `jsonrpc2: source "1": no database`)
+		_, err = Compose(func(r Registrar) error {
+			r.DefineMethod("echo", nil)
+			return nil
+		})
+		require.EqualError(t, err, `jsonrpc2: source "0": jsonrpc2: nil handler for method "echo"`)
+		_, err = Compose(func(r Registrar) error {
+			r.DefineMethod("rpc.echo", name("echo"))
+			return nil
+		})
+		require.EqualError(t, err, `jsonrpc2: method "rpc.echo" is reserved, see AllowReserved`)
+		_, err = NewBuilder(ConflictError, AllowReserved()).Add("debug", func(r Registrar) error {
+			r.DefineMethod("rpc.echo", name("echo"))
+			return nil
+		}).Build()
+		require.NoError(t, err)
+	})
+}
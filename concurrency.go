@@ -0,0 +1,59 @@
+package jsonrpc2
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// WithMethodConcurrency limits the number of requests of method handled at once, so an expensive method
+// cannot starve the others. The requests over the limit wait until a handler returns, or respond ErrServiceBusy
+// when their context is done, e.g. on timeout. The requests getting a slot with too little of their timeout left
+// respond the timeout error of a handler without calling theirs, see ServerConfig.MinQueueBudget.
+// A non-positive limit means no limit, removing the limit of method set by a previous option.
+//	server := jsonrpc2.NewServer(jsonrpc2.WithMethodConcurrency("pdf.generate", 4))
+func WithMethodConcurrency(method string, limit int) ServerOption {
+	return func(s *server) {
+		if limit <= 0 {
+			delete(s.concurrency, method)
+			return
+		}
+		s.concurrency[method] = make(chan struct{}, limit)
+	}
+}
+
+func (s *server) SetMinQueueBudget(d time.Duration) {
+	s.updateConfig(func(cfg *ServerConfig) {
+		cfg.MinQueueBudget = d
+	})
+}
+
+// ============ Private members below =================
+
+// limitConcurrency waits for a free slot of the method if it has a concurrency limit, and returns h releasing the slot
+// when it returns, which may be after a timeout. It returns ErrServiceBusy if ctx is done first, and
+// context.DeadlineExceeded if less than minBudget is left before the deadline of ctx once the slot is free.
+func (s *server) limitConcurrency(ctx context.Context, method string, h Handler, minBudget time.Duration) (Handler, error) {
+	sem, ok := s.concurrency[method]
+	if !ok {
+		return h, nil
+	}
+	select {
+	case sem <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ErrServiceBusy
+	}
+	if err := ctx.Err(); err != nil && err != context.DeadlineExceeded {
+		<-sem
+		return nil, ErrServiceBusy
+	}
+	if deadline, ok := ctx.Deadline(); ctx.Err() != nil || ok && deadline.Sub(s.clock.Now()) < minBudget {
+		<-sem
+		s.metrics.expire()
+		return nil, context.DeadlineExceeded
+	}
+	return func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		defer func() { <-sem }()
+		return h(ctx, params)
+	}, nil
+}
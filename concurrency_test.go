@@ -0,0 +1,134 @@
+package jsonrpc2
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"github.com/stretchr/testify/require"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestServerConfig_MinQueueBudget(t *testing.T) {
+	clock := MockClock()
+	namespace := uniqueExpvarName("test.queue")
+	server := NewServer(WithClock(clock), WithExpvarMetrics(namespace), WithMethodConcurrency("slow", 1))
+	server.SetDefaultTimeout(time.Second)
+	server.SetMinQueueBudget(500 * time.Millisecond)
+	var calls atomic.Int32
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	server.DefineMethod("slow", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		calls.Add(1)
+		started <- struct{}{}
+		<-release
+		return "done", nil
+	})
+	value := func(name string) string {
+		return expvar.Get(namespace + "." + name).String()
+	}
+	serve := func() <-chan json.RawMessage {
+		rsp := make(chan json.RawMessage, 1)
+		go func() {
+			rsp <- server.ServeRequest(json.RawMessage(`{"jsonrpc": "2.0", "method": "slow", "id": 1}`))
+		}()
+		return rsp
+	}
+	expired := `{"id": 1, "jsonrpc": "2.0", "error": {"code": -32000, "message": "context deadline exceeded"}}`
+	busy := `{"id": 1, "jsonrpc": "2.0", "error": {"code": -32000, "message": "Service busy"}}`
+
+	// the queued requests get a slot with less than the budget left
+	first := serve()
+	<-started
+	queued := []<-chan json.RawMessage{serve(), serve()}
+	waitFor(t, func() bool { return value("queue_depth") == "2" })
+	clock.Advance(600 * time.Millisecond)
+	release <- struct{}{}
+	require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "result": "done"}`, string(<-first))
+	for _, rsp := range queued {
+		require.JSONEq(t, expired, string(<-rsp))
+	}
+	require.Equal(t, int32(1), calls.Load())
+	require.Equal(t, "2", value("expired_in_queue"))
+
+	// the queued request times out while waiting
+	first = serve()
+	<-started
+	rsp := serve()
+	waitFor(t, func() bool { return value("queue_depth") == "1" })
+	clock.Advance(time.Second)
+	require.JSONEq(t, busy, string(<-rsp))
+	close(release)
+	<-first
+	require.Equal(t, int32(2), calls.Load())
+	require.Equal(t, "2", value("expired_in_queue"))
+
+	// enough budget left
+	server.SetMinQueueBudget(0)
+	require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "result": "done"}`, string(<-serve()))
+	require.Equal(t, "2", value("expired_in_queue"))
+}
+
+func TestWithMethodConcurrency(t *testing.T) {
+	clock := MockClock()
+	release := make(chan struct{})
+	server := NewServer(WithMethodConcurrency("resize", 1))
+	server.SetClock(clock)
+	server.SetDefaultTimeout(5 * time.Millisecond)
+	server.DefineMethod("resize", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		<-release
+		return "ok", nil
+	})
+	server.DefineMethod("echo", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		return params, nil
+	})
+	serve := func() <-chan json.RawMessage {
+		rsp := make(chan json.RawMessage, 1)
+		go func() {
+			rsp <- server.ServeRequest(json.RawMessage(`{ "jsonrpc": "2.0", "method": "resize", "id": 1 }`))
+		}()
+		return rsp
+	}
+
+	first := serve()
+	clock.BlockUntil(1)
+	second := serve()
+	clock.BlockUntil(2)
+	rsp := server.ServeRequest(json.RawMessage(`{ "jsonrpc": "2.0", "method": "echo", "params": "hi", "id": 1 }`))
+	require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "result": "hi"}`, string(rsp))
+
+	clock.Advance(5 * time.Millisecond)
+	require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "error": {"code": -32000, "message": "context deadline exceeded"}}`, string(<-first))
+	require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "error": {"code": -32000, "message": "Service busy"}}`, string(<-second))
+
+	// the first handler still holds the semaphore until it returns
+	close(release)
+	require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "result": "ok"}`, string(<-serve()))
+}
+
+func TestWithMethodConcurrency_NoLimit(t *testing.T) {
+	for _, limit := range []int{0, -1} {
+		started := make(chan struct{})
+		release := make(chan struct{})
+		server := NewServer(WithMethodConcurrency("resize", 1), WithMethodConcurrency("resize", limit))
+		server.DefineMethod("resize", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+			started <- struct{}{}
+			<-release
+			return "ok", nil
+		})
+		rsp := make(chan json.RawMessage, 2)
+		for i := 0; i < 2; i++ {
+			go func() {
+				rsp <- server.ServeRequest(json.RawMessage(`{ "jsonrpc": "2.0", "method": "resize", "id": 1 }`))
+			}()
+		}
+		// both handlers run at once
+		<-started
+		<-started
+		close(release)
+		for i := 0; i < 2; i++ {
+			require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "result": "ok"}`, string(<-rsp), "limit %d", limit)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package jsonrpc2
+
+import (
+	"errors"
+	"time"
+)
+
+// ServerConfig holds the runtime configuration of a server. Zero values mean no limit.
+// Usage:
+//	cfg := server.Config()
+//	cfg.DefaultTimeout = 2 * time.Second
+//	if err := server.ApplyConfig(cfg); err != nil { ... }
+type ServerConfig struct {
+	// DefaultTimeout is the timeout of the methods without their own
+	DefaultTimeout time.Duration
+	// MaxResponseBytes limits each response, see ErrResponseTooLarge
+	MaxResponseBytes int
+	// MaxBatchResponseBytes limits the assembled batch response, see ErrResponseTooLarge
+	MaxBatchResponseBytes int
+	// ServerTiming adds the time spent on the server to the responses as the "serverTiming" extension member:
+	//	{"jsonrpc": "2.0", "result": 19, "id": 1, "serverTiming": {"queueMs": 1.2, "handlerMs": 37.5}}
+	// It is disabled by default, so strict clients only receive standard members.
+	ServerTiming bool
+	// ValidateResponses replaces the results not matching the MethodConfig.ResultSchema of their method by
+	// ErrInternalServerError, with the mismatch as data: {"validation": "$.id: expected integer, got string"}.
+	// It is meant for development, as it costs a marshaling of the results.
+	ValidateResponses bool
+	// TimeoutGrace is the time the handlers have to return after their context is cancelled by a timeout or Close,
+	// before they are abandoned: the request responds its timeout, and the handler is reported by OnAbandon.
+	// A handler returning within the grace responds its own result. 0 responds at once without tracking the handlers.
+	TimeoutGrace time.Duration
+	// SlowThreshold is the duration above which the requests are reported as slow, even if they succeed,
+	// see OnSlowRequest. 0 disables the reports.
+	SlowThreshold time.Duration
+	// MinQueueBudget rejects the requests of the methods limited by WithMethodConcurrency whose remaining timeout is
+	// below it once they get a slot, as they would time out anyway. They respond the timeout error of a handler without
+	// calling theirs, and are counted as expired in queue by WithExpvarMetrics. The requests whose timeout passes
+	// while waiting respond ErrServiceBusy.
+	MinQueueBudget time.Duration
+	// StreamingBatchResponses lets the connection-oriented transports, e.g. ServeStdioMultiplex, write the response of
+	// each element of a batch as its own message as soon as it is ready, instead of the whole batch response once the
+	// slowest element is done. MaxBatchResponseBytes does not apply to the streamed responses. ServeRequest keeps
+	// responding whole batches.
+	StreamingBatchResponses bool
+	// BatchEndMarker sends the "rpc.batchEnd" notification after the streamed responses of a batch, with the number
+	// of elements of the batch: {"jsonrpc": "2.0", "method": "rpc.batchEnd", "params": {"size": 3}}
+	BatchEndMarker bool
+	// ValidateUTF8 rejects the requests with invalid UTF-8 by ErrParseError, with the offset of the first invalid byte as
+	// data: {"offset": 42, "detail": "invalid UTF-8"}. Otherwise the invalid bytes of the strings are decoded as U+FFFD.
+	ValidateUTF8 bool
+	// LogNotificationErrors logs the suppressed error responses of the notifications at WARN level by the logger of the
+	// request, see LoggerFromContext and OnError
+	LogNotificationErrors bool
+	// Chaos enables the faults injected by WithChaos
+	Chaos bool
+}
+
+// Validate returns an error if cfg has invalid values.
+func (cfg ServerConfig) Validate() error {
+	if cfg.DefaultTimeout < 0 {
+		return errors.New("jsonrpc2: negative DefaultTimeout")
+	}
+	if cfg.MaxResponseBytes < 0 {
+		return errors.New("jsonrpc2: negative MaxResponseBytes")
+	}
+	if cfg.TimeoutGrace < 0 {
+		return errors.New("jsonrpc2: negative TimeoutGrace")
+	}
+	if cfg.SlowThreshold < 0 {
+		return errors.New("jsonrpc2: negative SlowThreshold")
+	}
+	if cfg.MinQueueBudget < 0 {
+		return errors.New("jsonrpc2: negative MinQueueBudget")
+	}
+	if cfg.MaxBatchResponseBytes < 0 {
+		return errors.New("jsonrpc2: negative MaxBatchResponseBytes")
+	}
+	return nil
+}
+
+func (s *server) ApplyConfig(cfg ServerConfig) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	s.configMu.Lock()
+	defer s.configMu.Unlock()
+	s.config.Store(&cfg)
+	return nil
+}
+
+func (s *server) Config() ServerConfig {
+	return *s.loadConfig()
+}
+
+// ============ Private members below =================
+
+// loadConfig returns the current config, requests use a single snapshot so they observe a consistent config
+func (s *server) loadConfig() *ServerConfig {
+	return s.config.Load().(*ServerConfig)
+}
+
+// updateConfig replaces the config by a copy modified by update
+func (s *server) updateConfig(update func(cfg *ServerConfig)) {
+	s.configMu.Lock()
+	defer s.configMu.Unlock()
+	cfg := *s.loadConfig()
+	update(&cfg)
+	s.config.Store(&cfg)
+}
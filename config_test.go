@@ -0,0 +1,64 @@
+package jsonrpc2
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestServer_ApplyConfig(t *testing.T) {
+	server := NewServer()
+	server.DefineMethod("repeat", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		var n int
+		json.Unmarshal(params, &n)
+		return strings.Repeat("a", n), nil
+	})
+	req := json.RawMessage(`{ "jsonrpc": "2.0", "method": "repeat", "params": 10, "id": 1 }`)
+	t.Run("setters update config", func(t *testing.T) {
+		server.SetDefaultTimeout(time.Second)
+		server.SetMaxResponseBytes(100)
+		server.SetMaxBatchResponseBytes(1000)
+		require.Equal(t, ServerConfig{
+			DefaultTimeout:        time.Second,
+			MaxResponseBytes:      100,
+			MaxBatchResponseBytes: 1000,
+		}, server.Config())
+	})
+	t.Run("invalid config is rejected", func(t *testing.T) {
+		before := server.Config()
+		require.Error(t, server.ApplyConfig(ServerConfig{DefaultTimeout: -1}))
+		require.Error(t, server.ApplyConfig(ServerConfig{MaxResponseBytes: -1}))
+		require.Error(t, server.ApplyConfig(ServerConfig{MaxBatchResponseBytes: -1}))
+		require.Equal(t, before, server.Config())
+	})
+	t.Run("change limits under load", func(t *testing.T) {
+		require.NoError(t, server.ApplyConfig(ServerConfig{}))
+		stop := make(chan struct{})
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for {
+					select {
+					case <-stop:
+						return
+					default:
+						server.ServeRequest(req)
+					}
+				}
+			}()
+		}
+		require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "result": "aaaaaaaaaa"}`, string(server.ServeRequest(req)))
+		require.NoError(t, server.ApplyConfig(ServerConfig{MaxResponseBytes: 40}))
+		_, rpcErr, err := ExtractResult(server.ServeRequest(req))
+		require.NoError(t, err)
+		require.Equal(t, ErrResponseTooLarge.Code(), rpcErr.Code())
+		close(stop)
+		wg.Wait()
+	})
+}
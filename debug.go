@@ -0,0 +1,129 @@
+package jsonrpc2
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// DebugConfig configures the debug methods, see EnableDebugMethods.
+type DebugConfig struct {
+	// MaxSleep caps the duration of rpc.sleep, 10 seconds if 0
+	MaxSleep time.Duration
+	// Discoverable lists the debug methods in rpc.listMethods and rpc.describe, they are hidden by default
+	Discoverable bool
+}
+
+// EnableDebugMethods defines the methods testing the connectivity to the server, e.g. through proxies:
+//	rpc.echo({"a": 1})                        -> {"a": 1}, the params verbatim
+//	rpc.sleep({"ms": 500})                    -> {"sleptMs": 500}, sleeps up to DebugConfig.MaxSleep
+//	rpc.error({"code": -32050, "message": "x"}) -> the error {"code": -32050, "message": "x"}
+// rpc.sleep and rpc.error also take their params by position, as [500] and [-32050, "x"]. rpc.error rejects the codes
+// reserved by the specification, except the server errors from -32099 to -32000.
+func EnableDebugMethods(cfg DebugConfig) ServerOption {
+	if cfg.MaxSleep == 0 {
+		cfg.MaxSleep = defaultMaxDebugSleep
+	}
+	return func(s *server) {
+		s.defineBuiltins(MethodConfig{
+			Name:    echoMethod,
+			Handler: debugEcho,
+			Doc:     "Respond the params verbatim",
+			Hidden:  !cfg.Discoverable,
+		}, MethodConfig{
+			Name: sleepMethod,
+			Handler: func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+				return s.debugSleep(ctx, params, cfg.MaxSleep)
+			},
+			Doc:          "Sleep for the milliseconds given, up to a limit, and respond the milliseconds slept",
+			ParamsSchema: json.RawMessage(`{"type":"object","properties":{"ms":{"type":"integer"}},"required":["ms"]}`),
+			ResultSchema: json.RawMessage(`{"type":"object","properties":{"sleptMs":{"type":"integer"}}}`),
+			Hidden:       !cfg.Discoverable,
+		}, MethodConfig{
+			Name:         errorMethod,
+			Handler:      debugError,
+			Doc:          "Respond the error given",
+			ParamsSchema: json.RawMessage(`{"type":"object","properties":{"code":{"type":"integer"},"message":{"type":"string"}},"required":["code"]}`),
+			Hidden:       !cfg.Discoverable,
+		})
+	}
+}
+
+// ============ Private members below =================
+
+const (
+	echoMethod  = "rpc.echo"
+	sleepMethod = "rpc.sleep"
+	errorMethod = "rpc.error"
+
+	defaultMaxDebugSleep = 10 * time.Second
+	// maxDebugMessage is the longest message of rpc.error, in bytes
+	maxDebugMessage = 1024
+)
+
+func debugEcho(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	if params == nil {
+		return json.RawMessage("null"), nil
+	}
+	return params, nil
+}
+
+// debugSleep sleeps by the server clock until the duration requested, capped by max, or the request is cancelled
+func (s *server) debugSleep(ctx context.Context, params json.RawMessage, max time.Duration) (interface{}, error) {
+	var byPosition []int64
+	var byName struct {
+		Ms *int64 `json:"ms"`
+	}
+	var ms int64
+	if err := json.Unmarshal(params, &byPosition); err == nil && len(byPosition) == 1 {
+		ms = byPosition[0]
+	} else if err := json.Unmarshal(params, &byName); err == nil && byName.Ms != nil {
+		ms = *byName.Ms
+	} else {
+		return nil, ErrInvalidParams
+	}
+	// reject the negative durations, and the ones overflowing time.Duration
+	if ms < 0 || ms > int64(time.Duration(1<<63-1)/time.Millisecond) {
+		return nil, ErrInvalidParams
+	}
+	d := time.Duration(ms) * time.Millisecond
+	if d > max {
+		d = max
+	}
+	start := s.clock.Now()
+	timer := s.clock.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C():
+	case <-ctx.Done():
+	}
+	return map[string]int64{"sleptMs": s.clock.Now().Sub(start).Milliseconds()}, nil
+}
+
+func debugError(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var byPosition []json.RawMessage
+	var byName struct {
+		Code    *int   `json:"code"`
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(params, &byPosition); err == nil && len(byPosition) >= 1 && len(byPosition) <= 2 {
+		byName.Code = new(int)
+		if json.Unmarshal(byPosition[0], byName.Code) != nil {
+			return nil, ErrInvalidParams
+		}
+		if len(byPosition) == 2 && json.Unmarshal(byPosition[1], &byName.Message) != nil {
+			return nil, ErrInvalidParams
+		}
+	} else if err := json.Unmarshal(params, &byName); err != nil || byName.Code == nil {
+		return nil, ErrInvalidParams
+	}
+	code := *byName.Code
+	// the codes from -32768 to -32100 are reserved by the specification, they would be mistaken for protocol errors
+	if code >= -32768 && code < -32099 || len(byName.Message) > maxDebugMessage {
+		return nil, ErrInvalidParams
+	}
+	if byName.Message == "" {
+		byName.Message = "Debug error"
+	}
+	return nil, NewError(code, byName.Message)
+}
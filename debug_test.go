@@ -0,0 +1,108 @@
+package jsonrpc2
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestEnableDebugMethods(t *testing.T) {
+	t.Run("echo", func(t *testing.T) {
+		server := NewServer(EnableDebugMethods(DebugConfig{}))
+		rsp := server.ServeRequest(json.RawMessage(`[
+			{"jsonrpc": "2.0", "method": "rpc.echo", "params": {"b": [1, "é", null], "a": 1.50}, "id": 1},
+			{"jsonrpc": "2.0", "method": "rpc.echo", "id": 2}
+		]`))
+		require.JSONEq(t, `[
+			{"id": 1, "jsonrpc": "2.0", "result": {"b": [1, "é", null], "a": 1.50}},
+			{"id": 2, "jsonrpc": "2.0", "result": null}
+		]`, string(rsp))
+		require.Contains(t, string(rsp), `"a":1.50`)
+	})
+	t.Run("sleep", func(t *testing.T) {
+		clock := MockClock()
+		server := NewServer(WithClock(clock), EnableDebugMethods(DebugConfig{MaxSleep: time.Second}))
+		for _, c := range []struct {
+			params string
+			slept  time.Duration
+		}{
+			{`{"ms": 500}`, 500 * time.Millisecond},
+			{`[500]`, 500 * time.Millisecond},
+			{`{"ms": 0}`, 0},
+			// capped
+			{`{"ms": 60000}`, time.Second},
+		} {
+			rsp := make(chan json.RawMessage)
+			go func() {
+				rsp <- server.ServeRequest(json.RawMessage(`{"jsonrpc": "2.0", "method": "rpc.sleep", "params": ` + c.params + `, "id": 1}`))
+			}()
+			if c.slept > 0 {
+				clock.BlockUntil(1)
+				clock.Advance(c.slept)
+			}
+			expected := fmt.Sprintf(`{"id": 1, "jsonrpc": "2.0", "result": {"sleptMs": %d}}`, c.slept.Milliseconds())
+			require.JSONEq(t, expected, string(<-rsp), c.params)
+		}
+	})
+	t.Run("sleep until timeout", func(t *testing.T) {
+		clock := MockClock()
+		server := NewServer(WithClock(clock), EnableDebugMethods(DebugConfig{}))
+		server.SetDefaultTimeout(100 * time.Millisecond)
+		rsp := make(chan json.RawMessage)
+		go func() {
+			rsp <- server.ServeRequest(json.RawMessage(`{"jsonrpc": "2.0", "method": "rpc.sleep", "params": [5000], "id": 1}`))
+		}()
+		clock.BlockUntil(2)
+		clock.Advance(100 * time.Millisecond)
+		require.Contains(t, string(<-rsp), `"error"`)
+	})
+	t.Run("reject absurd sleeps", func(t *testing.T) {
+		server := NewServer(EnableDebugMethods(DebugConfig{}))
+		for _, params := range []string{`[-1]`, `{"ms": -5}`, `{"ms": 1e30}`, `{"ms": 1.5}`, `"soon"`, `{}`, `[1, 2]`} {
+			rsp := server.ServeRequest(json.RawMessage(`{"jsonrpc": "2.0", "method": "rpc.sleep", "params": ` + params + `, "id": 1}`))
+			require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "error": {"code": -32602, "message": "Invalid Params"}}`, string(rsp), params)
+		}
+	})
+	t.Run("error", func(t *testing.T) {
+		server := NewServer(EnableDebugMethods(DebugConfig{}))
+		for params, expected := range map[string]string{
+			`{"code": -32050, "message": "Out of stock"}`: `{"code": -32050, "message": "Out of stock"}`,
+			`[42, "Answer"]`:                              `{"code": 42, "message": "Answer"}`,
+			`[-32000]`:                                    `{"code": -32000, "message": "Debug error"}`,
+			`{"code": -32099}`:                            `{"code": -32099, "message": "Debug error"}`,
+			`{"code": -40000}`:                            `{"code": -40000, "message": "Debug error"}`,
+			// reserved by the specification
+			`{"code": -32601}`: `{"code": -32602, "message": "Invalid Params"}`,
+			`{"code": -32768}`: `{"code": -32602, "message": "Invalid Params"}`,
+			`{"code": -32100}`: `{"code": -32602, "message": "Invalid Params"}`,
+			`{"message": "x"}`: `{"code": -32602, "message": "Invalid Params"}`,
+			`["x"]`:            `{"code": -32602, "message": "Invalid Params"}`,
+		} {
+			rsp := server.ServeRequest(json.RawMessage(`{"jsonrpc": "2.0", "method": "rpc.error", "params": ` + params + `, "id": 1}`))
+			require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "error": `+expected+`}`, string(rsp), params)
+		}
+	})
+	t.Run("hidden from discovery", func(t *testing.T) {
+		server := NewServer(EnableIntrospection(), EnableDebugMethods(DebugConfig{}))
+		rsp := server.ServeRequest(json.RawMessage(`{"jsonrpc": "2.0", "method": "rpc.listMethods", "id": 1}`))
+		require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "result": ["rpc.describe", "rpc.listMethods", "rpc.listNotifications"]}`, string(rsp))
+		rsp = server.ServeRequest(json.RawMessage(`{"jsonrpc": "2.0", "method": "rpc.describe", "params": ["rpc.echo"], "id": 1}`))
+		require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "error": {"code": -32602, "message": "Invalid Params"}}`, string(rsp))
+
+		server = NewServer(EnableIntrospection(), EnableDebugMethods(DebugConfig{Discoverable: true}))
+		rsp = server.ServeRequest(json.RawMessage(`{"jsonrpc": "2.0", "method": "rpc.listMethods", "id": 1}`))
+		require.JSONEq(t, `{
+			"id": 1,
+			"jsonrpc": "2.0",
+			"result": ["rpc.describe", "rpc.echo", "rpc.error", "rpc.listMethods", "rpc.listNotifications", "rpc.sleep"]
+		}`, string(rsp))
+	})
+	t.Run("not checked by the method validator", func(t *testing.T) {
+		server := NewServer(WithMethodValidator(func(method string) error {
+			return fmt.Errorf("no method")
+		}), EnableDebugMethods(DebugConfig{}))
+		require.ElementsMatch(t, []string{"rpc.echo", "rpc.error", "rpc.sleep"}, server.Methods())
+	})
+}
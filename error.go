@@ -1,5 +1,11 @@
 package jsonrpc2
 
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
 // Rpc Error
 // You may return by `jsonrpc2.NewError(code, msg)`. This will be used in the error response.
 type Error interface {
@@ -7,23 +13,79 @@ type Error interface {
 	Error() string
 }
 
+// Error codes of the errors responded by the package. The codes from -32768 to -32000 are reserved by the
+// specification, -32000 to -32099 are server errors.
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+	// CodeServerError is the code of NewInternalError and of the handler errors which are not Error
+	CodeServerError      = -32000
+	CodeStillRunning     = -32002
+	CodeReplayDetected   = -32016
+	CodeResponseTooLarge = -32018
+	CodeWarmingUp        = -32019
+	CodeShuttingDown     = -32020
+	CodeInjectedFault    = -32099
+)
+
 var (
 	ErrParseError = rpcError{
-		ErrorCode:    -32700,
+		ErrorCode:    CodeParseError,
 		Message: "Parse error",
 	}
 	ErrInvalidRequest = rpcError{
-		ErrorCode:    -32600,
+		ErrorCode:    CodeInvalidRequest,
 		Message: "Invalid request",
 	}
 	ErrMethodNotFound = rpcError{
-		ErrorCode:    -32601,
+		ErrorCode:    CodeMethodNotFound,
 		Message: "Method not found",
 	}
 	ErrInvalidParams = rpcError{
-		ErrorCode:    -32602,
+		ErrorCode:    CodeInvalidParams,
 		Message: "Invalid Params",
 	}
+	// ErrInternalServerError is responded by DefaultSanitizer
+	ErrInternalServerError = rpcError{
+		ErrorCode:    CodeInternalError,
+		Message: "Internal server error",
+	}
+	ErrReplayDetected = rpcError{
+		ErrorCode:    CodeReplayDetected,
+		Message: "Replay detected",
+	}
+	// The data of ErrWarmingUp is {"etaMs": <expected remaining milliseconds>}
+	ErrWarmingUp = rpcError{
+		ErrorCode:    CodeWarmingUp,
+		Message: "Warming up",
+	}
+	ErrShuttingDown = rpcError{
+		ErrorCode:    CodeShuttingDown,
+		Message: "Shutting down",
+	}
+	// ErrServiceBusy is responded when the context of a request waiting for a slot of a method is done, see WithMethodConcurrency
+	ErrServiceBusy = rpcError{
+		ErrorCode:    CodeServerError,
+		Message: "Service busy",
+	}
+	// ErrStillRunning is responded when a detached method times out, its handler keeps running, see MethodConfig.Detached
+	ErrStillRunning = rpcError{
+		ErrorCode:    CodeStillRunning,
+		Message: "Still running",
+	}
+	// ErrInjectedFault is responded by the faults of WithChaos
+	ErrInjectedFault = rpcError{
+		ErrorCode:    CodeInjectedFault,
+		Message: "Injected fault",
+	}
+	// The data of ErrResponseTooLarge is {"size": <response bytes>, "limit": <max bytes>}
+	ErrResponseTooLarge = rpcError{
+		ErrorCode:    CodeResponseTooLarge,
+		Message: "Response too large",
+	}
 )
 
 func NewError(code int, msg string) Error {
@@ -33,15 +95,63 @@ func NewError(code int, msg string) Error {
 	}
 }
 
+// NewErrorWithData returns an Error responded with data as the "data" member of the error object.
+func NewErrorWithData(code int, msg string, data interface{}) Error {
+	return &rpcError{
+		ErrorCode: code,
+		Message:   msg,
+		ErrorData: data,
+	}
+}
+
 func NewInternalError(msg string) Error {
-	return NewError(-32000, msg)
+	return NewError(CodeServerError, msg)
+}
+
+// CodeOf returns the code of the first Error in the chain of err, see errors.As.
+func CodeOf(err error) (code int, ok bool) {
+	var e Error
+	if !errors.As(err, &e) {
+		return 0, false
+	}
+	return e.Code(), true
+}
+
+// IsMethodNotFound reports whether err is or wraps an Error with CodeMethodNotFound.
+func IsMethodNotFound(err error) bool {
+	code, ok := CodeOf(err)
+	return ok && code == CodeMethodNotFound
+}
+
+// IsInvalidParams reports whether err is or wraps an Error with CodeInvalidParams.
+func IsInvalidParams(err error) bool {
+	code, ok := CodeOf(err)
+	return ok && code == CodeInvalidParams
+}
+
+// NewSentinelError returns a distinct Error to declare as a package level variable, so callers can match it
+// with errors.Is even when wrapped. Two sentinels are never equal, even with the same code and message.
+//	var ErrOutOfStock = jsonrpc2.NewSentinelError(-32050, "Out of stock")
+func NewSentinelError(code int, msg string) Error {
+	return &sentinelError{rpcError{ErrorCode: code, Message: msg}}
+}
+
+// IsSentinel reports whether err is sentinel or wraps it.
+func IsSentinel(err error, sentinel Error) bool {
+	return errors.Is(err, sentinel)
 }
 
 // ============ Private members below =================
 
+// sentinelError is compared by pointer, as errors.New
+type sentinelError struct {
+	rpcError
+}
+
 type rpcError struct {
 	ErrorCode   int    `json:"code"`
 	Message 	string `json:"message"`
+	ErrorData   interface{} `json:"data,omitempty"`
 }
 
 func (e rpcError) Error() string {
@@ -50,4 +160,46 @@ func (e rpcError) Error() string {
 
 func (e rpcError) Code() int {
 	return e.ErrorCode
-}
\ No newline at end of file
+}
+
+func (e rpcError) Data() interface{} {
+	return e.ErrorData
+}
+
+// errorData returns the data of e if it has any
+func errorData(e Error) interface{} {
+	if d, ok := e.(interface{ Data() interface{} }); ok {
+		return d.Data()
+	}
+	return nil
+}
+
+// newParseError returns ErrParseError with the offset and cause of err as data. The messages of encoding/json are
+// rewritten, as they name the Go types the request is decoded to.
+func newParseError(err error) Error {
+	var syntax *json.SyntaxError
+	var typ *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntax):
+		return NewErrorWithData(CodeParseError, ErrParseError.Error(), parseErrorData{syntax.Offset, syntax.Error()})
+	case errors.As(err, &typ) && typ.Field != "":
+		detail := fmt.Sprintf("cannot unmarshal %s into %q of type %s", typ.Value, typ.Field, typ.Type.Kind())
+		return NewErrorWithData(CodeParseError, ErrParseError.Error(), parseErrorData{typ.Offset, detail})
+	case errors.As(err, &typ):
+		detail := fmt.Sprintf("cannot unmarshal %s into request object", typ.Value)
+		return NewErrorWithData(CodeParseError, ErrParseError.Error(), parseErrorData{typ.Offset, detail})
+	}
+	return ErrParseError
+}
+
+type parseErrorData struct {
+	Offset int64  `json:"offset"`
+	Detail string `json:"detail"`
+}
+
+func newResponseTooLargeError(size, limit int) Error {
+	return NewErrorWithData(CodeResponseTooLarge, ErrResponseTooLarge.Error(), map[string]int{
+		"size":  size,
+		"limit": limit,
+	})
+}
@@ -0,0 +1,70 @@
+package jsonrpc2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestNewSentinelError(t *testing.T) {
+	errOutOfStock := NewSentinelError(-32050, "Out of stock")
+	t.Run("distinct sentinels", func(t *testing.T) {
+		other := NewSentinelError(-32050, "Out of stock")
+		require.True(t, errors.Is(errOutOfStock, errOutOfStock))
+		require.False(t, errors.Is(other, errOutOfStock))
+		require.False(t, IsSentinel(other, errOutOfStock))
+	})
+	t.Run("wrapped sentinel", func(t *testing.T) {
+		err := fmt.Errorf("order 42: %w", errOutOfStock)
+		require.True(t, IsSentinel(err, errOutOfStock))
+		require.False(t, IsSentinel(errors.New("Out of stock"), errOutOfStock))
+		require.False(t, IsSentinel(nil, errOutOfStock))
+	})
+	t.Run("responded as error", func(t *testing.T) {
+		server := NewServer()
+		server.DefineMethod("order", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+			return nil, errOutOfStock
+		})
+		rsp := server.ServeRequest(json.RawMessage(`{ "jsonrpc": "2.0", "method": "order", "id": 1 }`))
+		require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "error": {"code": -32050, "message": "Out of stock"}}`, string(rsp))
+	})
+}
+
+func TestCodeOf(t *testing.T) {
+	t.Run("rpc errors", func(t *testing.T) {
+		code, ok := CodeOf(ErrMethodNotFound)
+		require.True(t, ok)
+		require.Equal(t, CodeMethodNotFound, code)
+		code, ok = CodeOf(NewError(-32001, "Unavailable"))
+		require.True(t, ok)
+		require.Equal(t, -32001, code)
+		require.True(t, IsMethodNotFound(ErrMethodNotFound))
+		require.True(t, IsInvalidParams(ErrInvalidParams))
+		require.False(t, IsMethodNotFound(ErrInvalidParams))
+	})
+	t.Run("wrapped errors", func(t *testing.T) {
+		err := fmt.Errorf("call users.get: %w", ErrMethodNotFound)
+		code, ok := CodeOf(err)
+		require.True(t, ok)
+		require.Equal(t, CodeMethodNotFound, code)
+		require.True(t, IsMethodNotFound(fmt.Errorf("retry: %w", err)))
+	})
+	t.Run("non rpc errors", func(t *testing.T) {
+		_, ok := CodeOf(errors.New("EOF"))
+		require.False(t, ok)
+		_, ok = CodeOf(nil)
+		require.False(t, ok)
+		require.False(t, IsMethodNotFound(errors.New("Method not found")))
+	})
+	t.Run("wrapped error is responded with its code", func(t *testing.T) {
+		server := NewServer()
+		server.DefineMethod("get", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+			return nil, fmt.Errorf("get user: %w", ErrInvalidParams)
+		})
+		rsp := server.ServeRequest(json.RawMessage(`{ "jsonrpc": "2.0", "method": "get", "id": 1 }`))
+		require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "error": {"code": -32602, "message": "Invalid Params"}}`, string(rsp))
+	})
+}
@@ -0,0 +1,125 @@
+package jsonrpc2
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+)
+
+type (
+	// EventLoop fans out the notifications served by a server to the subscribers of their method, e.g. for pub/sub.
+	// Usage:
+	//	loop := jsonrpc2.NewEventLoop(server, 100)
+	//	events, unsubscribe := loop.Subscribe("order.created")
+	//	defer unsubscribe()
+	//	go loop.Start(ctx)
+	//	for n := range events { ... }
+	EventLoop struct {
+		server Server
+		queue  chan Notification
+
+		mu          sync.Mutex
+		subscribers map[string]map[*subscription]struct{}
+	}
+
+	// A Notification is a notification served by the server of an EventLoop.
+	Notification struct {
+		Method string
+		Params json.RawMessage
+	}
+)
+
+// NewEventLoop returns an EventLoop of the notifications served by server, queuing up to bufferSize of them.
+// When the queue is full, serving a notification blocks until it is queued or its context is done.
+func NewEventLoop(server Server, bufferSize int) *EventLoop {
+	l := &EventLoop{
+		server:      server,
+		queue:       make(chan Notification, bufferSize),
+		subscribers: map[string]map[*subscription]struct{}{},
+	}
+	server.Use(l.middleware)
+	return l
+}
+
+// Subscribe returns a channel of the notifications of method, buffering up to the bufferSize of the loop,
+// and a func to unsubscribe. The channel is not closed, stop receiving after unsubscribing.
+// If the server has no such method, a method doing nothing is defined, so it must be called before serving requests.
+func (l *EventLoop) Subscribe(method string) (<-chan Notification, func()) {
+	if !l.server.MethodExists(method) {
+		l.server.DefineMethod(method, func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+			return nil, nil
+		})
+	}
+	sub := &subscription{
+		c:    make(chan Notification, cap(l.queue)),
+		done: make(chan struct{}),
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.subscribers[method] == nil {
+		l.subscribers[method] = map[*subscription]struct{}{}
+	}
+	l.subscribers[method][sub] = struct{}{}
+	var once sync.Once
+	return sub.c, func() {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			delete(l.subscribers[method], sub)
+			close(sub.done)
+		})
+	}
+}
+
+// Start delivers the queued notifications to the subscribers, in order, until ctx is done and returns ctx.Err().
+// A subscriber not receiving blocks the delivery to the others.
+func (l *EventLoop) Start(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case n := <-l.queue:
+			for _, sub := range l.subscriptions(n.Method) {
+				select {
+				case sub.c <- n:
+				case <-sub.done:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+		}
+	}
+}
+
+// ============ Private members below =================
+
+type subscription struct {
+	c chan Notification
+	// done is closed by unsubscribe
+	done chan struct{}
+}
+
+func (l *EventLoop) middleware(next Handler) Handler {
+	return func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		result, err := next(ctx, params)
+		info := MethodCallInfo(ctx)
+		if err != nil || info == nil || info.RequestID != nil {
+			return result, err
+		}
+		select {
+		case l.queue <- Notification{Method: info.Method, Params: params}:
+		case <-ctx.Done():
+		}
+		return result, err
+	}
+}
+
+func (l *EventLoop) subscriptions(method string) []*subscription {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	subs := make([]*subscription, 0, len(l.subscribers[method]))
+	for sub := range l.subscribers[method] {
+		subs = append(subs, sub)
+	}
+	return subs
+}
@@ -0,0 +1,64 @@
+package jsonrpc2
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"testing"
+)
+
+func TestEventLoop(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	server := NewServer()
+	server.DefineMethod("echo", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		return params, nil
+	})
+	loop := NewEventLoop(server, 10)
+	created1, unsubscribe1 := loop.Subscribe("order.created")
+	created2, unsubscribe2 := loop.Subscribe("order.created")
+	defer unsubscribe2()
+	echoes, unsubscribe3 := loop.Subscribe("echo")
+	defer unsubscribe3()
+	done := make(chan error)
+	go func() {
+		done <- loop.Start(ctx)
+	}()
+
+	t.Run("deliver to all subscribers", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for _, c := range []<-chan Notification{created1, created2} {
+			wg.Add(1)
+			go func(c <-chan Notification) {
+				defer wg.Done()
+				for _, id := range []string{"1", "2"} {
+					assert.Equal(t, Notification{Method: "order.created", Params: json.RawMessage(id)}, <-c)
+				}
+			}(c)
+		}
+		require.Nil(t, server.ServeRequest(json.RawMessage(`{ "jsonrpc": "2.0", "method": "order.created", "params": 1 }`)))
+		require.Nil(t, server.ServeRequest(json.RawMessage(`{ "jsonrpc": "2.0", "method": "order.created", "params": 2 }`)))
+		wg.Wait()
+	})
+	t.Run("requests are not delivered", func(t *testing.T) {
+		rsp := server.ServeRequest(json.RawMessage(`[
+			{ "jsonrpc": "2.0", "method": "echo", "params": "request", "id": 1 },
+			{ "jsonrpc": "2.0", "method": "echo", "params": "notification" }
+		]`))
+		require.JSONEq(t, `[{"id": 1, "jsonrpc": "2.0", "result": "request"}]`, string(rsp))
+		require.Equal(t, Notification{Method: "echo", Params: json.RawMessage(`"notification"`)}, <-echoes)
+	})
+	t.Run("unsubscribe", func(t *testing.T) {
+		unsubscribe1()
+		unsubscribe1()
+		require.Nil(t, server.ServeRequest(json.RawMessage(`{ "jsonrpc": "2.0", "method": "order.created", "params": 3 }`)))
+		require.Equal(t, json.RawMessage(`3`), (<-created2).Params)
+		require.Empty(t, created1)
+	})
+	t.Run("stop", func(t *testing.T) {
+		cancel()
+		require.Equal(t, context.Canceled, <-done)
+	})
+}
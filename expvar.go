@@ -0,0 +1,172 @@
+package jsonrpc2
+
+import (
+	"expvar"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// ExpvarGauge is an expvar.Var which can go up and down, e.g. the number of requests in flight.
+// It is published under its name on creation, so it is served by the expvar handler at /debug/vars.
+type ExpvarGauge struct {
+	v atomic.Int64
+}
+
+// NewExpvarGauge returns a gauge published as name. As expvar.NewInt, it panics if name is already published.
+func NewExpvarGauge(name string) *ExpvarGauge {
+	g := &ExpvarGauge{}
+	expvar.Publish(name, g)
+	return g
+}
+
+// Add adds delta to the gauge, which may be negative.
+func (g *ExpvarGauge) Add(delta int64) {
+	g.v.Add(delta)
+}
+
+func (g *ExpvarGauge) Set(value int64) {
+	g.v.Store(value)
+}
+
+func (g *ExpvarGauge) Value() int64 {
+	return g.v.Load()
+}
+
+// String returns the value as a JSON number, see expvar.Var.
+func (g *ExpvarGauge) String() string {
+	return strconv.FormatInt(g.v.Load(), 10)
+}
+
+// WithExpvarMetrics publishes the metrics of the server to expvar, under namespace:
+//	<namespace>.active_requests      gauge of the requests being handled
+//	<namespace>.queue_depth          gauge of the requests waiting for a slot, see WithMethodConcurrency
+//	<namespace>.requests_total       counter of the requests received
+//	<namespace>.errors_total         counter of the error responses
+//	<namespace>.expired_in_queue     counter of the requests rejected after waiting for a slot, see MinQueueBudget
+//	<namespace>.notification_errors  counters of the suppressed error responses of the notifications by method and
+//	                                 code, e.g. {"orders.created": {"-32601": 3}}, see OnError
+//	<namespace>.abandoned            gauge of the handlers abandoned after a timeout which have not returned, see OnAbandon
+//	<namespace>.method_bytes         counters of the bytes received and responded by method, e.g.
+//	                                 {"orders.get": {"in": 5120, "out": 20480}}, see OnAccounting
+// The variables are published once per namespace, the servers created with the same namespace share them.
+//	server := jsonrpc2.NewServer(jsonrpc2.WithExpvarMetrics("rpc"))
+func WithExpvarMetrics(namespace string) ServerOption {
+	m := publishExpvarMetrics(namespace)
+	return func(s *server) {
+		s.metrics = m
+	}
+}
+
+// ============ Private members below =================
+
+var (
+	// expvarNamespaces are the metrics published by WithExpvarMetrics, by namespace
+	expvarNamespacesMu sync.Mutex
+	expvarNamespaces   = map[string]*expvarMetrics{}
+)
+
+// publishExpvarMetrics returns the metrics of namespace, published on first use
+func publishExpvarMetrics(namespace string) *expvarMetrics {
+	expvarNamespacesMu.Lock()
+	defer expvarNamespacesMu.Unlock()
+	if m, ok := expvarNamespaces[namespace]; ok {
+		return m
+	}
+	m := &expvarMetrics{
+		active:             NewExpvarGauge(namespace + ".active_requests"),
+		queued:             NewExpvarGauge(namespace + ".queue_depth"),
+		requests:           expvar.NewInt(namespace + ".requests_total"),
+		errors:             expvar.NewInt(namespace + ".errors_total"),
+		expired:            expvar.NewInt(namespace + ".expired_in_queue"),
+		notificationErrors: expvar.NewMap(namespace + ".notification_errors"),
+		abandoned:          NewExpvarGauge(namespace + ".abandoned"),
+		methodBytes:        expvar.NewMap(namespace + ".method_bytes"),
+	}
+	expvarNamespaces[namespace] = m
+	return m
+}
+
+// expvarMetrics are the metrics of WithExpvarMetrics, its methods do nothing on a nil receiver
+type expvarMetrics struct {
+	active    *ExpvarGauge
+	queued    *ExpvarGauge
+	requests  *expvar.Int
+	errors    *expvar.Int
+	expired   *expvar.Int
+	abandoned *ExpvarGauge
+	// mu serializes the creation of the per method maps of notificationErrors and methodBytes
+	mu                 sync.Mutex
+	notificationErrors *expvar.Map
+	methodBytes        *expvar.Map
+}
+
+func (m *expvarMetrics) received() {
+	if m != nil {
+		m.requests.Add(1)
+	}
+}
+
+func (m *expvarMetrics) failed() {
+	if m != nil {
+		m.errors.Add(1)
+	}
+}
+
+// queue adds delta to the requests waiting for a slot
+func (m *expvarMetrics) queue(delta int64) {
+	if m != nil {
+		m.queued.Add(delta)
+	}
+}
+
+// expire counts a request rejected after waiting for a slot
+func (m *expvarMetrics) expire() {
+	if m != nil {
+		m.expired.Add(1)
+	}
+}
+
+// notificationError counts a suppressed error response of a notification
+func (m *expvarMetrics) notificationError(method string, code int) {
+	if m == nil {
+		return
+	}
+	m.methodMap(m.notificationErrors, method).Add(strconv.Itoa(code), 1)
+}
+
+// transfer counts the bytes of a request of method and of its response
+func (m *expvarMetrics) transfer(method string, in, out int) {
+	if m == nil {
+		return
+	}
+	bytes := m.methodMap(m.methodBytes, method)
+	bytes.Add("in", int64(in))
+	bytes.Add("out", int64(out))
+}
+
+// methodMap returns the map of method in maps, created on first use
+func (m *expvarMetrics) methodMap(maps *expvar.Map, method string) *expvar.Map {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	v, ok := maps.Get(method).(*expvar.Map)
+	if !ok {
+		v = new(expvar.Map)
+		maps.Set(method, v)
+	}
+	return v
+}
+
+// handle adds delta to the requests being handled
+func (m *expvarMetrics) handle(delta int64) {
+	if m != nil {
+		m.active.Add(delta)
+	}
+}
+
+// abandon adds delta to the abandoned handlers
+func (m *expvarMetrics) abandon(delta int64) {
+	if m != nil {
+		m.abandoned.Add(delta)
+	}
+}
@@ -0,0 +1,84 @@
+package jsonrpc2
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"github.com/stretchr/testify/require"
+	"strconv"
+	"sync/atomic"
+	"testing"
+)
+
+var expvarNames atomic.Int64
+
+// uniqueExpvarName returns prefix with a suffix unique to the process, as the expvar names cannot be published twice
+// when the tests are run several times
+func uniqueExpvarName(prefix string) string {
+	return prefix + "." + strconv.FormatInt(expvarNames.Add(1), 10)
+}
+
+func TestExpvarGauge(t *testing.T) {
+	name := uniqueExpvarName("test.gauge")
+	g := NewExpvarGauge(name)
+	g.Add(3)
+	g.Add(-1)
+	require.Equal(t, int64(2), g.Value())
+	require.Equal(t, "2", expvar.Get(name).String())
+	g.Set(-5)
+	require.Equal(t, "-5", g.String())
+	require.Panics(t, func() { NewExpvarGauge(name) })
+}
+
+func TestWithExpvarMetrics(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	namespace := uniqueExpvarName("test.rpc")
+	server := NewServer(WithExpvarMetrics(namespace), WithMethodConcurrency("wait", 1))
+	server.DefineMethod("wait", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		started <- struct{}{}
+		<-release
+		return nil, nil
+	})
+	server.DefineMethod("fail", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		return nil, NewError(-32001, "failed")
+	})
+	value := func(name string) string {
+		return expvar.Get(namespace + "." + name).String()
+	}
+
+	done := make(chan struct{})
+	for i := 0; i < 2; i++ {
+		go func() {
+			server.ServeRequest(json.RawMessage(`{ "jsonrpc": "2.0", "method": "wait", "id": 1 }`))
+			done <- struct{}{}
+		}()
+	}
+	<-started
+	waitFor(t, func() bool { return value("queue_depth") == "1" })
+	require.Equal(t, "1", value("active_requests"))
+
+	server.ServeRequest(json.RawMessage(`[
+		{ "jsonrpc": "2.0", "method": "fail", "id": 1 },
+		{ "jsonrpc": "2.0", "method": "unknown", "id": 2 }
+	]`))
+	require.Equal(t, "2", value("errors_total"))
+
+	close(release)
+	<-started
+	<-done
+	<-done
+	require.Equal(t, "0", value("queue_depth"))
+	require.Equal(t, "0", value("active_requests"))
+	require.Equal(t, "4", value("requests_total"))
+	require.Equal(t, "2", value("errors_total"))
+
+	server.Reset()
+	server.ServeRequest(json.RawMessage(`{ "jsonrpc": "2.0", "method": "unknown", "id": 1 }`))
+	require.Equal(t, "5", value("requests_total"), "the option is applied again by Reset")
+
+	// the servers of a namespace share its variables
+	other := NewServer(WithExpvarMetrics(namespace))
+	other.ServeRequest(json.RawMessage(`{ "jsonrpc": "2.0", "method": "unknown", "id": 1 }`))
+	require.Equal(t, "6", value("requests_total"))
+}
@@ -0,0 +1,59 @@
+package jsonrpc2
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// ExtractResult parses a jsonrpc 2.0 response and returns its result.
+// If the response is an error response, the Error is returned. If the response is malformed, error is returned.
+//	result, rpcErr, err := jsonrpc2.ExtractResult(server.ServeRequest(req))
+func ExtractResult(resp json.RawMessage) (json.RawMessage, Error, error) {
+	var r responseMessage
+	if err := json.Unmarshal(resp, &r); err != nil {
+		return nil, nil, err
+	}
+	return r.extract()
+}
+
+// ExtractResults parses a jsonrpc 2.0 batch response. The results and Errors are in the order of the responses,
+// with nil Error for a successful response and nil result for an error response.
+func ExtractResults(resp json.RawMessage) ([]json.RawMessage, []Error, error) {
+	var rs []responseMessage
+	if err := json.Unmarshal(resp, &rs); err != nil {
+		return nil, nil, err
+	}
+	results := make([]json.RawMessage, len(rs))
+	errs := make([]Error, len(rs))
+	for i := range rs {
+		var err error
+		if results[i], errs[i], err = rs[i].extract(); err != nil {
+			return nil, nil, err
+		}
+	}
+	return results, errs, nil
+}
+
+// ============ Private members below =================
+
+// A responseMessage represents a JSON-RPC response to be parsed.
+type responseMessage struct {
+	Version string          `json:"jsonrpc"`
+	Result  json.RawMessage `json:"result"`
+	Error   *rpcError       `json:"error"`
+}
+
+var errMalformedResponse = errors.New("jsonrpc2: malformed response")
+
+func (r responseMessage) extract() (json.RawMessage, Error, error) {
+	if r.Version != "2.0" {
+		return nil, nil, errMalformedResponse
+	}
+	if r.Error != nil {
+		return nil, r.Error, nil
+	}
+	if r.Result == nil {
+		return json.RawMessage("null"), nil, nil
+	}
+	return r.Result, nil, nil
+}
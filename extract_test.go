@@ -0,0 +1,50 @@
+package jsonrpc2
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestExtractResult(t *testing.T) {
+	t.Run("result", func(t *testing.T) {
+		result, rpcErr, err := ExtractResult(json.RawMessage(`{"jsonrpc":"2.0","result":{"a":1},"id":1}`))
+		require.NoError(t, err)
+		require.Nil(t, rpcErr)
+		require.JSONEq(t, `{"a":1}`, string(result))
+	})
+	t.Run("error", func(t *testing.T) {
+		result, rpcErr, err := ExtractResult(json.RawMessage(`{"jsonrpc":"2.0","error":{"code":-32601,"message":"Method not found"},"id":1}`))
+		require.NoError(t, err)
+		require.Nil(t, result)
+		require.Equal(t, -32601, rpcErr.Code())
+		require.Equal(t, "Method not found", rpcErr.Error())
+	})
+	t.Run("malformed", func(t *testing.T) {
+		_, _, err := ExtractResult(json.RawMessage(`{qqqq}`))
+		require.Error(t, err)
+		_, _, err = ExtractResult(json.RawMessage(`{"result":1,"id":1}`))
+		require.Equal(t, errMalformedResponse, err)
+	})
+}
+
+func TestExtractResults(t *testing.T) {
+	t.Run("results and errors", func(t *testing.T) {
+		results, rpcErrs, err := ExtractResults(json.RawMessage(`[
+			{"jsonrpc":"2.0","result":"hi","id":1},
+			{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid request"},"id":null}
+		]`))
+		require.NoError(t, err)
+		require.Len(t, results, 2)
+		require.JSONEq(t, `"hi"`, string(results[0]))
+		require.Nil(t, rpcErrs[0])
+		require.Nil(t, results[1])
+		require.Equal(t, -32600, rpcErrs[1].Code())
+	})
+	t.Run("malformed", func(t *testing.T) {
+		_, _, err := ExtractResults(json.RawMessage(`{"jsonrpc":"2.0","result":"hi","id":1}`))
+		require.Error(t, err)
+		_, _, err = ExtractResults(json.RawMessage(`[{"jsonrpc":"1.0","result":"hi","id":1}]`))
+		require.Equal(t, errMalformedResponse, err)
+	})
+}
@@ -0,0 +1,56 @@
+package jsonrpc2_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github/brianso/go-jsonrpc2"
+	"github/brianso/go-jsonrpc2/jsonrpc2test"
+	"testing"
+)
+
+// TestServer_Golden protects the wire format of the responses, run `go test -run TestServer_Golden -update` after
+// a deliberate change and review the diff of testdata/golden
+func TestServer_Golden(t *testing.T) {
+	server := jsonrpc2.NewServer()
+	server.DefineMethod("echo", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		return params, nil
+	})
+	server.DefineMethod("nothing", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		return nil, nil
+	})
+	server.DefineMethod("fail", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		return nil, jsonrpc2.NewErrorWithData(-32050, "Out of stock", map[string]int{"left": 0})
+	})
+	server.DefineMethod("crash", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		return nil, errors.New("connection refused")
+	})
+	cases := append(jsonrpc2test.ProtocolCases,
+		jsonrpc2test.GoldenCase{
+			Name:    "echo",
+			Request: json.RawMessage(`{"jsonrpc": "2.0", "method": "echo", "params": ["<a&b>", "éé", 1.50, {"z": null, "a": []}], "id": 1}`),
+		},
+		jsonrpc2test.GoldenCase{
+			Name:    "null_result",
+			Request: json.RawMessage(`{"jsonrpc": "2.0", "method": "nothing", "id": 1}`),
+		},
+		jsonrpc2test.GoldenCase{
+			Name:    "error_with_data",
+			Request: json.RawMessage(`{"jsonrpc": "2.0", "method": "fail", "id": 1}`),
+		},
+		jsonrpc2test.GoldenCase{
+			Name:    "handler_error",
+			Request: json.RawMessage(`{"jsonrpc": "2.0", "method": "crash", "id": 1}`),
+		},
+		jsonrpc2test.GoldenCase{
+			Name: "batch",
+			Request: json.RawMessage(`[
+				{"jsonrpc": "2.0", "method": "echo", "params": "hi", "id": "a"},
+				{"jsonrpc": "2.0", "method": "echo", "params": "notified"},
+				{"jsonrpc": "2.0", "method": "fail", "id": 2},
+				{"foo": "boo"}
+			]`),
+		},
+	)
+	jsonrpc2test.Golden(t, server, "testdata/golden", cases)
+}
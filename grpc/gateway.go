@@ -0,0 +1,32 @@
+// grpc serves jsonrpc2 servers over gRPC, by the JsonRpc2Gateway service of gateway.proto. It is imported as
+// jsonrpc2grpc next to the gRPC package in the examples
+package grpc
+
+import (
+	"context"
+	"github/brianso/go-jsonrpc2"
+)
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative gateway.proto
+
+// NewGRPCGateway returns the JsonRpc2Gateway service serving the body of the Execute calls by server, so gRPC clients
+// call the methods of server:
+//	s := grpc.NewServer()
+//	jsonrpc2grpc.RegisterJsonRpc2GatewayServer(s, jsonrpc2grpc.NewGRPCGateway(server))
+//	s.Serve(listener)
+// The response body is empty for notifications. The client half, NewGRPCClient, is deferred until the package has
+// a Client type to implement, NewJsonRpc2GatewayClient calls the gateway meanwhile.
+func NewGRPCGateway(server jsonrpc2.Requester) JsonRpc2GatewayServer {
+	return &gateway{server: server}
+}
+
+// ============ Private members below =================
+
+type gateway struct {
+	UnimplementedJsonRpc2GatewayServer
+	server jsonrpc2.Requester
+}
+
+func (g *gateway) Execute(ctx context.Context, req *JsonRpcRequest) (*JsonRpcResponse, error) {
+	return &JsonRpcResponse{Body: g.server.ServeRequest(req.Body)}, nil
+}
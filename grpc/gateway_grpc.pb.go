@@ -0,0 +1,109 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: gateway.proto
+
+package grpc
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	JsonRpc2Gateway_Execute_FullMethodName = "/jsonrpc2.grpc.JsonRpc2Gateway/Execute"
+)
+
+// JsonRpc2GatewayClient is the client API for JsonRpc2Gateway service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type JsonRpc2GatewayClient interface {
+	Execute(ctx context.Context, in *JsonRpcRequest, opts ...grpc.CallOption) (*JsonRpcResponse, error)
+}
+
+type jsonRpc2GatewayClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewJsonRpc2GatewayClient(cc grpc.ClientConnInterface) JsonRpc2GatewayClient {
+	return &jsonRpc2GatewayClient{cc}
+}
+
+func (c *jsonRpc2GatewayClient) Execute(ctx context.Context, in *JsonRpcRequest, opts ...grpc.CallOption) (*JsonRpcResponse, error) {
+	out := new(JsonRpcResponse)
+	err := c.cc.Invoke(ctx, JsonRpc2Gateway_Execute_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// JsonRpc2GatewayServer is the server API for JsonRpc2Gateway service.
+// All implementations must embed UnimplementedJsonRpc2GatewayServer
+// for forward compatibility
+type JsonRpc2GatewayServer interface {
+	Execute(context.Context, *JsonRpcRequest) (*JsonRpcResponse, error)
+	mustEmbedUnimplementedJsonRpc2GatewayServer()
+}
+
+// UnimplementedJsonRpc2GatewayServer must be embedded to have forward compatible implementations.
+type UnimplementedJsonRpc2GatewayServer struct {
+}
+
+func (UnimplementedJsonRpc2GatewayServer) Execute(context.Context, *JsonRpcRequest) (*JsonRpcResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Execute not implemented")
+}
+func (UnimplementedJsonRpc2GatewayServer) mustEmbedUnimplementedJsonRpc2GatewayServer() {}
+
+// UnsafeJsonRpc2GatewayServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to JsonRpc2GatewayServer will
+// result in compilation errors.
+type UnsafeJsonRpc2GatewayServer interface {
+	mustEmbedUnimplementedJsonRpc2GatewayServer()
+}
+
+func RegisterJsonRpc2GatewayServer(s grpc.ServiceRegistrar, srv JsonRpc2GatewayServer) {
+	s.RegisterService(&JsonRpc2Gateway_ServiceDesc, srv)
+}
+
+func _JsonRpc2Gateway_Execute_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(JsonRpcRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(JsonRpc2GatewayServer).Execute(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: JsonRpc2Gateway_Execute_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(JsonRpc2GatewayServer).Execute(ctx, req.(*JsonRpcRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// JsonRpc2Gateway_ServiceDesc is the grpc.ServiceDesc for JsonRpc2Gateway service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var JsonRpc2Gateway_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "jsonrpc2.grpc.JsonRpc2Gateway",
+	HandlerType: (*JsonRpc2GatewayServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Execute",
+			Handler:    _JsonRpc2Gateway_Execute_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "gateway.proto",
+}
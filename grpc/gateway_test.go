@@ -0,0 +1,50 @@
+package grpc
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"github/brianso/go-jsonrpc2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+	"net"
+	"testing"
+)
+
+func TestNewGRPCGateway(t *testing.T) {
+	server := jsonrpc2.NewServer()
+	server.DefineMethod("echo", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		return params, nil
+	})
+	listener := bufconn.Listen(1 << 20)
+	s := grpc.NewServer()
+	RegisterJsonRpc2GatewayServer(s, NewGRPCGateway(server))
+	go s.Serve(listener)
+	defer s.Stop()
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	defer conn.Close()
+	client := NewJsonRpc2GatewayClient(conn)
+	execute := func(body string) string {
+		rsp, err := client.Execute(context.Background(), &JsonRpcRequest{Body: []byte(body)})
+		require.NoError(t, err)
+		return string(rsp.Body)
+	}
+
+	require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "result": "hi"}`, execute(`{"jsonrpc": "2.0", "method": "echo", "params": "hi", "id": 1}`))
+	require.JSONEq(t, `[
+		{"id": 1, "jsonrpc": "2.0", "result": 1},
+		{"id": 2, "jsonrpc": "2.0", "error": {"code": -32601, "message": "Method not found"}}
+	]`, execute(`[
+		{"jsonrpc": "2.0", "method": "echo", "params": 1, "id": 1},
+		{"jsonrpc": "2.0", "method": "missing", "id": 2}
+	]`))
+	require.Empty(t, execute(`{"jsonrpc": "2.0", "method": "echo", "params": "n"}`), "notification")
+	require.JSONEq(t, `{"id": null, "jsonrpc": "2.0", "error": {"code": -32700, "message": "Parse error"}}`, execute(`{`))
+}
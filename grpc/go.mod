@@ -0,0 +1,22 @@
+module github/brianso/go-jsonrpc2/grpc
+
+go 1.21
+
+require (
+	github.com/stretchr/testify v1.8.4
+	github/brianso/go-jsonrpc2 v0.0.0
+	google.golang.org/grpc v1.64.0
+	google.golang.org/protobuf v1.34.1
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/net v0.22.0 // indirect
+	golang.org/x/sys v0.18.0 // indirect
+	golang.org/x/text v0.14.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20240318140521-94a12d6c2237 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github/brianso/go-jsonrpc2 => ../
@@ -0,0 +1,101 @@
+package jsonrpc2
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+)
+
+// MethodDescription describes a method, as responded by "rpc.describe".
+type MethodDescription struct {
+	Name         string          `json:"name"`
+	Description  string          `json:"description,omitempty"`
+	Deprecated   bool            `json:"deprecated,omitempty"`
+	Since        string          `json:"since,omitempty"`
+	ParamsSchema json.RawMessage `json:"paramsSchema,omitempty"`
+	ResultSchema json.RawMessage `json:"resultSchema,omitempty"`
+}
+
+// EnableIntrospection defines the methods describing the server, from the MethodConfig of the methods:
+//	rpc.listMethods()             -> ["math.add", "rpc.describe", "rpc.listMethods"]
+//	rpc.describe("math.add")      -> {"name": "math.add", "description": "Add numbers", "paramsSchema": {...}}
+//	rpc.listNotifications()       -> [{"name": "order.created", "paramsSchema": {...}}], see DescribeNotification
+// rpc.describe takes the method by position or by name, as {"method": "math.add"}.
+func EnableIntrospection() ServerOption {
+	return func(s *server) {
+		s.defineBuiltins(MethodConfig{
+			Name:         describeMethod,
+			Handler:      s.describe,
+			Doc:          "Describe a method of the server",
+			ParamsSchema: json.RawMessage(`{"type":"array","items":[{"type":"string"}]}`),
+			ResultSchema: json.RawMessage(`{"type":"object"}`),
+		}, MethodConfig{
+			Name:         listMethodsMethod,
+			Handler:      s.listMethods,
+			Doc:          "List the methods of the server",
+			ResultSchema: json.RawMessage(`{"type":"array","items":{"type":"string"}}`),
+		}, MethodConfig{
+			Name:         listNotificationsMethod,
+			Handler:      s.listNotifications,
+			Doc:          "List the notifications pushed by the server",
+			ResultSchema: json.RawMessage(`{"type":"array","items":{"type":"object"}}`),
+		})
+	}
+}
+
+// DefineMethodWithDoc defines a method described by doc, see EnableIntrospection.
+func DefineMethodWithDoc(s Server, method string, h Handler, doc string) {
+	DefineMethodConfig(s, MethodConfig{Name: method, Handler: h, Doc: doc})
+}
+
+// DefineMethodWithSchema defines a method with the JSON schemas of its params and result, see EnableIntrospection.
+func DefineMethodWithSchema(s Server, method string, h Handler, paramsSchema, resultSchema json.RawMessage) {
+	DefineMethodConfig(s, MethodConfig{Name: method, Handler: h, ParamsSchema: paramsSchema, ResultSchema: resultSchema})
+}
+
+// ============ Private members below =================
+
+const (
+	describeMethod    = "rpc.describe"
+	listMethodsMethod = "rpc.listMethods"
+)
+
+func (s *server) describe(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var byPosition []string
+	var byName struct {
+		Method string `json:"method"`
+	}
+	method := ""
+	if err := json.Unmarshal(params, &byPosition); err == nil && len(byPosition) == 1 {
+		method = byPosition[0]
+	} else if err := json.Unmarshal(params, &byName); err == nil {
+		method = byName.Method
+	}
+	s.methodsMu.RLock()
+	m, ok := s.methods[method]
+	s.methodsMu.RUnlock()
+	if !ok || m.Hidden {
+		return nil, ErrInvalidParams
+	}
+	return MethodDescription{
+		Name:         m.Name,
+		Description:  m.Doc,
+		Deprecated:   m.Deprecated,
+		Since:        m.Since,
+		ParamsSchema: m.ParamsSchema,
+		ResultSchema: m.ResultSchema,
+	}, nil
+}
+
+func (s *server) listMethods(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	s.methodsMu.RLock()
+	methods := []string{}
+	for method, m := range s.methods {
+		if !m.Hidden {
+			methods = append(methods, method)
+		}
+	}
+	s.methodsMu.RUnlock()
+	sort.Strings(methods)
+	return methods, nil
+}
@@ -0,0 +1,49 @@
+package jsonrpc2
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestEnableIntrospection(t *testing.T) {
+	h := func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		return nil, nil
+	}
+	server := NewServer(EnableIntrospection())
+	DefineMethodWithDoc(server, "math.add", h, "Add numbers")
+	DefineMethodWithSchema(server, "math.sub", h, json.RawMessage(`{"type":"array"}`), json.RawMessage(`{"type":"number"}`))
+	DefineMethodConfig(server, MethodConfig{Name: "math.mul", Handler: h, Deprecated: true, Since: "v1.2"})
+	t.Run("list methods", func(t *testing.T) {
+		rsp := server.ServeRequest(json.RawMessage(`{ "jsonrpc": "2.0", "method": "rpc.listMethods", "id": 1 }`))
+		require.JSONEq(t, `{
+			"id": 1,
+			"jsonrpc": "2.0",
+			"result": ["math.add", "math.mul", "math.sub", "rpc.describe", "rpc.listMethods", "rpc.listNotifications"]
+		}`, string(rsp))
+	})
+	t.Run("describe", func(t *testing.T) {
+		rsp := server.ServeRequest(json.RawMessage(`[
+			{ "jsonrpc": "2.0", "method": "rpc.describe", "params": ["math.add"], "id": 1 },
+			{ "jsonrpc": "2.0", "method": "rpc.describe", "params": {"method": "math.sub"}, "id": 2 },
+			{ "jsonrpc": "2.0", "method": "rpc.describe", "params": ["math.mul"], "id": 3 },
+			{ "jsonrpc": "2.0", "method": "rpc.describe", "params": ["rpc.listMethods"], "id": 4 },
+			{ "jsonrpc": "2.0", "method": "rpc.describe", "params": ["unknown"], "id": 5 }
+		]`))
+		require.JSONEq(t, `[
+			{"id": 1, "jsonrpc": "2.0", "result": {"name": "math.add", "description": "Add numbers"}},
+			{"id": 2, "jsonrpc": "2.0", "result": {"name": "math.sub", "paramsSchema": {"type":"array"}, "resultSchema": {"type":"number"}}},
+			{"id": 3, "jsonrpc": "2.0", "result": {"name": "math.mul", "deprecated": true, "since": "v1.2"}},
+			{"id": 4, "jsonrpc": "2.0", "result": {
+				"name": "rpc.listMethods",
+				"description": "List the methods of the server",
+				"resultSchema": {"type":"array","items":{"type":"string"}}
+			}},
+			{"id": 5, "jsonrpc": "2.0", "error": {"code": -32602, "message": "Invalid Params"}}
+		]`, string(rsp))
+	})
+	t.Run("disabled by default", func(t *testing.T) {
+		require.False(t, NewServer().MethodExists("rpc.describe"))
+	})
+}
@@ -0,0 +1,70 @@
+// jsonrpc2test provides utilities for testing jsonrpc2 servers
+package jsonrpc2test
+
+import (
+	"encoding/json"
+	"github/brianso/go-jsonrpc2"
+	"testing"
+)
+
+// BenchmarkMethod benchmarks serving method with params. The benchmark fails if the method responds an error.
+// Usage:
+//	func BenchmarkEcho(b *testing.B) {
+//		jsonrpc2test.BenchmarkMethod(b, server, "echo", json.RawMessage(`"hi"`))
+//	}
+func BenchmarkMethod(b *testing.B, server jsonrpc2.Requester, method string, params json.RawMessage) {
+	req := makeRequest(b, method, params)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := checkResponse(server.ServeRequest(req)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkMethodParallel is BenchmarkMethod serving the requests from parallel goroutines.
+func BenchmarkMethodParallel(b *testing.B, server jsonrpc2.Requester, method string, params json.RawMessage) {
+	req := makeRequest(b, method, params)
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if err := checkResponse(server.ServeRequest(req)); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
+
+// ============ Private members below =================
+
+func makeRequest(b *testing.B, method string, params json.RawMessage) json.RawMessage {
+	req, err := json.Marshal(struct {
+		Version string          `json:"jsonrpc"`
+		Method  string          `json:"method"`
+		Params  json.RawMessage `json:"params,omitempty"`
+		ID      int             `json:"id"`
+	}{
+		Version: "2.0",
+		Method:  method,
+		Params:  params,
+		ID:      1,
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+	return req
+}
+
+func checkResponse(rsp json.RawMessage) error {
+	_, rpcErr, err := jsonrpc2.ExtractResult(rsp)
+	if err != nil {
+		return err
+	}
+	if rpcErr != nil {
+		return rpcErr
+	}
+	return nil
+}
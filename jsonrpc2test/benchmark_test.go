@@ -0,0 +1,31 @@
+package jsonrpc2test
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"github/brianso/go-jsonrpc2"
+	"testing"
+)
+
+func newEchoServer() jsonrpc2.Server {
+	server := jsonrpc2.NewServer()
+	server.DefineMethod("echo", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		return params, nil
+	})
+	return server
+}
+
+func TestCheckResponse(t *testing.T) {
+	require.NoError(t, checkResponse(json.RawMessage(`{"jsonrpc":"2.0","result":"hi","id":1}`)))
+	err := checkResponse(json.RawMessage(`{"jsonrpc":"2.0","error":{"code":-32601,"message":"Method not found"},"id":1}`))
+	require.EqualError(t, err, "Method not found")
+}
+
+func BenchmarkMethod_echo(b *testing.B) {
+	BenchmarkMethod(b, newEchoServer(), "echo", json.RawMessage(`"hi"`))
+}
+
+func BenchmarkMethodParallel_echo(b *testing.B) {
+	BenchmarkMethodParallel(b, newEchoServer(), "echo", json.RawMessage(`"hi"`))
+}
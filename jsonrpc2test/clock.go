@@ -0,0 +1,22 @@
+package jsonrpc2test
+
+import (
+	"github/brianso/go-jsonrpc2"
+	"time"
+)
+
+// FakeClock is a jsonrpc2.Clock whose time only moves by Advance, so timeouts trigger without waiting.
+// Usage:
+//	clock := jsonrpc2test.NewFakeClock(time.Now())
+//	server.SetClock(clock)
+//	go server.ServeRequest(req)
+//	clock.BlockUntil(1) // the request timeout timer is started
+//	clock.Advance(time.Second)
+type FakeClock = jsonrpc2.ManualClock
+
+// NewFakeClock returns a FakeClock starting at now.
+func NewFakeClock(now time.Time) *FakeClock {
+	c := jsonrpc2.MockClock()
+	c.Set(now)
+	return c
+}
@@ -0,0 +1,16 @@
+package jsonrpc2test
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestNewFakeClock(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+	require.Equal(t, start, clock.Now())
+	timer := clock.NewTimer(time.Second)
+	clock.Advance(time.Second)
+	require.Equal(t, start.Add(time.Second), <-timer.C())
+}
@@ -0,0 +1,92 @@
+package jsonrpc2test
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"github/brianso/go-jsonrpc2"
+	"os"
+	"path/filepath"
+)
+
+// GoldenCase is a request whose response is compared to the golden file <dir>/<Name>.golden.
+type GoldenCase struct {
+	Name    string
+	Request json.RawMessage
+}
+
+// ProtocolCases are the requests whose responses are defined by the JSON-RPC 2.0 specification, they do not depend on
+// the methods of the server.
+var ProtocolCases = []GoldenCase{
+	{Name: "parse_error", Request: json.RawMessage(`{"jsonrpc": "2.0", "method": "foobar, "params": "bar", "baz]`)},
+	{Name: "invalid_request", Request: json.RawMessage(`{"jsonrpc": "2.0", "method": 1, "params": "bar"}`)},
+	{Name: "invalid_version", Request: json.RawMessage(`{"jsonrpc": "1.0", "method": "foobar", "id": 1}`)},
+	{Name: "method_not_found", Request: json.RawMessage(`{"jsonrpc": "2.0", "method": "jsonrpc2test.unknown", "id": "1"}`)},
+	{Name: "notification", Request: json.RawMessage(`{"jsonrpc": "2.0", "method": "jsonrpc2test.unknown"}`)},
+	{Name: "empty_batch", Request: json.RawMessage(`[]`)},
+	{Name: "invalid_batch", Request: json.RawMessage(`[1, 2]`)},
+	{Name: "notification_batch", Request: json.RawMessage(`[{"jsonrpc": "2.0", "method": "jsonrpc2test.unknown"}]`)},
+}
+
+// Golden serves the requests of cases and compares their canonical responses, see jsonrpc2.CanonicalizeJSON, to the
+// golden files in dir. The golden files are written instead when the tests run with the -update flag.
+// It returns false if a response differs, so changes of the wire format are made consciously.
+// Usage:
+//	func TestGolden(t *testing.T) {
+//		cases := append(jsonrpc2test.ProtocolCases, jsonrpc2test.GoldenCase{
+//			Name:    "echo",
+//			Request: json.RawMessage(`{"jsonrpc": "2.0", "method": "echo", "params": "hi", "id": 1}`),
+//		})
+//		jsonrpc2test.Golden(t, server, "testdata", cases)
+//	}
+// and after a deliberate change:
+//	go test -run TestGolden -update
+func Golden(t TB, server jsonrpc2.Requester, dir string, cases []GoldenCase) bool {
+	t.Helper()
+	ok := true
+	for _, c := range cases {
+		rsp := server.ServeRequest(c.Request)
+		var got []byte
+		if rsp != nil {
+			var err error
+			if got, err = jsonrpc2.CanonicalizeJSON(rsp); err != nil {
+				t.Errorf("%s: invalid response %s: %v", c.Name, rsp, err)
+				ok = false
+				continue
+			}
+		}
+		path := filepath.Join(dir, c.Name+".golden")
+		if *update {
+			if err := writeGolden(path, got); err != nil {
+				t.Errorf("%s: %v", c.Name, err)
+				ok = false
+			}
+			continue
+		}
+		want, err := os.ReadFile(path)
+		if err != nil {
+			t.Errorf("%s: %v, run the tests with -update to create it", c.Name, err)
+			ok = false
+			continue
+		}
+		if want = bytes.TrimSuffix(want, []byte("\n")); !bytes.Equal(got, want) {
+			t.Errorf("%s: response differs from %s\ngot:  %s\nwant: %s", c.Name, path, got, want)
+			ok = false
+		}
+	}
+	return ok
+}
+
+// ============ Private members below =================
+
+var update = flag.Bool("update", false, "update the golden files of jsonrpc2test.Golden")
+
+func writeGolden(path string, rsp []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	if len(rsp) > 0 {
+		rsp = append(rsp, '\n')
+	}
+	return os.WriteFile(path, rsp, 0644)
+}
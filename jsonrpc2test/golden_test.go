@@ -0,0 +1,46 @@
+package jsonrpc2test
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGolden(t *testing.T) {
+	dir := t.TempDir()
+	cases := append(ProtocolCases, GoldenCase{
+		Name:    "echo",
+		Request: json.RawMessage(`{"jsonrpc": "2.0", "method": "echo", "params": {"b": 1, "a": "é"}, "id": 1}`),
+	})
+	t.Run("missing golden files", func(t *testing.T) {
+		mock := &recordingT{}
+		require.False(t, Golden(mock, newEchoServer(), dir, cases))
+		require.Len(t, mock.failures, len(cases))
+		require.Contains(t, mock.failures[0], "parse_error: ")
+		require.Contains(t, mock.failures[0], "run the tests with -update to create it")
+	})
+	t.Run("update", func(t *testing.T) {
+		*update = true
+		defer func() { *update = false }()
+		require.True(t, Golden(t, newEchoServer(), dir, cases))
+		b, err := os.ReadFile(filepath.Join(dir, "echo.golden"))
+		require.NoError(t, err)
+		require.Equal(t, `{"id":1,"jsonrpc":"2.0","result":{"a":"é","b":1}}`+"\n", string(b))
+		b, err = os.ReadFile(filepath.Join(dir, "notification.golden"))
+		require.NoError(t, err)
+		require.Empty(t, b)
+	})
+	t.Run("compare", func(t *testing.T) {
+		require.True(t, Golden(t, newEchoServer(), dir, cases))
+		changed := GoldenCase{
+			Name:    "echo",
+			Request: json.RawMessage(`{"jsonrpc": "2.0", "method": "echo", "params": {"b": 2}, "id": 1}`),
+		}
+		mock := &recordingT{}
+		require.False(t, Golden(mock, newEchoServer(), dir, []GoldenCase{changed}))
+		require.Equal(t, []string{"echo: response differs from " + filepath.Join(dir, "echo.golden") +
+			"\ngot:  {\"id\":1,\"jsonrpc\":\"2.0\",\"result\":{\"b\":2}}\nwant: {\"id\":1,\"jsonrpc\":\"2.0\",\"result\":{\"a\":\"é\",\"b\":1}}"}, mock.failures)
+	})
+}
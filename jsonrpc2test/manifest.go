@@ -0,0 +1,17 @@
+package jsonrpc2test
+
+import (
+	"github/brianso/go-jsonrpc2"
+)
+
+// RequireMethods fails the test at once if the methods of server are not exactly manifest, see jsonrpc2.VerifyManifest.
+// Usage:
+//	func TestMethods(t *testing.T) {
+//		jsonrpc2test.RequireMethods(t, newServer(), []string{"account.get_balance", "account.transfer"})
+//	}
+func RequireMethods(t TB, server jsonrpc2.Introspector, manifest []string) {
+	t.Helper()
+	if err := server.VerifyMethods(manifest); err != nil {
+		t.Fatalf("%v", err)
+	}
+}
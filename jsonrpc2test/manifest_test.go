@@ -0,0 +1,14 @@
+package jsonrpc2test
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestRequireMethods(t *testing.T) {
+	RequireMethods(t, newEchoServer(), []string{"echo"})
+
+	mock := &recordingT{}
+	RequireMethods(mock, newEchoServer(), []string{"echo", "missing"})
+	require.Len(t, mock.failures, 1)
+}
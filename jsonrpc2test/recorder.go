@@ -0,0 +1,110 @@
+package jsonrpc2test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github/brianso/go-jsonrpc2"
+	"sync"
+	"time"
+)
+
+type (
+	// Recorder records the calls of the methods of a server to assert them in tests. It is safe for concurrent use.
+	// Usage:
+	//	recorder := jsonrpc2test.NewRecorder()
+	//	server.Use(recorder.Middleware())
+	//	...
+	//	recorder.AssertCalled(t, "users.get", 1)
+	Recorder struct {
+		mu    sync.Mutex
+		calls []RecordedCall
+	}
+
+	// RecordedCall is a call recorded by a Recorder.
+	RecordedCall struct {
+		Method string
+		Params json.RawMessage
+		// Response is the json of the result, nil on error
+		Response json.RawMessage
+		// Err is the error returned, errors which are not jsonrpc2.Error are recorded as responded
+		Err      jsonrpc2.Error
+		Duration time.Duration
+	}
+)
+
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+// Middleware returns the middleware recording the calls, the calls are recorded when the handler returns.
+func (r *Recorder) Middleware() jsonrpc2.Middleware {
+	return func(next jsonrpc2.Handler) jsonrpc2.Handler {
+		return func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+			clock := jsonrpc2.ClockFromContext(ctx)
+			start := clock.Now()
+			result, err := next(ctx, params)
+			call := RecordedCall{
+				Params:   params,
+				Duration: clock.Now().Sub(start),
+			}
+			if info := jsonrpc2.MethodCallInfo(ctx); info != nil {
+				call.Method = info.Method
+			}
+			if err != nil {
+				if !errors.As(err, &call.Err) {
+					call.Err = jsonrpc2.NewInternalError(err.Error())
+				}
+			} else {
+				call.Response, _ = json.Marshal(result)
+			}
+			r.mu.Lock()
+			r.calls = append(r.calls, call)
+			r.mu.Unlock()
+			return result, err
+		}
+	}
+}
+
+// Calls returns the recorded calls in the order they returned.
+func (r *Recorder) Calls() []RecordedCall {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]RecordedCall(nil), r.calls...)
+}
+
+// CallsForMethod returns the recorded calls of method.
+func (r *Recorder) CallsForMethod(method string) []RecordedCall {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var calls []RecordedCall
+	for _, call := range r.calls {
+		if call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// Reset forgets the recorded calls.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = nil
+}
+
+// AssertCalled fails the test if method was not called exactly times.
+func (r *Recorder) AssertCalled(t TB, method string, times int) bool {
+	t.Helper()
+	if n := len(r.CallsForMethod(method)); n != times {
+		t.Errorf("method %q called %d times, expected %d", method, n, times)
+		return false
+	}
+	return true
+}
+
+// AssertNotCalled fails the test if method was called.
+func (r *Recorder) AssertNotCalled(t TB, method string) bool {
+	t.Helper()
+	return r.AssertCalled(t, method, 0)
+}
@@ -0,0 +1,66 @@
+package jsonrpc2test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/require"
+	"github/brianso/go-jsonrpc2"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRecorder(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	recorder := NewRecorder()
+	server := jsonrpc2.NewServer(jsonrpc2.WithClock(clock))
+	server.Use(recorder.Middleware())
+	server.DefineMethod("echo", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		clock.Advance(time.Millisecond)
+		return params, nil
+	})
+	server.DefineMethod("fail", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		return nil, errors.New("boom")
+	})
+	server.DefineMethod("invalid", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		return nil, jsonrpc2.ErrInvalidParams
+	})
+
+	t.Run("record calls", func(t *testing.T) {
+		recorder.Reset()
+		server.ServeRequest(json.RawMessage(`{ "jsonrpc": "2.0", "method": "echo", "params": ["hi"], "id": 1 }`))
+		server.ServeRequest(json.RawMessage(`{ "jsonrpc": "2.0", "method": "fail", "id": 2 }`))
+		server.ServeRequest(json.RawMessage(`{ "jsonrpc": "2.0", "method": "invalid", "id": 3 }`))
+		require.Equal(t, []RecordedCall{
+			{Method: "echo", Params: json.RawMessage(`["hi"]`), Response: json.RawMessage(`["hi"]`), Duration: time.Millisecond},
+			{Method: "fail", Err: jsonrpc2.NewInternalError("boom")},
+			{Method: "invalid", Err: jsonrpc2.ErrInvalidParams},
+		}, recorder.Calls())
+		require.Len(t, recorder.CallsForMethod("fail"), 1)
+		recorder.AssertCalled(t, "echo", 1)
+		recorder.AssertNotCalled(t, "unknown")
+	})
+	t.Run("failed assertions", func(t *testing.T) {
+		mock := &recordingT{}
+		require.False(t, recorder.AssertCalled(mock, "echo", 2))
+		require.False(t, recorder.AssertNotCalled(mock, "echo"))
+		require.Equal(t, []string{
+			`method "echo" called 1 times, expected 2`,
+			`method "echo" called 1 times, expected 0`,
+		}, mock.failures)
+	})
+	t.Run("concurrent calls", func(t *testing.T) {
+		recorder.Reset()
+		var wg sync.WaitGroup
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				server.ServeRequest(json.RawMessage(`{ "jsonrpc": "2.0", "method": "invalid", "id": 1 }`))
+			}()
+		}
+		wg.Wait()
+		recorder.AssertCalled(t, "invalid", 50)
+	})
+}
@@ -0,0 +1,9 @@
+package jsonrpc2test
+
+// TB is the part of testing.TB used by the helpers of the package, so a *testing.T or *testing.B can be passed, or a
+// fake to check the failures of a helper.
+type TB interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+	Fatalf(format string, args ...interface{})
+}
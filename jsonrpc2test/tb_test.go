@@ -0,0 +1,24 @@
+package jsonrpc2test
+
+import (
+	"fmt"
+	"sync"
+)
+
+// recordingT is a TB recording the failures reported to it
+type recordingT struct {
+	mu       sync.Mutex
+	failures []string
+}
+
+func (t *recordingT) Helper() {}
+
+func (t *recordingT) Errorf(format string, args ...interface{}) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.failures = append(t.failures, fmt.Sprintf(format, args...))
+}
+
+func (t *recordingT) Fatalf(format string, args ...interface{}) {
+	t.Errorf(format, args...)
+}
@@ -0,0 +1,169 @@
+package jsonrpc2
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type (
+	// KVStore is the storage of the stateful middlewares, e.g. NonceMiddleware with NewKVNonceStore, so the replicas of
+	// a server can share their state. Expiries are measured by the server clock in memory, by the store otherwise.
+	// Implementations must be safe for concurrent use.
+	KVStore interface {
+		// Get returns the value of key, ok is false if key is not set or expired
+		Get(ctx context.Context, key string) (value []byte, ok bool, err error)
+		// Set sets key to value for ttl, without expiry if ttl is 0
+		Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+		// CompareAndSwap sets key to value for ttl if its value is old, or if it is not set when old is nil, atomically.
+		// It returns whether key was set.
+		CompareAndSwap(ctx context.Context, key string, old, value []byte, ttl time.Duration) (swapped bool, err error)
+		// Incr adds delta to the integer value of key, 0 if not set, and returns the result. ttl is the expiry of key
+		// when it is created, the expiry of an existing key is kept.
+		Incr(ctx context.Context, key string, delta int64, ttl time.Duration) (int64, error)
+		Delete(ctx context.Context, key string) error
+	}
+
+	// StoreFailurePolicy is the behavior of a component when its store fails, e.g. when Redis is unreachable.
+	StoreFailurePolicy int
+)
+
+const (
+	// FailClosed responds the errors of the store, rejecting the requests
+	FailClosed StoreFailurePolicy = iota
+	// FailOpen logs the errors of the store at WARN level by the logger of the request and lets the requests through, e.g. unchecked for
+	// replays
+	FailOpen
+)
+
+// NewMemoryKVStore returns a KVStore in memory, for a single replica or tests.
+func NewMemoryKVStore() KVStore {
+	return &memoryKVStore{entries: map[string]kvEntry{}}
+}
+
+// NewKVNonceStore returns a NonceStore keeping the nonces in kv under prefix, e.g. "nonce:", so the replicas sharing
+// kv detect the replays of each other.
+//	server.Use(jsonrpc2.NonceMiddleware(jsonrpc2.NewKVNonceStore(kv, "nonce:", jsonrpc2.FailClosed), time.Minute))
+func NewKVNonceStore(kv KVStore, prefix string, policy StoreFailurePolicy) NonceStore {
+	return &kvNonceStore{kv: kv, prefix: prefix, policy: policy}
+}
+
+// ============ Private members below =================
+
+// kvSweepInterval is the number of writes between the removals of the expired entries of a memoryKVStore
+const kvSweepInterval = 1024
+
+var errNotInteger = errors.New("jsonrpc2: value is not an integer")
+
+type (
+	memoryKVStore struct {
+		mu      sync.Mutex
+		entries map[string]kvEntry
+		writes  int
+	}
+
+	kvEntry struct {
+		value []byte
+		// expiry is zero for no expiry
+		expiry time.Time
+	}
+
+	kvNonceStore struct {
+		kv     KVStore
+		prefix string
+		policy StoreFailurePolicy
+	}
+)
+
+func (s *memoryKVStore) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.get(ClockFromContext(ctx).Now(), key)
+	return bytes.Clone(e.value), ok, nil
+}
+
+func (s *memoryKVStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.set(ClockFromContext(ctx).Now(), key, kvEntry{value: bytes.Clone(value)}, ttl)
+	return nil
+}
+
+func (s *memoryKVStore) CompareAndSwap(ctx context.Context, key string, old, value []byte, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := ClockFromContext(ctx).Now()
+	e, ok := s.get(now, key)
+	if ok != (old != nil) || ok && !bytes.Equal(e.value, old) {
+		return false, nil
+	}
+	s.set(now, key, kvEntry{value: bytes.Clone(value)}, ttl)
+	return true, nil
+}
+
+func (s *memoryKVStore) Incr(ctx context.Context, key string, delta int64, ttl time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := ClockFromContext(ctx).Now()
+	e, ok := s.get(now, key)
+	var n int64
+	if ok {
+		var err error
+		if n, err = strconv.ParseInt(string(e.value), 10, 64); err != nil {
+			return 0, errNotInteger
+		}
+	}
+	n += delta
+	value := []byte(strconv.FormatInt(n, 10))
+	if ok {
+		s.entries[key] = kvEntry{value: value, expiry: e.expiry}
+	} else {
+		s.set(now, key, kvEntry{value: value}, ttl)
+	}
+	return n, nil
+}
+
+func (s *memoryKVStore) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, key)
+	return nil
+}
+
+// get returns the entry of key if it is not expired at now, s.mu must be held
+func (s *memoryKVStore) get(now time.Time, key string) (kvEntry, bool) {
+	e, ok := s.entries[key]
+	if ok && !e.expiry.IsZero() && !e.expiry.After(now) {
+		delete(s.entries, key)
+		return kvEntry{}, false
+	}
+	return e, ok
+}
+
+// set sets the entry of key expiring after ttl, and removes the expired entries every kvSweepInterval writes,
+// s.mu must be held
+func (s *memoryKVStore) set(now time.Time, key string, e kvEntry, ttl time.Duration) {
+	if ttl > 0 {
+		e.expiry = now.Add(ttl)
+	}
+	s.entries[key] = e
+	if s.writes++; s.writes%kvSweepInterval == 0 {
+		for k, e := range s.entries {
+			if !e.expiry.IsZero() && !e.expiry.After(now) {
+				delete(s.entries, k)
+			}
+		}
+	}
+}
+
+func (s *kvNonceStore) Add(ctx context.Context, nonce string, ttl time.Duration) (bool, error) {
+	added, err := s.kv.CompareAndSwap(ctx, s.prefix+nonce, nil, []byte{'1'}, ttl)
+	if err != nil && s.policy == FailOpen {
+		LoggerFromContext(ctx).WarnContext(ctx, "jsonrpc2: nonce store failed, nonce not checked", "error", err)
+		return true, nil
+	}
+	return added, err
+}
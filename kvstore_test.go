@@ -0,0 +1,97 @@
+package jsonrpc2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestMemoryKVStore(t *testing.T) {
+	clock := MockClock()
+	ctx := context.WithValue(context.Background(), clockContextKey{}, Clock(clock))
+	kv := NewMemoryKVStore()
+
+	_, ok, err := kv.Get(ctx, "a")
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.NoError(t, kv.Set(ctx, "a", []byte("1"), time.Minute))
+	value, ok, _ := kv.Get(ctx, "a")
+	require.True(t, ok)
+	require.Equal(t, []byte("1"), value)
+
+	// check-and-set
+	swapped, _ := kv.CompareAndSwap(ctx, "a", nil, []byte("2"), 0)
+	require.False(t, swapped, "a is set")
+	swapped, _ = kv.CompareAndSwap(ctx, "a", []byte("0"), []byte("2"), 0)
+	require.False(t, swapped)
+	swapped, _ = kv.CompareAndSwap(ctx, "a", []byte("1"), []byte("2"), time.Minute)
+	require.True(t, swapped)
+	swapped, _ = kv.CompareAndSwap(ctx, "b", nil, []byte("1"), 0)
+	require.True(t, swapped)
+
+	// counters keep their expiry
+	n, _ := kv.Incr(ctx, "a", 3, time.Hour)
+	require.Equal(t, int64(5), n)
+	n, _ = kv.Incr(ctx, "c", -1, time.Hour)
+	require.Equal(t, int64(-1), n)
+	kv.Set(ctx, "d", []byte("x"), 0)
+	_, err = kv.Incr(ctx, "d", 1, 0)
+	require.Error(t, err)
+
+	clock.Advance(time.Minute)
+	_, ok, _ = kv.Get(ctx, "a")
+	require.False(t, ok, "a is expired")
+	swapped, _ = kv.CompareAndSwap(ctx, "a", nil, []byte("1"), 0)
+	require.True(t, swapped)
+	n, _ = kv.Incr(ctx, "c", -1, time.Hour)
+	require.Equal(t, int64(-2), n)
+	_, ok, _ = kv.Get(ctx, "b")
+	require.True(t, ok, "b does not expire")
+
+	require.NoError(t, kv.Delete(ctx, "b"))
+	_, ok, _ = kv.Get(ctx, "b")
+	require.False(t, ok)
+}
+
+func TestNewKVNonceStore(t *testing.T) {
+	ctx := context.Background()
+	kv := NewMemoryKVStore()
+	store := NewKVNonceStore(kv, "nonce:", FailClosed)
+	added, err := store.Add(ctx, "f81d4fae", time.Minute)
+	require.NoError(t, err)
+	require.True(t, added)
+	added, _ = NewKVNonceStore(kv, "nonce:", FailClosed).Add(ctx, "f81d4fae", time.Minute)
+	require.False(t, added, "the stores sharing kv detect the replays of each other")
+	_, ok, _ := kv.Get(ctx, "nonce:f81d4fae")
+	require.True(t, ok)
+
+	failing := failingKVStore{KVStore: kv}
+	req := json.RawMessage(`{"jsonrpc": "2.0", "method": "transfer", "nonce": "1", "id": 1}`)
+	server := NewServer()
+	server.DefineMethod("transfer", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		return "ok", nil
+	})
+	server.Use(NonceMiddleware(NewKVNonceStore(failing, "nonce:", FailClosed), time.Minute))
+	require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "error": {"code": -32000, "message": "unreachable"}}`,
+		string(server.ServeRequest(req)))
+
+	server.Reset()
+	server.DefineMethod("transfer", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		return "ok", nil
+	})
+	server.Use(NonceMiddleware(NewKVNonceStore(failing, "nonce:", FailOpen), time.Minute))
+	require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "result": "ok"}`, string(server.ServeRequest(req)))
+	require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "result": "ok"}`, string(server.ServeRequest(req)))
+}
+
+// failingKVStore fails the check-and-set, as a store which is unreachable
+type failingKVStore struct {
+	KVStore
+}
+
+func (failingKVStore) CompareAndSwap(ctx context.Context, key string, old, value []byte, ttl time.Duration) (bool, error) {
+	return false, errors.New("unreachable")
+}
@@ -0,0 +1,89 @@
+package jsonrpc2
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+)
+
+// WithLogger sets the logger LoggerFromContext derives the loggers of the requests from, slog.Default() otherwise.
+func WithLogger(l *slog.Logger) ServerOption {
+	return func(s *server) {
+		s.logger = l
+	}
+}
+
+// LoggerFromContext returns the logger of the request served with ctx, with the "method" and "id" of the request and
+// the attributes added by WithLogAttrs, e.g. a tenant added by a middleware. It returns slog.Default() for a context
+// which is not of a request, so handlers never check for nil.
+// Usage:
+//	jsonrpc2.LoggerFromContext(ctx).Info("charging card", "amount", amount)
+//	// level=INFO msg="charging card" method=payment.charge id=42 tenant=acme amount=100
+func LoggerFromContext(ctx context.Context) *slog.Logger {
+	l, ok := ctx.Value(loggerContextKey{}).(*requestLogger)
+	if !ok {
+		return slog.Default()
+	}
+	base := l.base
+	if base == nil {
+		base = slog.Default()
+	}
+	args := make([]any, len(l.attrs))
+	for i, attr := range l.attrs {
+		args[i] = attr
+	}
+	return base.With(args...)
+}
+
+// WithLogAttrs returns ctx whose LoggerFromContext has attrs too, e.g. in a middleware:
+//	ctx = jsonrpc2.WithLogAttrs(ctx, slog.String("tenant", tenantOf(ctx)))
+//	return next(ctx, params)
+func WithLogAttrs(ctx context.Context, attrs ...slog.Attr) context.Context {
+	l, ok := ctx.Value(loggerContextKey{}).(*requestLogger)
+	if !ok {
+		l = &requestLogger{}
+	}
+	return context.WithValue(ctx, loggerContextKey{}, &requestLogger{
+		base:  l.base,
+		attrs: append(l.attrs[:len(l.attrs):len(l.attrs)], attrs...),
+	})
+}
+
+// ============ Private members below =================
+
+type (
+	loggerContextKey struct{}
+
+	// requestLogger is the logger of a request, made by LoggerFromContext only when used
+	requestLogger struct {
+		// base is nil for slog.Default()
+		base  *slog.Logger
+		attrs []slog.Attr
+	}
+)
+
+// withRequestLogger returns ctx with the logger of r
+func (s *server) withRequestLogger(ctx context.Context, r request) context.Context {
+	attrs := []slog.Attr{slog.String("method", r.Method)}
+	if r.ID != nil {
+		attrs = append(attrs, slog.String("id", logID(r.ID)))
+	}
+	return context.WithValue(ctx, loggerContextKey{}, &requestLogger{base: s.logger, attrs: attrs})
+}
+
+// requestLog returns the logger of the built-in logs about r served with ctx, which may not have the logger of r yet
+func (s *server) requestLog(ctx context.Context, r request) *slog.Logger {
+	if _, ok := ctx.Value(loggerContextKey{}).(*requestLogger); !ok {
+		ctx = s.withRequestLogger(ctx, r)
+	}
+	return LoggerFromContext(ctx)
+}
+
+// logID returns id as logged, the string ids unquoted
+func logID(id json.RawMessage) string {
+	var s string
+	if json.Unmarshal(id, &s) == nil {
+		return s
+	}
+	return string(id)
+}
@@ -0,0 +1,78 @@
+package jsonrpc2
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestLoggerFromContext(t *testing.T) {
+	var logs bytes.Buffer
+	server := NewServer(WithLogger(slog.New(slog.NewJSONHandler(&logs, nil))))
+	server.Use(func(next Handler) Handler {
+		return func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+			return next(WithLogAttrs(ctx, slog.String("tenant", "acme")), params)
+		}
+	})
+	server.DefineMethod("payment.charge", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		LoggerFromContext(WithLogAttrs(ctx, slog.Int("attempt", 1))).Info("charging card", "amount", 100)
+		LoggerFromContext(ctx).Info("charged")
+		return nil, nil
+	})
+	record := func() map[string]interface{} {
+		var r map[string]interface{}
+		line, err := logs.ReadBytes('\n')
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(line, &r))
+		delete(r, "time")
+		return r
+	}
+
+	server.ServeRequest(json.RawMessage(`{"jsonrpc": "2.0", "method": "payment.charge", "id": 42}`))
+	require.Equal(t, map[string]interface{}{
+		"level": "INFO", "msg": "charging card", "method": "payment.charge", "id": "42", "tenant": "acme",
+		"attempt": float64(1), "amount": float64(100),
+	}, record())
+	// the attributes added to a context do not leak to its parent
+	require.Equal(t, map[string]interface{}{
+		"level": "INFO", "msg": "charged", "method": "payment.charge", "id": "42", "tenant": "acme",
+	}, record())
+
+	// no id for a notification
+	server.ServeRequest(json.RawMessage(`{"jsonrpc": "2.0", "method": "payment.charge"}`))
+	require.NotContains(t, record(), "id")
+
+	require.Same(t, slog.Default(), LoggerFromContext(context.Background()))
+}
+
+func TestWithLogger_BuiltinLogs(t *testing.T) {
+	var logs bytes.Buffer
+	clock := MockClock()
+	server := NewServer(WithClock(clock), WithLogger(slog.New(slog.NewTextHandler(&logs, nil))))
+	server.SetSlowThreshold(time.Second)
+	server.SetLogNotificationErrors(true)
+	server.Use(func(next Handler) Handler {
+		return func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+			return next(WithLogAttrs(ctx, slog.String("tenant", "acme")), params)
+		}
+	})
+	server.DefineMethod("slow", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		ClockFromContext(ctx).Sleep(2 * time.Second)
+		return nil, nil
+	})
+	rsp := make(chan json.RawMessage)
+	go func() {
+		rsp <- server.ServeRequest(json.RawMessage(`{"jsonrpc": "2.0", "method": "slow", "id": "abc"}`))
+	}()
+	clock.BlockUntil(1)
+	clock.Advance(2 * time.Second)
+	<-rsp
+	require.Contains(t, logs.String(), `msg="jsonrpc2: slow request" method=slow id=abc duration=2s params_bytes=0`)
+
+	server.ServeRequest(json.RawMessage(`{"jsonrpc": "2.0", "method": "undefined"}`))
+	require.Contains(t, logs.String(), `msg="jsonrpc2: notification error" method=undefined code=-32601`)
+}
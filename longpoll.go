@@ -0,0 +1,202 @@
+package jsonrpc2
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// LongPollServer serves jsonrpc requests over http for clients which cannot keep a connection, e.g. behind proxies.
+// The requests are served by the server, except the polls, whose params have a "_pollToken" member: they wait up to
+// the timeout for the result completed for their token by Complete, typically in the background after a request
+// started the work and returned the token.
+//	--> {"jsonrpc": "2.0", "method": "export.poll", "params": {"_pollToken": "f81d4fae"}, "id": 2}
+//	<-- {"jsonrpc": "2.0", "result": {"url": "..."}, "id": 2}
+// If no result is completed in time, the poll responds a null result with the "retry" extension member:
+//	<-- {"jsonrpc": "2.0", "result": null, "retry": true, "id": 2}
+// The poll is served by the server first, so its method must be defined, e.g. to check the caller may poll the token.
+// Its error is responded at once, its result is ignored. The timeout is measured by the clock of the server, if it
+// is a server of the package.
+type LongPollServer struct {
+	server    Requester
+	timeout   time.Duration
+	resultTTL time.Duration
+
+	mu sync.Mutex
+	// results holds the results completed and not yet polled, and the channels of the polls waiting for them
+	results map[string]*pollResult
+}
+
+// NewLongPollHandler returns a LongPollServer serving the requests with server and holding the polls up to timeout.
+func NewLongPollHandler(server Requester, timeout time.Duration) *LongPollServer {
+	return &LongPollServer{
+		server:    server,
+		timeout:   timeout,
+		resultTTL: DefaultPollResultTTL,
+		results:   map[string]*pollResult{},
+	}
+}
+
+// DefaultPollResultTTL is how long a LongPollServer keeps the results completed and not polled, see SetResultTTL.
+const DefaultPollResultTTL = time.Minute
+
+// SetResultTTL sets how long the results completed and not polled are kept, DefaultPollResultTTL by default.
+// It must not be called while serving requests.
+func (l *LongPollServer) SetResultTTL(d time.Duration) {
+	l.resultTTL = d
+}
+
+// Complete sets the result of token, responded to its poll. A result is kept until polled, once, or until the
+// result TTL elapsed.
+func (l *LongPollServer) Complete(token string, result json.RawMessage) {
+	now := l.clock().Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.expire(now)
+	p := l.result(token)
+	select {
+	case p.c <- result:
+		p.expires = now.Add(l.resultTTL)
+	default:
+		// already completed
+	}
+}
+
+func (l *LongPollServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	rsp := l.server.ServeRequest(body)
+	if token := pollToken(body); token != "" {
+		rsp = l.poll(r, rsp, token)
+	}
+	if rsp == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(rsp)
+}
+
+// ============ Private members below =================
+
+type (
+	// pollResponse is the response to a poll, with the retry extension member
+	pollResponse struct {
+		ID      json.RawMessage `json:"id"`
+		Version string          `json:"jsonrpc"`
+		Result  json.RawMessage `json:"result"`
+		Retry   bool            `json:"retry,omitempty"`
+	}
+
+	// pollResult is the result of a token, completed or awaited by polls
+	pollResult struct {
+		c chan json.RawMessage
+		// polls is the number of polls waiting for the result
+		polls int
+		// expires is when the completed result is dropped if not polled
+		expires time.Time
+	}
+)
+
+// pollToken returns the "_pollToken" param if body is a single request with an id and a token
+func pollToken(body []byte) string {
+	var req request
+	if err := json.Unmarshal(body, &req); err != nil || req.ID == nil {
+		return ""
+	}
+	var params struct {
+		PollToken string `json:"_pollToken"`
+	}
+	if err := json.Unmarshal(req.Params, &params); err != nil {
+		return ""
+	}
+	return params.PollToken
+}
+
+// clock returns the clock of the server, or the real clock if it is not a server of the package
+func (l *LongPollServer) clock() Clock {
+	switch s := l.server.(type) {
+	case *server:
+		return s.clock
+	case *MethodRouter:
+		return s.Server.(*server).clock
+	}
+	return realClock{}
+}
+
+// result returns the result of token, l.mu must be held
+func (l *LongPollServer) result(token string) *pollResult {
+	p, ok := l.results[token]
+	if !ok {
+		p = &pollResult{c: make(chan json.RawMessage, 1)}
+		l.results[token] = p
+	}
+	return p
+}
+
+// expire drops the completed results not polled in time, l.mu must be held
+func (l *LongPollServer) expire(now time.Time) {
+	for token, p := range l.results {
+		if p.polls == 0 && !p.expires.IsZero() && !now.Before(p.expires) {
+			delete(l.results, token)
+		}
+	}
+}
+
+// release stops a poll waiting for the result of token. The result is dropped once polled, or if it is not completed
+// and no other poll waits for it.
+func (l *LongPollServer) release(token string, p *pollResult, polled bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	p.polls--
+	if l.results[token] == p && (polled || p.polls == 0 && len(p.c) == 0) {
+		delete(l.results, token)
+	}
+}
+
+// poll waits for the result of token once the server responded served without error
+func (l *LongPollServer) poll(r *http.Request, served json.RawMessage, token string) json.RawMessage {
+	var rsp struct {
+		pollResponse
+		Error json.RawMessage `json:"error"`
+	}
+	if err := json.Unmarshal(served, &rsp); err != nil || rsp.Error != nil {
+		return served
+	}
+	clock := l.clock()
+	l.mu.Lock()
+	l.expire(clock.Now())
+	p := l.result(token)
+	p.polls++
+	l.mu.Unlock()
+
+	timer := clock.NewTimer(l.timeout)
+	defer timer.Stop()
+	poll := pollResponse{ID: rsp.ID, Version: rsp.Version, Result: json.RawMessage("null")}
+	select {
+	case poll.Result = <-p.c:
+	case <-timer.C():
+		// the result may be completed along with the timeout
+		select {
+		case poll.Result = <-p.c:
+		default:
+			poll.Retry = true
+		}
+	case <-r.Context().Done():
+		l.release(token, p, false)
+		return nil
+	}
+	l.release(token, p, !poll.Retry)
+	b, _ := json.Marshal(poll)
+	return b
+}
@@ -0,0 +1,136 @@
+package jsonrpc2
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLongPollHandler(t *testing.T) {
+	clock := MockClock()
+	server := NewServer(WithClock(clock))
+	server.DefineMethod("echo", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		return params, nil
+	})
+	server.DefineMethod("poll", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		var p struct {
+			PollToken string `json:"_pollToken"`
+		}
+		json.Unmarshal(params, &p)
+		if p.PollToken == "forbidden" {
+			return nil, ErrInvalidParams
+		}
+		return nil, nil
+	})
+	handler := NewLongPollHandler(server, 50*time.Millisecond)
+	handler.SetResultTTL(time.Minute)
+	ts := httptest.NewServer(handler)
+	defer ts.Close()
+	post := func(body string) (int, string) {
+		rsp, err := http.Post(ts.URL, "application/json", strings.NewReader(body))
+		if !assert.NoError(t, err) {
+			return 0, ""
+		}
+		defer rsp.Body.Close()
+		b, _ := io.ReadAll(rsp.Body)
+		return rsp.StatusCode, string(b)
+	}
+	poll := func(token string) string {
+		_, rsp := post(`{ "jsonrpc": "2.0", "method": "poll", "params": {"_pollToken": "` + token + `"}, "id": 1 }`)
+		return rsp
+	}
+	pending := func() int {
+		handler.mu.Lock()
+		defer handler.mu.Unlock()
+		return len(handler.results)
+	}
+
+	t.Run("simultaneous pollers", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				assert.JSONEq(t, fmt.Sprintf(`{"id": 1, "jsonrpc": "2.0", "result": %d}`, i), poll(fmt.Sprint("token", i)))
+			}(i)
+		}
+		for i := 0; i < 5; i++ {
+			handler.Complete(fmt.Sprint("token", i), json.RawMessage(fmt.Sprint(i)))
+		}
+		wg.Wait()
+		require.Zero(t, pending())
+	})
+	t.Run("completed before poll", func(t *testing.T) {
+		handler.Complete("early", json.RawMessage(`"done"`))
+		require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "result": "done"}`, poll("early"))
+		require.Zero(t, pending())
+	})
+	t.Run("timeout", func(t *testing.T) {
+		rsp := make(chan string)
+		go func() {
+			rsp <- poll("late")
+		}()
+		clock.BlockUntil(1)
+		clock.Advance(50 * time.Millisecond)
+		require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "result": null, "retry": true}`, <-rsp)
+		require.Zero(t, pending(), "the token of the poll is dropped")
+	})
+	t.Run("disconnected", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, ts.URL, strings.NewReader(`{ "jsonrpc": "2.0", "method": "poll", "params": {"_pollToken": "gone"}, "id": 1 }`))
+		require.NoError(t, err)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			if rsp, err := http.DefaultClient.Do(req); err == nil {
+				rsp.Body.Close()
+			}
+		}()
+		clock.BlockUntil(1)
+		cancel()
+		<-done
+		waitFor(t, func() bool { return pending() == 0 })
+	})
+	t.Run("results not polled expire", func(t *testing.T) {
+		handler.Complete("forgotten", json.RawMessage(`"done"`))
+		require.Equal(t, 1, pending())
+		clock.Advance(time.Minute)
+		handler.Complete("other", json.RawMessage(`"done"`))
+		require.Equal(t, 1, pending())
+		require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "result": "done"}`, poll("other"))
+		require.Zero(t, pending())
+	})
+	t.Run("polls are served by the server", func(t *testing.T) {
+		for body, expected := range map[string]string{
+			`{ "jsonrpc": "1.0", "method": "poll", "params": {"_pollToken": "t"}, "id": 1 }`:         `{"id": 1, "jsonrpc": "2.0", "error": {"code": -32600, "message": "Invalid request"}}`,
+			`{ "jsonrpc": "2.0", "method": "missing", "params": {"_pollToken": "t"}, "id": 1 }`:      `{"id": 1, "jsonrpc": "2.0", "error": {"code": -32601, "message": "Method not found"}}`,
+			`{ "jsonrpc": "2.0", "method": "poll", "params": {"_pollToken": "forbidden"}, "id": 1 }`: `{"id": 1, "jsonrpc": "2.0", "error": {"code": -32602, "message": "Invalid Params"}}`,
+		} {
+			_, rsp := post(body)
+			require.JSONEq(t, expected, rsp, body)
+		}
+		require.Zero(t, pending())
+	})
+	t.Run("requests without token", func(t *testing.T) {
+		code, rsp := post(`{ "jsonrpc": "2.0", "method": "echo", "params": {"a": 1}, "id": 1 }`)
+		require.Equal(t, http.StatusOK, code)
+		require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "result": {"a": 1}}`, rsp)
+		code, _ = post(`{ "jsonrpc": "2.0", "method": "echo" }`)
+		require.Equal(t, http.StatusNoContent, code)
+	})
+	t.Run("method not allowed", func(t *testing.T) {
+		rsp, err := http.Get(ts.URL)
+		require.NoError(t, err)
+		rsp.Body.Close()
+		require.Equal(t, http.StatusMethodNotAllowed, rsp.StatusCode)
+	})
+}
@@ -0,0 +1,294 @@
+package jsonrpc2
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ManifestMode tells which differences VerifyManifest reports.
+type ManifestMode int
+
+type (
+	// ServerManifest describes the surface of a server, as exported by Server.ExportManifest.
+	ServerManifest struct {
+		Methods []MethodManifest `json:"methods"`
+		// Config is the ServerConfig of the server, by field name, with the durations as strings, e.g. "2s"
+		Config map[string]interface{} `json:"config"`
+	}
+
+	// MethodManifest describes a method and its options, see MethodConfig.
+	MethodManifest struct {
+		Name          string          `json:"name"`
+		Doc           string          `json:"doc,omitempty"`
+		Timeout       string          `json:"timeout,omitempty"`
+		SlowThreshold string          `json:"slowThreshold,omitempty"`
+		Detached      bool            `json:"detached,omitempty"`
+		Hidden        bool            `json:"hidden,omitempty"`
+		Deprecated    bool            `json:"deprecated,omitempty"`
+		Since         string          `json:"since,omitempty"`
+		ParamsSchema  json.RawMessage `json:"paramsSchema,omitempty"`
+		ResultSchema  json.RawMessage `json:"resultSchema,omitempty"`
+		// Concurrency is the limit set by WithMethodConcurrency
+		Concurrency int `json:"concurrency,omitempty"`
+		// Validated, Initialized and Migrated tell whether the method has a validator, an initializer and params
+		// migrations, which cannot be compared
+		Validated   bool `json:"validated,omitempty"`
+		Initialized bool `json:"initialized,omitempty"`
+		Migrated    bool `json:"migrated,omitempty"`
+		// Source is the name of the source which defined the method, see Builder
+		Source string `json:"source,omitempty"`
+	}
+
+	// ManifestDiff is the difference between two manifests exported by Server.ExportManifest, see DiffManifests.
+	ManifestDiff struct {
+		// Added are the methods of b not in a, Removed the methods of a not in b
+		Added   []string `json:"added,omitempty"`
+		Removed []string `json:"removed,omitempty"`
+		// Changed are the methods of a and b with different options
+		Changed []MethodDiff `json:"changed,omitempty"`
+		// Config are the differences of the server configurations
+		Config []FieldDiff `json:"config,omitempty"`
+	}
+
+	// MethodDiff is the difference of the options of a method, see ManifestDiff.
+	MethodDiff struct {
+		Method string      `json:"method"`
+		Fields []FieldDiff `json:"fields"`
+	}
+
+	// FieldDiff is the difference of a field, A and B are its JSON values, nil if absent.
+	FieldDiff struct {
+		Field string          `json:"field"`
+		A     json.RawMessage `json:"a"`
+		B     json.RawMessage `json:"b"`
+	}
+)
+
+const (
+	// ManifestExact reports the missing and the unexpected methods
+	ManifestExact ManifestMode = iota
+	// ManifestSubset only reports the missing methods: the manifest is a subset of the methods
+	ManifestSubset
+	// ManifestSuperset only reports the unexpected methods: the methods are a subset of the manifest
+	ManifestSuperset
+)
+
+// VerifyManifest compares methods, e.g. Server.Methods, to the expected manifest, so typos in method names are caught
+// at startup. The error lists the methods of manifest which are missing and the unexpected methods:
+//	jsonrpc2: methods differ from manifest: missing [account.get_balance], unexpected [account.get_ballance]
+// The built-in "rpc." methods, e.g. rpc.initialize, are unexpected only if the manifest has "rpc." methods.
+func VerifyManifest(methods, manifest []string, mode ManifestMode) error {
+	defined := map[string]bool{}
+	for _, m := range methods {
+		defined[m] = true
+	}
+	expected := map[string]bool{}
+	builtins := false
+	for _, m := range manifest {
+		expected[m] = true
+		builtins = builtins || strings.HasPrefix(m, "rpc.")
+	}
+	var missing, unexpected []string
+	if mode != ManifestSuperset {
+		for m := range expected {
+			if !defined[m] {
+				missing = append(missing, m)
+			}
+		}
+	}
+	if mode != ManifestSubset {
+		for m := range defined {
+			if !expected[m] && (builtins || !strings.HasPrefix(m, "rpc.")) {
+				unexpected = append(unexpected, m)
+			}
+		}
+	}
+	if len(missing) == 0 && len(unexpected) == 0 {
+		return nil
+	}
+	sort.Strings(missing)
+	sort.Strings(unexpected)
+	var diffs []string
+	if len(missing) > 0 {
+		diffs = append(diffs, fmt.Sprintf("missing %v", missing))
+	}
+	if len(unexpected) > 0 {
+		diffs = append(diffs, fmt.Sprintf("unexpected %v", unexpected))
+	}
+	return fmt.Errorf("jsonrpc2: methods differ from manifest: %s", strings.Join(diffs, ", "))
+}
+
+// ManifestFromOpenRPC returns the names of the methods of an OpenRPC document, to verify them by VerifyManifest.
+func ManifestFromOpenRPC(doc []byte) ([]string, error) {
+	var d struct {
+		Methods []struct {
+			Name string `json:"name"`
+		} `json:"methods"`
+	}
+	if err := json.Unmarshal(doc, &d); err != nil {
+		return nil, fmt.Errorf("jsonrpc2: invalid OpenRPC document: %w", err)
+	}
+	manifest := make([]string, 0, len(d.Methods))
+	for _, m := range d.Methods {
+		if m.Name == "" {
+			return nil, errors.New("jsonrpc2: invalid OpenRPC document: method without name")
+		}
+		manifest = append(manifest, m.Name)
+	}
+	return manifest, nil
+}
+
+// DiffManifests returns the differences between the manifests a and b exported by Server.ExportManifest, e.g. to check
+// that staging and production expose the same surface:
+//	diff, err := jsonrpc2.DiffManifests(staging, production)
+//	if err != nil { ... }
+//	if !diff.Empty() { ... }
+func DiffManifests(a, b []byte) (ManifestDiff, error) {
+	var ma, mb struct {
+		Methods []map[string]json.RawMessage `json:"methods"`
+		Config  map[string]json.RawMessage   `json:"config"`
+	}
+	if err := json.Unmarshal(a, &ma); err != nil {
+		return ManifestDiff{}, fmt.Errorf("jsonrpc2: invalid manifest a: %w", err)
+	}
+	if err := json.Unmarshal(b, &mb); err != nil {
+		return ManifestDiff{}, fmt.Errorf("jsonrpc2: invalid manifest b: %w", err)
+	}
+	byName := func(methods []map[string]json.RawMessage) map[string]map[string]json.RawMessage {
+		m := make(map[string]map[string]json.RawMessage, len(methods))
+		for _, method := range methods {
+			var name string
+			json.Unmarshal(method["name"], &name)
+			m[name] = method
+		}
+		return m
+	}
+	methodsA, methodsB := byName(ma.Methods), byName(mb.Methods)
+	var diff ManifestDiff
+	for name, method := range methodsA {
+		other, ok := methodsB[name]
+		if !ok {
+			diff.Removed = append(diff.Removed, name)
+		} else if fields := diffFields(method, other); len(fields) > 0 {
+			diff.Changed = append(diff.Changed, MethodDiff{Method: name, Fields: fields})
+		}
+	}
+	for name := range methodsB {
+		if _, ok := methodsA[name]; !ok {
+			diff.Added = append(diff.Added, name)
+		}
+	}
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Slice(diff.Changed, func(i, j int) bool {
+		return diff.Changed[i].Method < diff.Changed[j].Method
+	})
+	diff.Config = diffFields(ma.Config, mb.Config)
+	return diff, nil
+}
+
+// Empty reports whether the manifests are the same.
+func (d ManifestDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0 && len(d.Config) == 0
+}
+
+// ExportManifest returns the ServerManifest of s as JSON. It is deterministic: the methods are sorted by name.
+func (s *server) ExportManifest() ([]byte, error) {
+	m := ServerManifest{Methods: []MethodManifest{}, Config: configManifest(s.Config())}
+	s.methodsMu.RLock()
+	for _, cfg := range s.methods {
+		m.Methods = append(m.Methods, MethodManifest{
+			Name:          cfg.Name,
+			Doc:           cfg.Doc,
+			Timeout:       durationManifest(cfg.Timeout),
+			SlowThreshold: durationManifest(cfg.SlowThreshold),
+			Detached:      cfg.Detached,
+			Hidden:        cfg.Hidden,
+			Deprecated:    cfg.Deprecated,
+			Since:         cfg.Since,
+			ParamsSchema:  cfg.ParamsSchema,
+			ResultSchema:  cfg.ResultSchema,
+			Concurrency:   cap(s.concurrency[cfg.Name]),
+			Validated:     cfg.Validator != nil,
+			Initialized:   cfg.Initializer != nil,
+			Migrated:      len(s.migrations[cfg.Name]) > 0,
+			Source:        cfg.source,
+		})
+	}
+	s.methodsMu.RUnlock()
+	sort.Slice(m.Methods, func(i, j int) bool {
+		return m.Methods[i].Name < m.Methods[j].Name
+	})
+	return json.Marshal(m)
+}
+
+func (s *server) Methods() []string {
+	s.methodsMu.RLock()
+	defer s.methodsMu.RUnlock()
+	methods := make([]string, 0, len(s.methods))
+	for method := range s.methods {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+	return methods
+}
+
+func (s *server) VerifyMethods(manifest []string) error {
+	return VerifyManifest(s.Methods(), manifest, ManifestExact)
+}
+
+// ============ Private members below =================
+
+// configManifest returns the fields of cfg by name, with the durations as strings
+func configManifest(cfg ServerConfig) map[string]interface{} {
+	m := map[string]interface{}{}
+	v := reflect.ValueOf(cfg)
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Field(i).Interface()
+		if d, ok := f.(time.Duration); ok {
+			f = d.String()
+		}
+		m[v.Type().Field(i).Name] = f
+	}
+	return m
+}
+
+func durationManifest(d time.Duration) string {
+	if d == 0 {
+		return ""
+	}
+	return d.String()
+}
+
+// diffFields returns the fields of a and b with different JSON values, sorted by name
+func diffFields(a, b map[string]json.RawMessage) []FieldDiff {
+	var diffs []FieldDiff
+	for field, va := range a {
+		if vb, ok := b[field]; !ok || !jsonEqual(va, vb) {
+			diffs = append(diffs, FieldDiff{Field: field, A: va, B: b[field]})
+		}
+	}
+	for field, vb := range b {
+		if _, ok := a[field]; !ok {
+			diffs = append(diffs, FieldDiff{Field: field, B: vb})
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool {
+		return diffs[i].Field < diffs[j].Field
+	})
+	return diffs
+}
+
+func jsonEqual(a, b json.RawMessage) bool {
+	var va, vb interface{}
+	if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+		return bytes.Equal(a, b)
+	}
+	return reflect.DeepEqual(va, vb)
+}
@@ -0,0 +1,118 @@
+package jsonrpc2
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestServer_VerifyMethods(t *testing.T) {
+	h := func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		return nil, nil
+	}
+	server := NewServer()
+	server.RegisterCapability(BatchCapability)
+	server.DefineMethod("account.get_ballance", h)
+	server.DefineMethod("account.transfer", h)
+	require.Equal(t, []string{"account.get_ballance", "account.transfer", "rpc.initialize"}, server.Methods())
+
+	require.NoError(t, server.VerifyMethods([]string{"account.transfer", "account.get_ballance"}))
+	require.EqualError(t, server.VerifyMethods([]string{"account.get_balance", "account.transfer", "rpc.initialize"}),
+		"jsonrpc2: methods differ from manifest: missing [account.get_balance], unexpected [account.get_ballance]")
+	require.EqualError(t, server.VerifyMethods([]string{"account.transfer", "rpc.initialize"}),
+		"jsonrpc2: methods differ from manifest: unexpected [account.get_ballance]")
+}
+
+func TestVerifyManifest(t *testing.T) {
+	methods := []string{"a", "b", "c"}
+	for _, c := range []struct {
+		mode     ManifestMode
+		manifest []string
+		err      string
+	}{
+		{ManifestExact, []string{"c", "b", "a"}, ""},
+		{ManifestExact, []string{"a", "b", "d"}, "jsonrpc2: methods differ from manifest: missing [d], unexpected [c]"},
+		{ManifestSubset, []string{"a", "b"}, ""},
+		{ManifestSubset, []string{"a", "d"}, "jsonrpc2: methods differ from manifest: missing [d]"},
+		{ManifestSuperset, []string{"a", "b", "c", "d"}, ""},
+		{ManifestSuperset, []string{"a", "d"}, "jsonrpc2: methods differ from manifest: unexpected [b c]"},
+	} {
+		err := VerifyManifest(methods, c.manifest, c.mode)
+		if c.err == "" {
+			require.NoError(t, err, c.manifest)
+		} else {
+			require.EqualError(t, err, c.err, c.manifest)
+		}
+	}
+}
+
+func TestManifestFromOpenRPC(t *testing.T) {
+	manifest, err := ManifestFromOpenRPC([]byte(`{
+		"openrpc": "1.2.6",
+		"info": {"title": "Accounts", "version": "1.0.0"},
+		"methods": [
+			{"name": "account.get_balance", "params": [], "result": {"name": "balance", "schema": {"type": "number"}}},
+			{"name": "account.transfer", "params": []}
+		]
+	}`))
+	require.NoError(t, err)
+	require.Equal(t, []string{"account.get_balance", "account.transfer"}, manifest)
+	_, err = ManifestFromOpenRPC([]byte(`{"methods": [{"params": []}]}`))
+	require.Error(t, err)
+	_, err = ManifestFromOpenRPC([]byte(`[`))
+	require.Error(t, err)
+}
+
+func TestDiffManifests(t *testing.T) {
+	h := func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		return nil, nil
+	}
+	staging := NewServer(WithMethodConcurrency("report.generate", 4))
+	DefineMethodConfig(staging, MethodConfig{Name: "report.generate", Handler: h, Timeout: time.Minute})
+	DefineMethodWithSchema(staging, "account.get", h, json.RawMessage(`{"type": "array"}`), nil)
+	staging.DefineMethod("debug.dump", h)
+	staging.SetDefaultTimeout(2 * time.Second)
+
+	production := NewServer(WithMethodConcurrency("report.generate", 8))
+	DefineMethodConfig(production, MethodConfig{Name: "report.generate", Handler: h, Timeout: time.Minute, Detached: true})
+	DefineMethodWithSchema(production, "account.get", h, json.RawMessage(`{"type":"array"}`), nil)
+	production.DefineMethod("account.close", h)
+	production.SetDefaultTimeout(5 * time.Second)
+	production.SetServerTiming(true)
+
+	a, err := staging.ExportManifest()
+	require.NoError(t, err)
+	again, err := staging.ExportManifest()
+	require.NoError(t, err)
+	require.Equal(t, a, again)
+	require.JSONEq(t, `{"name": "report.generate", "timeout": "1m0s", "concurrency": 4}`, func() string {
+		var m ServerManifest
+		require.NoError(t, json.Unmarshal(a, &m))
+		b, _ := json.Marshal(m.Methods[2])
+		return string(b)
+	}())
+	b, err := production.ExportManifest()
+	require.NoError(t, err)
+
+	diff, err := DiffManifests(a, b)
+	require.NoError(t, err)
+	require.False(t, diff.Empty())
+	require.Equal(t, []string{"account.close"}, diff.Added)
+	require.Equal(t, []string{"debug.dump"}, diff.Removed)
+	require.Equal(t, []MethodDiff{{Method: "report.generate", Fields: []FieldDiff{
+		{Field: "concurrency", A: json.RawMessage(`4`), B: json.RawMessage(`8`)},
+		{Field: "detached", B: json.RawMessage(`true`)},
+	}}}, diff.Changed)
+	require.Equal(t, []FieldDiff{
+		{Field: "DefaultTimeout", A: json.RawMessage(`"2s"`), B: json.RawMessage(`"5s"`)},
+		{Field: "ServerTiming", A: json.RawMessage(`false`), B: json.RawMessage(`true`)},
+	}, diff.Config)
+
+	diff, err = DiffManifests(a, a)
+	require.NoError(t, err)
+	require.True(t, diff.Empty())
+	_, err = DiffManifests(a, []byte(`{`))
+	require.Error(t, err)
+}
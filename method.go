@@ -0,0 +1,212 @@
+package jsonrpc2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+type (
+	// MethodConfig defines a method with all its options at once. Zero fields fall back to the server defaults.
+	// Usage:
+	//	jsonrpc2.DefineMethodConfig(server, jsonrpc2.MethodConfig{
+	//		Name:       "report.generate",
+	//		Handler:    generateReport,
+	//		Timeout:    30 * time.Second,
+	//		Middleware: []jsonrpc2.Middleware{audit},
+	//		Validator:  validateReportParams,
+	//		Doc:        "Generate the monthly report",
+	//	})
+	MethodConfig struct {
+		Name    string
+		Handler Handler
+		// Timeout overrides the server default timeout
+		Timeout time.Duration
+		// AdaptiveTimeout derives the timeout from the recent latencies of the method, falling back to Timeout
+		AdaptiveTimeout *AdaptiveTimeout
+		// SlowThreshold overrides ServerConfig.SlowThreshold
+		SlowThreshold time.Duration
+		// Middleware wraps the handler inside the server middlewares
+		Middleware []Middleware
+		// Validator checks the params before the handler is called
+		Validator Validator
+		Doc       string
+		// Deprecated and Since describe the method lifecycle, e.g. Since: "v1.2", see EnableIntrospection
+		Deprecated bool
+		Since      string
+		// ParamsSchema and ResultSchema are the JSON schemas of the params and result, see EnableIntrospection
+		ParamsSchema json.RawMessage
+		ResultSchema json.RawMessage
+		// Hidden excludes the method from rpc.listMethods and rpc.describe, e.g. the debug methods
+		Hidden bool
+		// LockOSThread runs the handler and its middlewares with runtime.LockOSThread, e.g. for cgo libraries requiring
+		// thread affinity. If the method is limited to 1 request at once by WithMethodConcurrency, its handlers run
+		// one after the other on a dedicated goroutine locked to its thread for the life of the server, so they always
+		// run on the same thread, and a detached or abandoned handler delays the next ones.
+		LockOSThread bool
+		// source is the name of the source of the method in a Builder
+		source string
+		// builtin marks the methods defined by the package, e.g. "rpc.initialize", whose names are not checked
+		builtin bool
+		// Initializer prepares the resources of the method once, on first call or by Server.WarmUp.
+		// The calls during initialization respond ErrWarmingUp, the calls after a failed initialization respond its error
+		// until Server.RetryInit.
+		Initializer func(ctx context.Context) error
+		// InitDuration is the expected duration of Initializer, used as the ETA of ErrWarmingUp
+		InitDuration time.Duration
+		// Detached runs the handler with a context without the deadline and cancellation of the request, but its values,
+		// so work like an export is never half-cancelled. On timeout ErrStillRunning is responded while the handler
+		// keeps running. Server.Wait waits for the detached handlers.
+		Detached bool
+	}
+
+	// A Validator checks the params of a request. If error returned is not jsonrpc2.Error, ErrInvalidParams is responded.
+	Validator func(params json.RawMessage) error
+)
+
+// DefineMethodConfig defines a method of s from cfg. It panics if Name or Handler is not set, or if s is not a
+// server of the package, e.g. NewServer or NewMethodRouter.
+func DefineMethodConfig(s Registrar, cfg MethodConfig) {
+	if cfg.Name == "" {
+		panic("jsonrpc2: MethodConfig.Name is required")
+	}
+	if cfg.Handler == nil {
+		panic("jsonrpc2: MethodConfig.Handler is required")
+	}
+	d, ok := s.(interface{ defineMethod(cfg MethodConfig) })
+	if !ok {
+		panic(fmt.Sprintf("jsonrpc2: DefineMethodConfig: %T is not a server of the package", s))
+	}
+	d.defineMethod(cfg)
+}
+
+// WithMethodValidator checks every method name at registration instead of DefaultMethodValidator, nil accepts any
+// non empty name. DefineMethod panics if validator returns an error, so invalid names are caught at startup.
+//	server := jsonrpc2.NewServer(jsonrpc2.WithMethodValidator(jsonrpc2.StrictMethodValidator()))
+func WithMethodValidator(validator func(method string) error) ServerOption {
+	return func(s *server) {
+		s.validateMethod = validator
+	}
+}
+
+// AllowReserved lets the methods starting with "rpc." be defined. The specification reserves them for extensions,
+// so by default DefineMethod panics rather than shadow a built-in method, e.g. rpc.initialize.
+func AllowReserved() ServerOption {
+	return func(s *server) {
+		s.allowReserved = true
+	}
+}
+
+// DefaultMethodValidator accepts non empty, valid UTF-8 method names without white spaces or control characters.
+func DefaultMethodValidator() func(method string) error {
+	relaxed := RelaxedMethodValidator()
+	return func(method string) error {
+		if err := relaxed(method); err != nil {
+			return err
+		}
+		for i, c := range method {
+			if unicode.IsSpace(c) {
+				return fmt.Errorf("white space %q at %d", c, i)
+			}
+		}
+		return nil
+	}
+}
+
+// StrictMethodValidator accepts non empty method names made of ASCII letters, digits, dots and underscores.
+func StrictMethodValidator() func(method string) error {
+	return func(method string) error {
+		if method == "" {
+			return errEmptyMethodName
+		}
+		for i, c := range method {
+			if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '.' || c == '_') {
+				return fmt.Errorf("invalid character %q at %d", c, i)
+			}
+		}
+		return nil
+	}
+}
+
+// RelaxedMethodValidator accepts non empty, valid UTF-8 method names without control characters.
+func RelaxedMethodValidator() func(method string) error {
+	return func(method string) error {
+		if method == "" {
+			return errEmptyMethodName
+		}
+		if !utf8.ValidString(method) {
+			return errors.New("invalid UTF-8")
+		}
+		for i, c := range method {
+			if unicode.IsControl(c) {
+				return fmt.Errorf("control character %q at %d", c, i)
+			}
+		}
+		return nil
+	}
+}
+
+// ============ Private members below =================
+
+// detach calls h with a context never cancelled
+func detach(h Handler) Handler {
+	return func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		return h(context.WithoutCancel(ctx), params)
+	}
+}
+
+var errEmptyMethodName = errors.New("empty method name")
+
+// reservedPrefix starts the method names reserved by the specification
+const reservedPrefix = "rpc."
+
+// checkMethod returns why cfg cannot be defined, if it cannot
+func (s *server) checkMethod(cfg MethodConfig) error {
+	if cfg.Name == "" {
+		return errEmptyMethodName
+	}
+	if cfg.Handler == nil {
+		return fmt.Errorf("nil handler for method %q", cfg.Name)
+	}
+	if s.validateMethod != nil && !cfg.builtin {
+		if err := s.validateMethod(cfg.Name); err != nil {
+			return fmt.Errorf("invalid method name %q: %v", cfg.Name, err)
+		}
+	}
+	if cfg.AdaptiveTimeout != nil {
+		if err := cfg.AdaptiveTimeout.check(); err != nil {
+			return fmt.Errorf("invalid adaptive timeout of method %q: %v", cfg.Name, err)
+		}
+	}
+	if strings.HasPrefix(cfg.Name, reservedPrefix) && !s.allowReserved && !cfg.builtin {
+		return fmt.Errorf("method %q is reserved, see AllowReserved", cfg.Name)
+	}
+	return nil
+}
+
+// handler returns the method handler wrapped with its validator, migrations and middlewares
+func (cfg MethodConfig) handler(migrations []ParamsMigration) Handler {
+	h := cfg.Handler
+	if cfg.Validator != nil {
+		next := h
+		h = func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+			if err := cfg.Validator(params); err != nil {
+				if _, ok := CodeOf(err); ok {
+					return nil, err
+				}
+				return nil, ErrInvalidParams
+			}
+			return next(ctx, params)
+		}
+	}
+	h = migrate(h, migrations)
+	for i := len(cfg.Middleware) - 1; i >= 0; i-- {
+		h = cfg.Middleware[i](h)
+	}
+	return h
+}
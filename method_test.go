@@ -0,0 +1,181 @@
+package jsonrpc2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestDefineMethodConfig(t *testing.T) {
+	wait := func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		return "ok", nil
+	}
+	t.Run("middleware runs inside server middleware", func(t *testing.T) {
+		var calls []string
+		trace := func(name string) Middleware {
+			return func(next Handler) Handler {
+				return func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+					calls = append(calls, name)
+					return next(ctx, params)
+				}
+			}
+		}
+		server := NewServer()
+		server.Use(trace("server"))
+		DefineMethodConfig(server, MethodConfig{
+			Name:       "wait",
+			Handler:    wait,
+			Middleware: []Middleware{trace("method1"), trace("method2")},
+		})
+		server.ServeRequest(json.RawMessage(`{ "jsonrpc": "2.0", "method": "wait", "params": 0, "id": 1 }`))
+		require.Equal(t, []string{"server", "method1", "method2"}, calls)
+	})
+	t.Run("validator", func(t *testing.T) {
+		server := NewServer()
+		DefineMethodConfig(server, MethodConfig{
+			Name:    "wait",
+			Handler: wait,
+			Validator: func(params json.RawMessage) error {
+				var n float64
+				if err := json.Unmarshal(params, &n); err != nil {
+					return errors.New("not a number")
+				}
+				if n > 10 {
+					return NewError(-32001, "Too long")
+				}
+				return nil
+			},
+		})
+		rsp := server.ServeRequest(json.RawMessage(`{ "jsonrpc": "2.0", "method": "wait", "params": "a", "id": 1 }`))
+		require.JSONEq(t, `{
+			"id": 1,
+			"jsonrpc": "2.0",
+			"error": { "code": -32602, "message":"Invalid Params" }
+		}`, string(rsp))
+		rsp = server.ServeRequest(json.RawMessage(`{ "jsonrpc": "2.0", "method": "wait", "params": 20, "id": 1 }`))
+		require.JSONEq(t, `{
+			"id": 1,
+			"jsonrpc": "2.0",
+			"error": { "code": -32001, "message":"Too long" }
+		}`, string(rsp))
+		rsp = server.ServeRequest(json.RawMessage(`{ "jsonrpc": "2.0", "method": "wait", "params": 1, "id": 1 }`))
+		require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "result": "ok"}`, string(rsp))
+	})
+	t.Run("name and handler are required", func(t *testing.T) {
+		server := NewServer()
+		require.Panics(t, func() {
+			DefineMethodConfig(server, MethodConfig{Handler: wait})
+		})
+		require.Panics(t, func() {
+			DefineMethodConfig(server, MethodConfig{Name: "wait"})
+		})
+	})
+}
+
+func TestWithMethodValidator(t *testing.T) {
+	h := func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		return nil, nil
+	}
+	t.Run("strict", func(t *testing.T) {
+		server := NewServer(WithMethodValidator(StrictMethodValidator()))
+		require.NotPanics(t, func() { server.DefineMethod("math.add_int2", h) })
+		for _, method := range []string{"", "math add", "math\x00add", "math\u0085add", "mäth"} {
+			require.Panics(t, func() { server.DefineMethod(method, h) }, method)
+		}
+	})
+	t.Run("relaxed", func(t *testing.T) {
+		server := NewServer(WithMethodValidator(RelaxedMethodValidator()))
+		require.NotPanics(t, func() { server.DefineMethod("math add", h) })
+		require.NotPanics(t, func() { server.DefineMethod("mäth", h) })
+		for _, method := range []string{"", "math\x00add", "math\u0085add", "math\x7fadd", "math\xffadd"} {
+			require.Panics(t, func() { server.DefineMethod(method, h) }, method)
+		}
+	})
+	t.Run("applies to DefineMethodConfig", func(t *testing.T) {
+		server := NewServer(WithMethodValidator(StrictMethodValidator()))
+		require.Panics(t, func() { DefineMethodConfig(server, MethodConfig{Name: "math add", Handler: h}) })
+	})
+	t.Run("custom", func(t *testing.T) {
+		server := NewServer(WithMethodValidator(func(method string) error {
+			return errors.New("no")
+		}))
+		require.PanicsWithValue(t, `jsonrpc2: invalid method name "echo": no`, func() { server.DefineMethod("echo", h) })
+	})
+}
+
+func TestDefineMethod_Checks(t *testing.T) {
+	h := func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		return nil, nil
+	}
+	t.Run("invalid", func(t *testing.T) {
+		server := NewServer()
+		for method, expected := range map[string]string{
+			"":              `jsonrpc2: empty method name`,
+			"math add":      `jsonrpc2: invalid method name "math add": white space ' ' at 4`,
+			"math\tadd":     `jsonrpc2: invalid method name "math\tadd": control character '\t' at 4`,
+			"math\u00a0add": `jsonrpc2: invalid method name "math\u00a0add": white space '\u00a0' at 4`,
+			"math\xffadd":   `jsonrpc2: invalid method name "math\xffadd": invalid UTF-8`,
+			"rpc.discover":  `jsonrpc2: method "rpc.discover" is reserved, see AllowReserved`,
+		} {
+			require.PanicsWithValue(t, expected, func() { server.DefineMethod(method, h) }, method)
+		}
+		require.PanicsWithValue(t, `jsonrpc2: nil handler for method "echo"`, func() { server.DefineMethod("echo", nil) })
+		require.Panics(t, func() { server.Namespace("rpc").DefineMethod("discover", h) })
+		require.Empty(t, server.Methods())
+	})
+	t.Run("allow reserved", func(t *testing.T) {
+		server := NewServer(AllowReserved())
+		require.NotPanics(t, func() { server.DefineMethod("rpc.discover", h) })
+		require.Equal(t, []string{"rpc.discover"}, server.Methods())
+	})
+	t.Run("built-in methods", func(t *testing.T) {
+		server := NewServer(EnableIntrospection())
+		require.Equal(t, []string{"rpc.describe", "rpc.listMethods", "rpc.listNotifications"}, server.Methods())
+	})
+}
+
+func TestMethodConfig_Detached(t *testing.T) {
+	clock := MockClock()
+	release := make(chan struct{})
+	finished := make(chan error, 1)
+	server := NewServer(WithClock(clock))
+	server.SetDefaultTimeout(time.Second)
+	DefineMethodConfig(server, MethodConfig{
+		Name: "startExport",
+		Handler: func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+			<-release
+			if MethodCallInfo(ctx) == nil {
+				finished <- errors.New("missing context values")
+			} else {
+				finished <- ctx.Err()
+			}
+			return "done", nil
+		},
+		Detached: true,
+	})
+	rsp := make(chan json.RawMessage)
+	go func() {
+		rsp <- server.ServeRequest(json.RawMessage(`{ "jsonrpc": "2.0", "method": "startExport", "id": 1 }`))
+	}()
+	clock.BlockUntil(1)
+	clock.Advance(time.Second)
+	require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "error": {"code": -32002, "message": "Still running"}}`, string(<-rsp))
+
+	server.Close()
+	waited := make(chan struct{})
+	go func() {
+		server.Wait()
+		close(waited)
+	}()
+	select {
+	case <-waited:
+		t.Fatal("Wait returned before the detached handler")
+	case <-time.After(10 * time.Millisecond):
+	}
+	close(release)
+	<-waited
+	require.NoError(t, <-finished)
+}
@@ -0,0 +1,100 @@
+package jsonrpc2
+
+import (
+	"reflect"
+	"sort"
+)
+
+type (
+	// A MethodSet is a group of methods registered together by Server.RegisterMethodSet.
+	MethodSet interface {
+		Methods() map[string]Handler
+	}
+
+	// A Namespace defines methods with the "<name>." prefix.
+	// Usage:
+	//	server.Namespace("math").DefineMethod("add", add)
+	//	// defines "math.add"
+	Namespace interface {
+		DefineMethod(method string, h Handler)
+		RegisterMethodSet(ms MethodSet)
+		Namespace(name string) Namespace
+	}
+)
+
+// NewMethodSet returns a MethodSet of handlers keyed by method name.
+func NewMethodSet(handlers map[string]Handler) MethodSet {
+	return methodSet(handlers)
+}
+
+// NewMethodSetFromStruct returns a MethodSet of the exported methods of svc having the Handler signature.
+// The methods are named after the Go method names.
+//	type Math struct{}
+//	func (Math) Add(ctx context.Context, params json.RawMessage) (interface{}, error) { ... }
+//
+//	server.Namespace("math").RegisterMethodSet(jsonrpc2.NewMethodSetFromStruct(Math{}))
+//	// defines "math.Add"
+func NewMethodSetFromStruct(svc interface{}) MethodSet {
+	handlers := map[string]Handler{}
+	v := reflect.ValueOf(svc)
+	handlerType := reflect.TypeOf(Handler(nil))
+	for i := 0; i < v.NumMethod(); i++ {
+		m := v.Method(i)
+		if !m.Type().ConvertibleTo(handlerType) {
+			continue
+		}
+		handlers[v.Type().Method(i).Name] = m.Convert(handlerType).Interface().(Handler)
+	}
+	return methodSet(handlers)
+}
+
+func (s *server) RegisterMethodSet(ms MethodSet) {
+	registerMethodSet(s, "", ms)
+}
+
+func (s *server) Namespace(name string) Namespace {
+	return &namespace{s: s, prefix: name + "."}
+}
+
+// ============ Private members below =================
+
+type (
+	methodSet map[string]Handler
+
+	namespace struct {
+		s      *server
+		prefix string
+	}
+)
+
+func (ms methodSet) Methods() map[string]Handler {
+	return ms
+}
+
+func (ns *namespace) DefineMethod(method string, h Handler) {
+	ns.s.DefineMethod(ns.prefix+method, h)
+}
+
+func (ns *namespace) RegisterMethodSet(ms MethodSet) {
+	registerMethodSet(ns.s, ns.prefix, ms)
+}
+
+func (ns *namespace) Namespace(name string) Namespace {
+	return &namespace{s: ns.s, prefix: ns.prefix + name + "."}
+}
+
+// registerMethodSet defines the methods of ms with prefix at once. They are validated in name order so method name
+// validation fails deterministically.
+func registerMethodSet(s *server, prefix string, ms MethodSet) {
+	handlers := ms.Methods()
+	names := make([]string, 0, len(handlers))
+	for name := range handlers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	methods := make([]MethodConfig, 0, len(names))
+	for _, name := range names {
+		methods = append(methods, MethodConfig{Name: prefix + name, Handler: handlers[name]})
+	}
+	s.defineMethods(methods)
+}
@@ -0,0 +1,59 @@
+package jsonrpc2
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+type testMathService struct{}
+
+func (testMathService) Add(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var p [2]float64
+	json.Unmarshal(params, &p)
+	return p[0] + p[1], nil
+}
+
+func (testMathService) NotAHandler(a int) int {
+	return a
+}
+
+func (testMathService) unexported(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	return nil, nil
+}
+
+func TestServer_RegisterMethodSet(t *testing.T) {
+	echo := func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		return params, nil
+	}
+	t.Run("method set", func(t *testing.T) {
+		server := NewServer()
+		server.RegisterMethodSet(NewMethodSet(map[string]Handler{"echo": echo}))
+		rsp := server.ServeRequest(json.RawMessage(`{ "jsonrpc": "2.0", "method": "echo", "params": "hi", "id": 1 }`))
+		require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "result": "hi"}`, string(rsp))
+	})
+	t.Run("method set from struct", func(t *testing.T) {
+		ms := NewMethodSetFromStruct(testMathService{})
+		require.Len(t, ms.Methods(), 1)
+		server := NewServer()
+		server.RegisterMethodSet(ms)
+		rsp := server.ServeRequest(json.RawMessage(`{ "jsonrpc": "2.0", "method": "Add", "params": [1, 2], "id": 1 }`))
+		require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "result": 3}`, string(rsp))
+	})
+	t.Run("namespace", func(t *testing.T) {
+		server := NewServer()
+		server.Namespace("math").RegisterMethodSet(NewMethodSetFromStruct(&testMathService{}))
+		server.Namespace("a").Namespace("b").DefineMethod("echo", echo)
+		rsp := server.ServeRequest(json.RawMessage(`[
+			{ "jsonrpc": "2.0", "method": "math.Add", "params": [1, 2], "id": 1 },
+			{ "jsonrpc": "2.0", "method": "a.b.echo", "params": "hi", "id": 2 },
+			{ "jsonrpc": "2.0", "method": "Add", "params": [1, 2], "id": 3 }
+		]`))
+		require.JSONEq(t, `[
+			{"id": 1, "jsonrpc": "2.0", "result": 3},
+			{"id": 2, "jsonrpc": "2.0", "result": "hi"},
+			{"id": 3, "jsonrpc": "2.0", "error": {"code": -32601, "message": "Method not found"}}
+		]`, string(rsp))
+	})
+}
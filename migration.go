@@ -0,0 +1,107 @@
+package jsonrpc2
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sort"
+)
+
+// A ParamsMigration rewrites the params of old clients to the shape expected by the handler, e.g. renamed fields.
+// Its errors are responded as ErrInvalidParams with the error as data: {"migration": "..."}, unless they are Error.
+type ParamsMigration func(ctx context.Context, params json.RawMessage) (json.RawMessage, error)
+
+// WithParamsMigration migrates the params of method before its validator and handler, after the middlewares.
+// The migrations of a method run in the order they are given, so a migration from v1 to v2 is followed by v2 to v3:
+//	jsonrpc2.WithParamsMigration("account.get", jsonrpc2.RenameParams(map[string]string{"acct_id": "accountId"}))
+func WithParamsMigration(method string, migrations ...ParamsMigration) ServerOption {
+	return func(s *server) {
+		s.migrations[method] = append(s.migrations[method], migrations...)
+	}
+}
+
+// RenameParams returns a ParamsMigration renaming the members of the objects of the params, at any depth, from the
+// keys of fields to their values. A member already having the new name is kept over the old one, so migrated params
+// pass through unchanged.
+func RenameParams(fields map[string]string) ParamsMigration {
+	return func(ctx context.Context, params json.RawMessage) (json.RawMessage, error) {
+		if len(params) == 0 {
+			return params, nil
+		}
+		d := json.NewDecoder(bytes.NewReader(params))
+		d.UseNumber()
+		var v interface{}
+		if err := d.Decode(&v); err != nil {
+			return nil, err
+		}
+		v, renamed := renameParams(v, fields)
+		if !renamed {
+			return params, nil
+		}
+		return json.Marshal(v)
+	}
+}
+
+// ============ Private members below =================
+
+// migrate wraps h with migrations
+func migrate(h Handler, migrations []ParamsMigration) Handler {
+	if len(migrations) == 0 {
+		return h
+	}
+	return func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+		for _, m := range migrations {
+			var err error
+			if params, err = m(ctx, params); err != nil {
+				if _, ok := CodeOf(err); ok {
+					return nil, err
+				}
+				return nil, NewErrorWithData(CodeInvalidParams, ErrInvalidParams.Error(), map[string]string{
+					"migration": err.Error(),
+				})
+			}
+		}
+		return h(ctx, params)
+	}
+}
+
+// renameParams returns v with the members of its objects renamed, and whether a member was renamed. The renamed
+// members are collected into new objects, so a member is renamed once even if the renames are chained, e.g. a to b
+// and b to c.
+func renameParams(v interface{}, fields map[string]string) (interface{}, bool) {
+	renamed := false
+	switch v := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		var moved []string
+		for k, e := range v {
+			var r bool
+			if e, r = renameParams(e, fields); r {
+				renamed = true
+			}
+			v[k] = e
+			if to, ok := fields[k]; ok && to != k {
+				moved = append(moved, k)
+				continue
+			}
+			out[k] = e
+		}
+		// the members not renamed are kept over the renamed ones, the renamed ones clash in the order of their names
+		sort.Strings(moved)
+		for _, k := range moved {
+			if _, exists := out[fields[k]]; !exists {
+				out[fields[k]] = v[k]
+			}
+			renamed = true
+		}
+		return out, renamed
+	case []interface{}:
+		for i, e := range v {
+			var r bool
+			if v[i], r = renameParams(e, fields); r {
+				renamed = true
+			}
+		}
+	}
+	return v, renamed
+}
@@ -0,0 +1,86 @@
+package jsonrpc2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestWithParamsMigration(t *testing.T) {
+	echo := func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		return params, nil
+	}
+	call := func(server Server, params string) string {
+		return string(server.ServeRequest(json.RawMessage(`{"jsonrpc": "2.0", "method": "account.get", "params": ` + params + `, "id": 1}`)))
+	}
+	t.Run("rename fields", func(t *testing.T) {
+		server := NewServer(WithParamsMigration("account.get", RenameParams(map[string]string{
+			"acct_id":  "accountId",
+			"zip_code": "zipCode",
+		})))
+		server.DefineMethod("account.get", echo)
+		server.DefineMethod("other", echo)
+		for params, expected := range map[string]string{
+			// nested
+			`{"acct_id": 1, "owner": {"zip_code": "75001"}}`: `{"accountId": 1, "owner": {"zipCode": "75001"}}`,
+			// arrays of objects
+			`[{"acct_id": 1}, {"acct_id": 2, "big": 12345678901234567890}]`: `[{"accountId": 1}, {"accountId": 2, "big": 12345678901234567890}]`,
+			// already migrated
+			`{"accountId": 1, "owner": {"zipCode": "75001"}}`: `{"accountId": 1, "owner": {"zipCode": "75001"}}`,
+			`{"accountId": 1, "acct_id": 2}`:                  `{"accountId": 1}`,
+			`"acct_id"`:                                       `"acct_id"`,
+		} {
+			require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "result": `+expected+`}`, call(server, params), params)
+		}
+		rsp := server.ServeRequest(json.RawMessage(`{"jsonrpc": "2.0", "method": "other", "params": {"acct_id": 1}, "id": 1}`))
+		require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "result": {"acct_id": 1}}`, string(rsp))
+	})
+	t.Run("chained renames", func(t *testing.T) {
+		server := NewServer(WithParamsMigration("account.get", RenameParams(map[string]string{"a": "b", "b": "c", "x": "y", "y": "x"})))
+		server.DefineMethod("account.get", echo)
+		for params, expected := range map[string]string{
+			`{"a": 1, "b": 2}`:         `{"b": 1, "c": 2}`,
+			`{"a": 1}`:                 `{"b": 1}`,
+			`{"x": 1, "y": 2}`:         `{"y": 1, "x": 2}`,
+			`{"a": 1, "c": 3}`:         `{"b": 1, "c": 3}`,
+			`{"b": 2, "c": 3}`:         `{"c": 3}`,
+			`{"o": [{"a": {"a": 1}}]}`: `{"o": [{"b": {"b": 1}}]}`,
+		} {
+			for i := 0; i < 10; i++ {
+				require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "result": `+expected+`}`, call(server, params), params)
+			}
+		}
+	})
+	t.Run("composition", func(t *testing.T) {
+		var validated json.RawMessage
+		server := NewServer(
+			WithParamsMigration("account.get", RenameParams(map[string]string{"acct": "acct_id"})),
+			WithParamsMigration("account.get", RenameParams(map[string]string{"acct_id": "accountId"})),
+		)
+		DefineMethodConfig(server, MethodConfig{
+			Name:    "account.get",
+			Handler: echo,
+			Validator: func(params json.RawMessage) error {
+				validated = params
+				return nil
+			},
+		})
+		require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "result": {"accountId": 1}}`, call(server, `{"acct": 1}`))
+		require.JSONEq(t, `{"accountId": 1}`, string(validated))
+	})
+	t.Run("failure", func(t *testing.T) {
+		server := NewServer(
+			WithParamsMigration("account.get", func(ctx context.Context, params json.RawMessage) (json.RawMessage, error) {
+				return nil, errors.New("acct_id is not a number")
+			}),
+		)
+		server.DefineMethod("account.get", echo)
+		require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "error": {
+			"code": -32602,
+			"message": "Invalid Params",
+			"data": {"migration": "acct_id is not a number"}
+		}}`, call(server, `{"acct_id": "x"}`))
+	})
+}
@@ -0,0 +1,22 @@
+module github/brianso/go-jsonrpc2/nats
+
+go 1.22
+
+require (
+	github.com/nats-io/nats.go v1.31.0
+	github.com/stretchr/testify v1.8.4
+	github/brianso/go-jsonrpc2 v0.0.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/klauspost/compress v1.18.0 // indirect
+	github.com/nats-io/nkeys v0.4.6 // indirect
+	github.com/nats-io/nuid v1.0.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/crypto v0.17.0 // indirect
+	golang.org/x/sys v0.17.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github/brianso/go-jsonrpc2 => ../
@@ -0,0 +1,55 @@
+// nats serves jsonrpc2 servers over NATS messaging, it is imported as jsonrpc2nats next to the NATS client in the
+// examples
+package nats
+
+import (
+	"context"
+	natsio "github.com/nats-io/nats.go"
+	"github/brianso/go-jsonrpc2"
+	"sync"
+)
+
+// ListenNATS serves the messages published to subject as requests, and publishes their responses to the reply subject
+// of the messages. A message may be a batch request, the notifications get no reply. The requests are served
+// concurrently, as they are received. It returns ctx.Err() once ctx is done and the requests being served are
+// replied, or the error of the subscription.
+//	nc, err := nats.Connect(nats.DefaultURL)
+//	...
+//	go jsonrpc2nats.ListenNATS(ctx, nc, "rpc.accounts", server)
+//
+//	msg, err := nc.Request("rpc.accounts", []byte(`{"jsonrpc": "2.0", "method": "get", "params": [42], "id": 1}`), time.Second)
+func ListenNATS(ctx context.Context, nc *natsio.Conn, subject string, server jsonrpc2.Requester) error {
+	var mu sync.Mutex
+	stopped := false
+	var serving sync.WaitGroup
+	sub, err := nc.Subscribe(subject, func(msg *natsio.Msg) {
+		mu.Lock()
+		defer mu.Unlock()
+		if stopped {
+			return
+		}
+		serving.Add(1)
+		go func() {
+			defer serving.Done()
+			rsp := server.ServeRequest(msg.Data)
+			if rsp == nil || msg.Reply == "" {
+				return
+			}
+			// a failed reply is left to the timeout of the requester
+			_ = msg.Respond(rsp)
+		}()
+	})
+	if err != nil {
+		return err
+	}
+	<-ctx.Done()
+	err = sub.Unsubscribe()
+	mu.Lock()
+	stopped = true
+	mu.Unlock()
+	serving.Wait()
+	if err != nil && err != natsio.ErrConnectionClosed {
+		return err
+	}
+	return ctx.Err()
+}
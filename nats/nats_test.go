@@ -0,0 +1,192 @@
+package nats
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	natsio "github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/require"
+	"github/brianso/go-jsonrpc2"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestListenNATS(t *testing.T) {
+	url := startFakeServer(t)
+	server := jsonrpc2.NewServer()
+	notified := make(chan json.RawMessage, 1)
+	server.DefineMethod("echo", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		return params, nil
+	})
+	server.DefineMethod("notify", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		notified <- params
+		return nil, nil
+	})
+	nc, err := natsio.Connect(url)
+	require.NoError(t, err)
+	defer nc.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- ListenNATS(ctx, nc, "rpc.test", server)
+	}()
+
+	client, err := natsio.Connect(url)
+	require.NoError(t, err)
+	defer client.Close()
+	request := func(req string) string {
+		// the subscription of ListenNATS may not be registered yet
+		for i := 0; ; i++ {
+			msg, err := client.Request("rpc.test", []byte(req), 100*time.Millisecond)
+			if err == nil {
+				return string(msg.Data)
+			}
+			require.Less(t, i, 50, err)
+		}
+	}
+
+	t.Run("request", func(t *testing.T) {
+		rsp := request(`{"jsonrpc": "2.0", "method": "echo", "params": "hi", "id": 1}`)
+		require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "result": "hi"}`, rsp)
+	})
+	t.Run("batch", func(t *testing.T) {
+		rsp := request(`[
+			{"jsonrpc": "2.0", "method": "echo", "params": 1, "id": 1},
+			{"jsonrpc": "2.0", "method": "echo", "params": 2},
+			{"jsonrpc": "2.0", "method": "missing", "id": 2}
+		]`)
+		require.JSONEq(t, `[
+			{"id": 1, "jsonrpc": "2.0", "result": 1},
+			{"id": 2, "jsonrpc": "2.0", "error": {"code": -32601, "message": "Method not found"}}
+		]`, rsp)
+	})
+	t.Run("notification", func(t *testing.T) {
+		_, err := client.Request("rpc.test", []byte(`{"jsonrpc": "2.0", "method": "notify", "params": "n"}`), 100*time.Millisecond)
+		require.ErrorIs(t, err, natsio.ErrTimeout, "no reply")
+		require.JSONEq(t, `"n"`, string(<-notified))
+		require.NoError(t, client.Publish("rpc.test", []byte(`{"jsonrpc": "2.0", "method": "notify", "params": "p"}`)))
+		require.JSONEq(t, `"p"`, string(<-notified))
+	})
+	t.Run("context done", func(t *testing.T) {
+		cancel()
+		require.ErrorIs(t, <-done, context.Canceled)
+	})
+}
+
+// startFakeServer starts a NATS server supporting the subset of the protocol used by the tests, and returns its url
+func startFakeServer(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+	s := &fakeServer{subs: map[*fakeConn]map[string]string{}}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(&fakeConn{conn: conn})
+		}
+	}()
+	return "nats://" + l.Addr().String()
+}
+
+type (
+	fakeServer struct {
+		mu sync.Mutex
+		// subs are the subjects of the subscriptions of each connection by sid
+		subs map[*fakeConn]map[string]string
+	}
+
+	fakeConn struct {
+		mu   sync.Mutex
+		conn net.Conn
+	}
+)
+
+func (c *fakeConn) write(format string, args ...interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fmt.Fprintf(c.conn, format, args...)
+}
+
+func (s *fakeServer) serve(c *fakeConn) {
+	defer c.conn.Close()
+	s.mu.Lock()
+	s.subs[c] = map[string]string{}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.subs, c)
+		s.mu.Unlock()
+	}()
+	c.write("INFO {\"server_id\":\"fake\",\"version\":\"2.10.0\",\"proto\":1,\"max_payload\":1048576}\r\n")
+	r := bufio.NewReader(c.conn)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		args := strings.Fields(line)
+		if len(args) == 0 {
+			continue
+		}
+		switch strings.ToUpper(args[0]) {
+		case "PING":
+			c.write("PONG\r\n")
+		case "SUB":
+			// SUB <subject> [queue group] <sid>
+			s.mu.Lock()
+			s.subs[c][args[len(args)-1]] = args[1]
+			s.mu.Unlock()
+		case "UNSUB":
+			s.mu.Lock()
+			delete(s.subs[c], args[1])
+			s.mu.Unlock()
+		case "PUB":
+			// PUB <subject> [reply-to] <#bytes>
+			size, _ := strconv.Atoi(args[len(args)-1])
+			payload := make([]byte, size+2)
+			if _, err := io.ReadFull(r, payload); err != nil {
+				return
+			}
+			reply := ""
+			if len(args) == 4 {
+				reply = args[2] + " "
+			}
+			s.publish(args[1], reply, payload[:size])
+		}
+	}
+}
+
+func (s *fakeServer) publish(subject, reply string, payload []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for c, subs := range s.subs {
+		for sid, pattern := range subs {
+			if subjectMatches(pattern, subject) {
+				c.write("MSG %s %s %s%d\r\n%s\r\n", subject, sid, reply, len(payload), payload)
+			}
+		}
+	}
+}
+
+// subjectMatches reports whether subject matches pattern, with the "*" and ">" wildcards
+func subjectMatches(pattern, subject string) bool {
+	p, s := strings.Split(pattern, "."), strings.Split(subject, ".")
+	for i, token := range p {
+		if token == ">" {
+			return len(s) > i
+		}
+		if i >= len(s) || token != "*" && token != s[i] {
+			return false
+		}
+	}
+	return len(p) == len(s)
+}
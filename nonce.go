@@ -0,0 +1,119 @@
+package jsonrpc2
+
+import (
+	"container/heap"
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// NonceStore remembers the nonces seen by NonceMiddleware.
+// Implementations must be safe for concurrent use.
+type NonceStore interface {
+	// Add records nonce for ttl. It returns false if the nonce is already recorded and not yet expired.
+	Add(ctx context.Context, nonce string, ttl time.Duration) (added bool, err error)
+}
+
+// NonceMiddleware rejects requests whose "nonce" member has been seen within window.
+//	{ "jsonrpc": "2.0", "method": "transfer", "params": [...], "nonce": "f81d4fae", "id": 1 }
+// A replayed request responds with ErrReplayDetected, a request without nonce responds with ErrInvalidRequest.
+// Each element of a batch request carries its own nonce.
+func NonceMiddleware(store NonceStore, window time.Duration) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+			nonce := requestNonce(ctx)
+			if nonce == "" {
+				return nil, ErrInvalidRequest
+			}
+			added, err := store.Add(ctx, nonce, window)
+			if err != nil {
+				return nil, err
+			}
+			if !added {
+				return nil, ErrReplayDetected
+			}
+			return next(ctx, params)
+		}
+	}
+}
+
+// NewMemoryNonceStore returns an in-memory NonceStore holding at most capacity nonces.
+// When full of nonces not expired, Add returns ErrServiceBusy rather than forget a nonce which could then be replayed,
+// so capacity should cover the expected number of requests within the window. Expiries are measured by the server
+// clock, see Server.SetClock.
+func NewMemoryNonceStore(capacity int) NonceStore {
+	if capacity <= 0 {
+		capacity = 1
+	}
+	return &memoryNonceStore{
+		capacity: capacity,
+		expiries: make(map[string]time.Time, capacity),
+	}
+}
+
+// ============ Private members below =================
+
+type (
+	memoryNonceStore struct {
+		mu       sync.Mutex
+		capacity int
+		expiries map[string]time.Time
+		// queue orders the nonces by expiry, the ttl may differ between calls
+		queue nonceQueue
+	}
+
+	nonceEntry struct {
+		nonce  string
+		expiry time.Time
+	}
+
+	// nonceQueue is a min-heap of nonces by expiry, see container/heap
+	nonceQueue []nonceEntry
+)
+
+func (s *memoryNonceStore) Add(ctx context.Context, nonce string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := ClockFromContext(ctx).Now()
+	for len(s.queue) > 0 && !s.queue[0].expiry.After(now) {
+		e := heap.Pop(&s.queue).(nonceEntry)
+		delete(s.expiries, e.nonce)
+	}
+	if _, ok := s.expiries[nonce]; ok {
+		return false, nil
+	}
+	if len(s.queue) >= s.capacity {
+		return false, ErrServiceBusy
+	}
+	expiry := now.Add(ttl)
+	s.expiries[nonce] = expiry
+	heap.Push(&s.queue, nonceEntry{nonce: nonce, expiry: expiry})
+	return true, nil
+}
+
+func (q nonceQueue) Len() int           { return len(q) }
+func (q nonceQueue) Less(i, j int) bool { return q[i].expiry.Before(q[j].expiry) }
+func (q nonceQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+
+func (q *nonceQueue) Push(x any) {
+	*q = append(*q, x.(nonceEntry))
+}
+
+func (q *nonceQueue) Pop() any {
+	old := *q
+	e := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return e
+}
+
+func requestNonce(ctx context.Context) string {
+	raw := RawRequestFromContext(ctx)
+	var r struct {
+		Nonce string `json:"nonce"`
+	}
+	if err := json.Unmarshal(raw, &r); err != nil {
+		return ""
+	}
+	return r.Nonce
+}
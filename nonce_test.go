@@ -0,0 +1,111 @@
+package jsonrpc2
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNonceMiddleware(t *testing.T) {
+	server := NewServer()
+	server.Use(NonceMiddleware(NewMemoryNonceStore(100), time.Minute))
+	server.DefineMethod("echo", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		return params, nil
+	})
+	t.Run("same nonce twice is rejected", func(t *testing.T) {
+		rsp := server.ServeRequest(json.RawMessage(`{ "jsonrpc": "2.0", "method": "echo", "params": "hi", "nonce": "a", "id": 1 }`))
+		require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "result": "hi"}`, string(rsp))
+		rsp = server.ServeRequest(json.RawMessage(`{ "jsonrpc": "2.0", "method": "echo", "params": "hi", "nonce": "a", "id": 2 }`))
+		require.JSONEq(t, `{
+			"id": 2,
+			"jsonrpc": "2.0",
+			"error": {"code": -32016, "message": "Replay detected"}
+		}`, string(rsp))
+	})
+	t.Run("different nonces pass", func(t *testing.T) {
+		rsp := server.ServeRequest(json.RawMessage(`[
+			{ "jsonrpc": "2.0", "method": "echo", "params": "hi", "nonce": "b", "id": 1 },
+			{ "jsonrpc": "2.0", "method": "echo", "params": "hi", "nonce": "c", "id": 2 },
+			{ "jsonrpc": "2.0", "method": "echo", "params": "hi", "nonce": "a", "id": 3 }
+		]`))
+		require.JSONEq(t, `[
+			{"id": 1, "jsonrpc": "2.0", "result": "hi"},
+			{"id": 2, "jsonrpc": "2.0", "result": "hi"},
+			{"id": 3, "jsonrpc": "2.0", "error": {"code": -32016, "message": "Replay detected"}}
+		]`, string(rsp))
+	})
+	t.Run("missing nonce is invalid", func(t *testing.T) {
+		rsp := server.ServeRequest(json.RawMessage(`{ "jsonrpc": "2.0", "method": "echo", "params": "hi", "id": 1 }`))
+		require.JSONEq(t, `{
+			"id": 1,
+			"jsonrpc": "2.0",
+			"error": {"code": -32600, "message": "Invalid request"}
+		}`, string(rsp))
+	})
+}
+
+func TestMemoryNonceStore(t *testing.T) {
+	ctx := context.Background()
+	t.Run("is bounded", func(t *testing.T) {
+		clock := MockClock()
+		ctx := context.WithValue(ctx, clockContextKey{}, Clock(clock))
+		store := NewMemoryNonceStore(2)
+		for _, n := range []string{"a", "b"} {
+			added, err := store.Add(ctx, n, time.Minute)
+			require.NoError(t, err)
+			require.True(t, added)
+		}
+		// full of nonces not expired, none is forgotten
+		_, err := store.Add(ctx, "c", time.Minute)
+		require.ErrorIs(t, err, ErrServiceBusy)
+		added, err := store.Add(ctx, "a", time.Minute)
+		require.NoError(t, err)
+		require.False(t, added)
+
+		clock.Advance(time.Minute)
+		added, err = store.Add(ctx, "c", time.Minute)
+		require.NoError(t, err)
+		require.True(t, added)
+	})
+	t.Run("expires by ttl", func(t *testing.T) {
+		clock := MockClock()
+		ctx := context.WithValue(ctx, clockContextKey{}, Clock(clock))
+		store := NewMemoryNonceStore(2)
+		added, _ := store.Add(ctx, "long", time.Hour)
+		require.True(t, added)
+		added, _ = store.Add(ctx, "short", time.Second)
+		require.True(t, added)
+		clock.Advance(time.Second)
+		// "short" expired first, though added last
+		added, err := store.Add(ctx, "next", time.Minute)
+		require.NoError(t, err)
+		require.True(t, added)
+		added, _ = store.Add(ctx, "long", time.Hour)
+		require.False(t, added)
+	})
+	t.Run("is safe for concurrent use", func(t *testing.T) {
+		store := NewMemoryNonceStore(1000)
+		var mu sync.Mutex
+		count := 0
+		var wg sync.WaitGroup
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					if added, _ := store.Add(ctx, fmt.Sprint(j), time.Minute); added {
+						mu.Lock()
+						count++
+						mu.Unlock()
+					}
+				}
+			}()
+		}
+		wg.Wait()
+		require.Equal(t, 100, count)
+	})
+}
@@ -0,0 +1,161 @@
+package jsonrpc2
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// NotificationDescription describes a notification pushed by the server, as responded by "rpc.listNotifications".
+type NotificationDescription struct {
+	Name         string          `json:"name"`
+	Description  string          `json:"description,omitempty"`
+	ParamsSchema json.RawMessage `json:"paramsSchema,omitempty"`
+}
+
+// NotifierFor returns a function sending the notification method with a payload of type T to conn, e.g. a connection
+// or a NotificationBus, so the method name and the payload type are checked once:
+//	orderCreated := jsonrpc2.NotifierFor[OrderCreated](bus, "order.created")
+//	err := orderCreated(ctx, OrderCreated{ID: 42})
+// The payload is marshaled before it is handed to conn, which applies its own queueing and backpressure.
+func NotifierFor[T any](conn Notifier, method string) func(ctx context.Context, payload T) error {
+	return func(ctx context.Context, payload T) error {
+		params, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		return conn.Notify(ctx, method, json.RawMessage(params))
+	}
+}
+
+// DescribeNotification declares that s pushes the notification method with a payload of type T, listed by
+// "rpc.listNotifications" with the JSON schema of T, see EnableIntrospection:
+//	err := jsonrpc2.DescribeNotification[OrderCreated](server, "order.created", "An order was created")
+// It returns an error if s is not a server of the package, e.g. a mock, nor a MethodRouter.
+func DescribeNotification[T any](s Server, method string, doc string) error {
+	srv, ok := s.(interface{ describeNotification(d NotificationDescription) })
+	if !ok {
+		return fmt.Errorf("jsonrpc2: %T cannot describe notifications", s)
+	}
+	srv.describeNotification(NotificationDescription{
+		Name:         method,
+		Description:  doc,
+		ParamsSchema: SchemaOf[T](),
+	})
+	return nil
+}
+
+// SchemaOf returns the JSON schema of the JSON encoding of T: its objects have the properties of the exported fields
+// of the structs, named by their json tags, and the fields without omitempty are required.
+func SchemaOf[T any]() json.RawMessage {
+	b, _ := json.Marshal(schemaOf(reflect.TypeOf((*T)(nil)).Elem(), map[reflect.Type]bool{}))
+	return b
+}
+
+// ============ Private members below =================
+
+const listNotificationsMethod = "rpc.listNotifications"
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+	marshalerType  = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+func (s *server) describeNotification(d NotificationDescription) {
+	s.methodsMu.Lock()
+	defer s.methodsMu.Unlock()
+	s.notifications[d.Name] = d
+}
+
+func (s *server) listNotifications(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	s.methodsMu.RLock()
+	notifications := make([]NotificationDescription, 0, len(s.notifications))
+	for _, d := range s.notifications {
+		notifications = append(notifications, d)
+	}
+	s.methodsMu.RUnlock()
+	sort.Slice(notifications, func(i, j int) bool {
+		return notifications[i].Name < notifications[j].Name
+	})
+	return notifications, nil
+}
+
+// schemaOf returns the schema of t, visiting are the struct types being described, whose recursive fields have an
+// empty schema
+func schemaOf(t reflect.Type, visiting map[reflect.Type]bool) map[string]interface{} {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case t == rawMessageType || t.Implements(marshalerType) || reflect.PointerTo(t).Implements(marshalerType):
+		return map[string]interface{}{}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// []byte is encoded in base64
+			return map[string]interface{}{"type": "string"}
+		}
+		return map[string]interface{}{"type": "array", "items": schemaOf(t.Elem(), visiting)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": schemaOf(t.Elem(), visiting)}
+	case reflect.Struct:
+		if visiting[t] {
+			return map[string]interface{}{}
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+		properties := map[string]interface{}{}
+		required := []string{}
+		structProperties(t, visiting, properties, &required)
+		sort.Strings(required)
+		return map[string]interface{}{"type": "object", "properties": properties, "required": required}
+	}
+	return map[string]interface{}{}
+}
+
+// structProperties adds the properties of the fields of t, and of its embedded structs, as encoded by encoding/json
+func structProperties(t reflect.Type, visiting map[reflect.Type]bool, properties map[string]interface{}, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		ft := f.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			structProperties(ft, visiting, properties, required)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		properties[name] = schemaOf(f.Type, visiting)
+		if !strings.Contains(","+opts+",", ",omitempty,") {
+			*required = append(*required, name)
+		}
+	}
+}
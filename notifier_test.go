@@ -0,0 +1,93 @@
+package jsonrpc2
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"io"
+	"testing"
+	"time"
+)
+
+// pipeConnection writes the notifications to a pipe, one per line
+type pipeConnection struct {
+	w io.Writer
+}
+
+func (c pipeConnection) Notify(ctx context.Context, method string, params interface{}) error {
+	b, err := json.Marshal(map[string]interface{}{"jsonrpc": "2.0", "method": method, "params": params})
+	if err != nil {
+		return err
+	}
+	_, err = c.w.Write(append(b, '\n'))
+	return err
+}
+
+type orderCreated struct {
+	ID       int               `json:"id"`
+	Items    []string          `json:"items"`
+	Note     string            `json:"note,omitempty"`
+	At       time.Time         `json:"at"`
+	Tags     map[string]string `json:"tags,omitempty"`
+	Parent   *orderCreated     `json:"parent,omitempty"`
+	internal bool
+}
+
+func TestNotifierFor(t *testing.T) {
+	r, w := io.Pipe()
+	notify := NotifierFor[orderCreated](pipeConnection{w}, "order.created")
+	sent := orderCreated{ID: 42, Items: []string{"book"}, At: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}
+	errs := make(chan error, 1)
+	go func() {
+		errs <- notify(context.Background(), sent)
+	}()
+	lines := bufio.NewScanner(r)
+	require.True(t, lines.Scan())
+	require.NoError(t, <-errs)
+	var n struct {
+		Method string       `json:"method"`
+		Params orderCreated `json:"params"`
+	}
+	require.NoError(t, json.Unmarshal(lines.Bytes(), &n))
+	require.Equal(t, "order.created", n.Method)
+	require.Equal(t, sent, n.Params)
+
+	invalid := NotifierFor[chan int](pipeConnection{w}, "invalid")
+	require.Error(t, invalid(context.Background(), make(chan int)))
+}
+
+func TestDescribeNotification(t *testing.T) {
+	server := NewServer(EnableIntrospection())
+	require.NoError(t, DescribeNotification[orderCreated](server, "order.created", "An order was created"))
+	require.NoError(t, DescribeNotification[string](server, "motd", ""))
+	rsp := server.ServeRequest(json.RawMessage(`{"jsonrpc": "2.0", "method": "rpc.listNotifications", "id": 1}`))
+	require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "result": [
+		{"name": "motd", "paramsSchema": {"type": "string"}},
+		{"name": "order.created", "description": "An order was created", "paramsSchema": {
+			"type": "object",
+			"properties": {
+				"id": {"type": "integer"},
+				"items": {"type": "array", "items": {"type": "string"}},
+				"note": {"type": "string"},
+				"at": {"type": "string", "format": "date-time"},
+				"tags": {"type": "object", "additionalProperties": {"type": "string"}},
+				"parent": {}
+			},
+			"required": ["at", "id", "items"]
+		}}
+	]}`, string(rsp))
+}
+
+func TestDescribeNotification_Router(t *testing.T) {
+	router := NewMethodRouter(EnableIntrospection())
+	require.NoError(t, DescribeNotification[string](router, "motd", ""))
+	rsp := router.ServeRequest(json.RawMessage(`{"jsonrpc": "2.0", "method": "rpc.listNotifications", "id": 1}`))
+	require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "result": [{"name": "motd", "paramsSchema": {"type": "string"}}]}`,
+		string(rsp))
+
+	// a wrapper does not forward the unexported method
+	wrapper := struct{ Server }{NewServer()}
+	require.EqualError(t, DescribeNotification[string](wrapper, "motd", ""),
+		"jsonrpc2: struct { jsonrpc2.Server } cannot describe notifications")
+}
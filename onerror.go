@@ -0,0 +1,65 @@
+package jsonrpc2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+)
+
+// FailedRequest is a request responded with an error, see OnError.
+type FailedRequest struct {
+	Method string
+	// ID is nil for a notification
+	ID      json.RawMessage
+	Code    int
+	Message string
+	// Notification is true for the notifications, whose error response is not sent as required by the spec
+	Notification bool
+}
+
+// OnError calls fn with the requests responded with an error, including the notifications whose error response is
+// suppressed, e.g. a notification of a method which is not defined, which would fail silently otherwise.
+//	server := jsonrpc2.NewServer(jsonrpc2.OnError(func(r jsonrpc2.FailedRequest) {
+//		if r.Notification {
+//			notificationErrors.WithLabelValues(r.Method, strconv.Itoa(r.Code)).Inc()
+//		}
+//	}))
+// fn is called by the goroutine serving the request, before its response is returned, so concurrently for the
+// elements of a batch.
+func OnError(fn func(FailedRequest)) ServerOption {
+	return func(s *server) {
+		s.onError = fn
+	}
+}
+
+// SetLogNotificationErrors logs the suppressed error responses of the notifications, see
+// ServerConfig.LogNotificationErrors.
+func (s *server) SetLogNotificationErrors(enabled bool) {
+	s.updateConfig(func(cfg *ServerConfig) {
+		cfg.LogNotificationErrors = enabled
+	})
+}
+
+// ============ Private members below =================
+
+// reportError reports the error response of r to OnError, and counts and logs it if r is a notification
+func (s *server) reportError(ctx context.Context, r request, err error) {
+	notification := s.validateRequest(r) == nil && r.ID == nil
+	if s.onError == nil && !notification {
+		return
+	}
+	f := FailedRequest{Method: r.Method, ID: r.ID, Code: CodeServerError, Message: err.Error(), Notification: notification}
+	var e Error
+	if errors.As(err, &e) {
+		f.Code = e.Code()
+	}
+	if notification {
+		s.metrics.notificationError(f.Method, f.Code)
+		if s.loadConfig().LogNotificationErrors {
+			s.requestLog(ctx, r).WarnContext(ctx, "jsonrpc2: notification error", "code", f.Code, "message", f.Message)
+		}
+	}
+	if s.onError != nil {
+		s.onError(f)
+	}
+}
@@ -0,0 +1,56 @@
+package jsonrpc2
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"expvar"
+	"github.com/stretchr/testify/require"
+	"log/slog"
+	"sync"
+	"testing"
+)
+
+func TestOnError(t *testing.T) {
+	var logs bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+
+	var mu sync.Mutex
+	var failed []FailedRequest
+	namespace := uniqueExpvarName("test.onerror")
+	server := NewServer(WithExpvarMetrics(namespace), OnError(func(r FailedRequest) {
+		mu.Lock()
+		failed = append(failed, r)
+		mu.Unlock()
+	}))
+	server.DefineMethod("fail", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		return nil, NewError(-32001, "failed")
+	})
+
+	// the responses of the notifications are suppressed but reported
+	require.Nil(t, server.ServeRequest(json.RawMessage(`{"jsonrpc": "2.0", "method": "undefined"}`)))
+	require.Nil(t, server.ServeRequest(json.RawMessage(`[
+		{"jsonrpc": "2.0", "method": "fail", "params": 1},
+		{"jsonrpc": "2.0", "method": "undefined"}
+	]`)))
+	require.ElementsMatch(t, []FailedRequest{
+		{Method: "undefined", Code: CodeMethodNotFound, Message: "Method not found", Notification: true},
+		{Method: "fail", Code: -32001, Message: "failed", Notification: true},
+		{Method: "undefined", Code: CodeMethodNotFound, Message: "Method not found", Notification: true},
+	}, failed)
+	require.JSONEq(t, `{"undefined": {"-32601": 2}, "fail": {"-32001": 1}}`,
+		expvar.Get(namespace + ".notification_errors").String())
+	require.Empty(t, logs.String())
+
+	// the responses of the requests are reported too
+	failed = nil
+	server.ServeRequest(json.RawMessage(`{"jsonrpc": "2.0", "method": "fail", "id": 1}`))
+	require.Equal(t, []FailedRequest{{Method: "fail", ID: json.RawMessage("1"), Code: -32001, Message: "failed"}}, failed)
+	require.JSONEq(t, `{"undefined": {"-32601": 2}, "fail": {"-32001": 1}}`,
+		expvar.Get(namespace + ".notification_errors").String())
+
+	server.SetLogNotificationErrors(true)
+	server.ServeRequest(json.RawMessage(`{"jsonrpc": "2.0", "method": "undefined"}`))
+	require.Contains(t, logs.String(), `level=WARN msg="jsonrpc2: notification error" method=undefined code=-32601 message="Method not found"`)
+}
@@ -0,0 +1,27 @@
+package otel
+
+import (
+	"context"
+	"encoding/json"
+	"github/brianso/go-jsonrpc2"
+	"go.opentelemetry.io/otel/trace"
+	"log/slog"
+)
+
+// NewLogMiddleware adds the "trace_id" and "span_id" of the span of the requests to their jsonrpc2.LoggerFromContext,
+// so the logs of the handlers are correlated with their traces.
+// Usage:
+//	server.Use(otel.NewLogMiddleware())
+func NewLogMiddleware() jsonrpc2.Middleware {
+	return func(next jsonrpc2.Handler) jsonrpc2.Handler {
+		return func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+			if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+				ctx = jsonrpc2.WithLogAttrs(ctx,
+					slog.String("trace_id", sc.TraceID().String()),
+					slog.String("span_id", sc.SpanID().String()),
+				)
+			}
+			return next(ctx, params)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package otel
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"github/brianso/go-jsonrpc2"
+	"go.opentelemetry.io/otel/trace"
+	"log/slog"
+	"testing"
+)
+
+func TestNewLogMiddleware(t *testing.T) {
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+		SpanID:  trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+	})
+	var logs bytes.Buffer
+	server := jsonrpc2.NewServer(
+		jsonrpc2.WithBaseContext(trace.ContextWithSpanContext(context.Background(), sc)),
+		jsonrpc2.WithLogger(slog.New(slog.NewJSONHandler(&logs, nil))),
+	)
+	server.Use(NewLogMiddleware())
+	server.DefineMethod("log", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		jsonrpc2.LoggerFromContext(ctx).Info("handled")
+		return nil, nil
+	})
+	server.ServeRequest(json.RawMessage(`{"jsonrpc":"2.0","method":"log","id":1}`))
+
+	var record map[string]interface{}
+	require.NoError(t, json.Unmarshal(logs.Bytes(), &record))
+	require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", record["trace_id"])
+	require.Equal(t, "00f067aa0ba902b7", record["span_id"])
+	require.Equal(t, "log", record["method"])
+}
@@ -0,0 +1,80 @@
+// otel records OpenTelemetry metrics of jsonrpc2 servers, following the RPC semantic conventions, and correlates
+// their logs with traces
+package otel
+
+import (
+	"context"
+	"encoding/json"
+	"github/brianso/go-jsonrpc2"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"time"
+)
+
+// NewMetricMiddleware records the instruments of the RPC semantic conventions for each request:
+//	rpc.server.duration       histogram of the handler duration in milliseconds, measured by the server clock
+//	rpc.server.request_size   histogram of the request json size in bytes
+//	rpc.server.response_size  histogram of the result or error json size in bytes
+// with the attributes rpc.system="jsonrpc", rpc.method and, on error only, rpc.jsonrpc.error_code.
+// Usage:
+//	server.Use(otel.NewMetricMiddleware(otel.GetMeterProvider().Meter("jsonrpc2")))
+func NewMetricMiddleware(meter metric.Meter) jsonrpc2.Middleware {
+	duration, _ := meter.Float64Histogram("rpc.server.duration",
+		metric.WithUnit("ms"),
+		metric.WithDescription("Measures the duration of inbound RPC."),
+		metric.WithExplicitBucketBoundaries(durationBoundaries...),
+	)
+	requestSize, _ := meter.Int64Histogram("rpc.server.request_size",
+		metric.WithUnit("By"),
+		metric.WithDescription("Measures the size of RPC request messages (uncompressed)."),
+	)
+	responseSize, _ := meter.Int64Histogram("rpc.server.response_size",
+		metric.WithUnit("By"),
+		metric.WithDescription("Measures the size of RPC response messages (uncompressed)."),
+	)
+	return func(next jsonrpc2.Handler) jsonrpc2.Handler {
+		return func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+			clock := jsonrpc2.ClockFromContext(ctx)
+			start := clock.Now()
+			result, err := next(ctx, params)
+			elapsed := clock.Now().Sub(start)
+
+			attrs := []attribute.KeyValue{attribute.String("rpc.system", "jsonrpc")}
+			if info := jsonrpc2.MethodCallInfo(ctx); info != nil {
+				attrs = append(attrs, attribute.String("rpc.method", info.Method))
+			}
+			if err != nil {
+				attrs = append(attrs, attribute.Int("rpc.jsonrpc.error_code", errorCode(err)))
+			}
+			opt := metric.WithAttributes(attrs...)
+			duration.Record(ctx, float64(elapsed)/float64(time.Millisecond), opt)
+			requestSize.Record(ctx, int64(len(jsonrpc2.RawRequestFromContext(ctx))), opt)
+			responseSize.Record(ctx, int64(responseLen(result, err)), opt)
+			return result, err
+		}
+	}
+}
+
+// ============ Private members below =================
+
+// durationBoundaries are the recommended bucket boundaries of rpc.server.duration, in milliseconds
+var durationBoundaries = []float64{0, 5, 10, 25, 50, 75, 100, 250, 500, 750, 1000, 2500, 5000, 7500, 10000}
+
+// errorCode returns the code responded for err
+func errorCode(err error) int {
+	if code, ok := jsonrpc2.CodeOf(err); ok {
+		return code
+	}
+	return jsonrpc2.CodeServerError
+}
+
+// responseLen returns the json size of the result, or of the error message if err is not nil
+func responseLen(result interface{}, err error) int {
+	var b []byte
+	if err != nil {
+		b, _ = json.Marshal(err.Error())
+	} else {
+		b, _ = json.Marshal(result)
+	}
+	return len(b)
+}
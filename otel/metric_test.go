@@ -0,0 +1,64 @@
+package otel
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"github/brianso/go-jsonrpc2"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"testing"
+)
+
+func TestNewMetricMiddleware(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	meter := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)).Meter("test")
+	server := jsonrpc2.NewServer()
+	server.Use(NewMetricMiddleware(meter))
+	server.DefineMethod("echo", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		return params, nil
+	})
+	server.DefineMethod("fail", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		return nil, jsonrpc2.ErrInvalidParams
+	})
+	req := `{"jsonrpc":"2.0","method":"echo","params":"hi","id":1}`
+	server.ServeRequest(json.RawMessage(req))
+	server.ServeRequest(json.RawMessage(`{"jsonrpc":"2.0","method":"fail","id":1}`))
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	require.Len(t, rm.ScopeMetrics, 1)
+	metrics := map[string]metricdata.Metrics{}
+	for _, m := range rm.ScopeMetrics[0].Metrics {
+		metrics[m.Name] = m
+	}
+	echoAttrs := attribute.NewSet(attribute.String("rpc.system", "jsonrpc"), attribute.String("rpc.method", "echo"))
+	failAttrs := attribute.NewSet(
+		attribute.String("rpc.system", "jsonrpc"),
+		attribute.String("rpc.method", "fail"),
+		attribute.Int("rpc.jsonrpc.error_code", jsonrpc2.CodeInvalidParams),
+	)
+
+	duration := metrics["rpc.server.duration"]
+	require.Equal(t, "ms", duration.Unit)
+	points := duration.Data.(metricdata.Histogram[float64]).DataPoints
+	require.Len(t, points, 2)
+	require.Equal(t, durationBoundaries, points[0].Bounds)
+	for _, p := range points {
+		require.Equal(t, uint64(1), p.Count)
+		require.Contains(t, []attribute.Set{echoAttrs, failAttrs}, p.Attributes)
+	}
+
+	sizes := func(name string) map[attribute.Set]int64 {
+		m := metrics[name]
+		require.Equal(t, "By", m.Unit)
+		sums := map[attribute.Set]int64{}
+		for _, p := range m.Data.(metricdata.Histogram[int64]).DataPoints {
+			sums[p.Attributes] = p.Sum
+		}
+		return sums
+	}
+	require.Equal(t, map[attribute.Set]int64{echoAttrs: int64(len(req)), failAttrs: 40}, sizes("rpc.server.request_size"))
+	require.Equal(t, map[attribute.Set]int64{echoAttrs: 4, failAttrs: 16}, sizes("rpc.server.response_size"))
+}
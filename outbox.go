@@ -0,0 +1,454 @@
+package jsonrpc2
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+type (
+	// Outbox keeps the notifications published to durable topics until the subscriber acknowledges them, so the
+	// notifications published while the subscriber is offline are delivered when it reconnects, at least once.
+	// A topic has a single subscriber, e.g. "user:42", which receives the notifications in publishing order with their
+	// sequence number, as SequencedParams, and acknowledges them by the "rpc.ack" notification, see EnableOutboxAck:
+	//	<-- {"jsonrpc": "2.0", "method": "order.created", "params": {"seq": 7, "params": {...}}}
+	//	--> {"jsonrpc": "2.0", "method": "rpc.ack", "params": {"topic": "user:42", "seq": 7}}
+	// Usage:
+	//	outbox := jsonrpc2.NewOutbox(jsonrpc2.NewMemoryOutboxStore(), jsonrpc2.OutboxConfig{MaxEvents: 1000})
+	//	server := jsonrpc2.NewServer(jsonrpc2.EnableOutboxAck(outbox))
+	//
+	//	disconnect, err := outbox.Connect(ctx, "user:42", conn) // redelivers the events not acknowledged
+	//	defer disconnect()
+	//
+	//	outbox.Publish(ctx, "user:42", "order.created", order)
+	Outbox struct {
+		store OutboxStore
+		cfg   OutboxConfig
+
+		// mu serializes the calls to the store and queues the events to the subscribers in order. The events are sent
+		// outside mu, so a slow subscriber only delays the publications to its topic.
+		mu   sync.Mutex
+		subs map[string]*outboxSub
+	}
+
+	// OutboxConfig bounds the events kept per topic, zero values mean no limit.
+	OutboxConfig struct {
+		// MaxEvents is the max number of events kept per topic, the oldest are dropped
+		MaxEvents int
+		// MaxAge drops the events published more than MaxAge ago
+		MaxAge time.Duration
+		// Clock tells the publication time of the events, the real time if nil
+		Clock Clock
+	}
+
+	// OutboxEvent is a notification kept by an Outbox.
+	OutboxEvent struct {
+		Seq    uint64          `json:"seq"`
+		Method string          `json:"method"`
+		Params json.RawMessage `json:"params,omitempty"`
+		Time   time.Time       `json:"time"`
+	}
+
+	// An OutboxStore keeps the events of an Outbox. It is only called by the Outbox, one call at a time.
+	OutboxStore interface {
+		// Append adds e to the events of topic, e.Seq is the last sequence number of topic plus one
+		Append(topic string, e OutboxEvent) error
+		// Events returns the events of topic with a sequence number above after, in order
+		Events(topic string, after uint64) ([]OutboxEvent, error)
+		// Trim removes the events of topic up to the sequence number upTo included
+		Trim(topic string, upTo uint64) error
+		// LastSeq returns the sequence number of the last event appended to topic, even if trimmed, or 0
+		LastSeq(topic string) (uint64, error)
+	}
+
+	// MemoryOutboxStore is an OutboxStore in memory, the events are lost when the process exits.
+	MemoryOutboxStore struct {
+		topics map[string]*outboxTopic
+	}
+
+	// FileOutboxStore is an OutboxStore keeping the events in memory and in an append-only file, so they survive the
+	// restarts of the process. The file grows with every operation, it is rewritten with the events kept by Compact.
+	FileOutboxStore struct {
+		MemoryOutboxStore
+		path string
+		f    *os.File
+	}
+)
+
+func NewOutbox(store OutboxStore, cfg OutboxConfig) *Outbox {
+	if cfg.Clock == nil {
+		cfg.Clock = RealClock()
+	}
+	return &Outbox{store: store, cfg: cfg, subs: map[string]*outboxSub{}}
+}
+
+// EnableOutboxAck defines the "rpc.ack" method, acknowledging the events of a topic of outbox up to a sequence number:
+//	--> {"jsonrpc": "2.0", "method": "rpc.ack", "params": {"topic": "user:42", "seq": 7}}
+// The topics should not be guessable, as any client can acknowledge them.
+func EnableOutboxAck(outbox *Outbox) ServerOption {
+	return func(s *server) {
+		s.defineBuiltins(MethodConfig{
+			Name: ackMethod,
+			Handler: func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+				var ack struct {
+					Topic string  `json:"topic"`
+					Seq   *uint64 `json:"seq"`
+				}
+				if err := json.Unmarshal(params, &ack); err != nil || ack.Topic == "" || ack.Seq == nil {
+					return nil, ErrInvalidParams
+				}
+				return nil, outbox.Ack(ack.Topic, *ack.Seq)
+			},
+			Doc:          "Acknowledge the events of an outbox topic up to a sequence number",
+			ParamsSchema: json.RawMessage(`{"type":"object","properties":{"topic":{"type":"string"},"seq":{"type":"integer"}},"required":["topic","seq"]}`),
+		})
+	}
+}
+
+// Publish keeps the notification in topic and sends it to the subscriber of topic if connected. It returns the
+// sequence number of the notification once sent. A failed delivery is not an error, the notification is redelivered on
+// reconnection.
+func (o *Outbox) Publish(ctx context.Context, topic string, method string, params interface{}) (uint64, error) {
+	var raw json.RawMessage
+	if params != nil {
+		var err error
+		if raw, err = json.Marshal(params); err != nil {
+			return 0, err
+		}
+	}
+	e, sub, err := o.append(topic, method, raw)
+	if err != nil {
+		return 0, err
+	}
+	if sub != nil {
+		sub.flush(ctx)
+	}
+	return e.Seq, nil
+}
+
+// Connect redelivers the events of topic not acknowledged to conn, in order, then sends it the events published until
+// the returned function is called. A new connection replaces the previous subscriber of topic.
+func (o *Outbox) Connect(ctx context.Context, topic string, conn Notifier) (func(), error) {
+	sub, err := o.subscribe(topic, conn)
+	if err != nil {
+		return nil, err
+	}
+	sub.flush(ctx)
+	return func() {
+		o.mu.Lock()
+		defer o.mu.Unlock()
+		if o.subs[topic] == sub {
+			delete(o.subs, topic)
+		}
+	}, nil
+}
+
+// Ack removes the events of topic up to seq included, they are not redelivered.
+func (o *Outbox) Ack(topic string, seq uint64) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.store.Trim(topic, seq)
+}
+
+func NewMemoryOutboxStore() *MemoryOutboxStore {
+	return &MemoryOutboxStore{topics: map[string]*outboxTopic{}}
+}
+
+func (m *MemoryOutboxStore) Append(topic string, e OutboxEvent) error {
+	t := m.topic(topic)
+	t.events = append(t.events, e)
+	t.last = e.Seq
+	return nil
+}
+
+func (m *MemoryOutboxStore) Events(topic string, after uint64) ([]OutboxEvent, error) {
+	t, ok := m.topics[topic]
+	if !ok {
+		return nil, nil
+	}
+	var events []OutboxEvent
+	for _, e := range t.events {
+		if e.Seq > after {
+			events = append(events, e)
+		}
+	}
+	return events, nil
+}
+
+func (m *MemoryOutboxStore) Trim(topic string, upTo uint64) error {
+	t, ok := m.topics[topic]
+	if !ok {
+		return nil
+	}
+	i := 0
+	for i < len(t.events) && t.events[i].Seq <= upTo {
+		i++
+	}
+	t.events = append([]OutboxEvent(nil), t.events[i:]...)
+	return nil
+}
+
+func (m *MemoryOutboxStore) LastSeq(topic string) (uint64, error) {
+	if t, ok := m.topics[topic]; ok {
+		return t.last, nil
+	}
+	return 0, nil
+}
+
+// OpenFileOutboxStore opens the store of the file at path, created if it does not exist, and loads its events.
+func OpenFileOutboxStore(path string) (*FileOutboxStore, error) {
+	s := &FileOutboxStore{MemoryOutboxStore: *NewMemoryOutboxStore(), path: path}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	s.f = f
+	return s, nil
+}
+
+func (s *FileOutboxStore) Append(topic string, e OutboxEvent) error {
+	if err := s.log(outboxRecord{Topic: topic, Event: &e}); err != nil {
+		return err
+	}
+	return s.MemoryOutboxStore.Append(topic, e)
+}
+
+func (s *FileOutboxStore) Trim(topic string, upTo uint64) error {
+	if err := s.log(outboxRecord{Topic: topic, Trim: upTo}); err != nil {
+		return err
+	}
+	return s.MemoryOutboxStore.Trim(topic, upTo)
+}
+
+// Compact rewrites the file with the events kept, it must not be called while the store is used by an Outbox.
+func (s *FileOutboxStore) Compact() error {
+	tmp := s.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for topic, t := range s.topics {
+		// the trim record keeps the last sequence number of the topics without events
+		records := []outboxRecord{{Topic: topic, Trim: t.last, Last: t.last}}
+		for i := range t.events {
+			records = append(records, outboxRecord{Topic: topic, Event: &t.events[i]})
+		}
+		for _, r := range records {
+			if err := enc.Encode(r); err != nil {
+				f.Close()
+				return err
+			}
+		}
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return err
+	}
+	s.f.Close()
+	s.f, err = os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND, 0o600)
+	return err
+}
+
+func (s *FileOutboxStore) Close() error {
+	return s.f.Close()
+}
+
+// ============ Private members below =================
+
+// ackMethod acknowledges the events of an Outbox, see EnableOutboxAck
+const ackMethod = "rpc.ack"
+
+type (
+	// outboxSub sends the events queued by the Outbox to a connection, one at a time in order
+	outboxSub struct {
+		conn Notifier
+
+		// mu guards pending, send is held while sending
+		mu      sync.Mutex
+		pending []OutboxEvent
+		send    sync.Mutex
+	}
+
+	outboxTopic struct {
+		events []OutboxEvent
+		last   uint64
+	}
+
+	// outboxRecord is a line of the file of a FileOutboxStore: an appended event, or a trim
+	outboxRecord struct {
+		Topic string       `json:"topic"`
+		Event *OutboxEvent `json:"event,omitempty"`
+		Trim  uint64       `json:"trim,omitempty"`
+		// Last restores the last sequence number of a compacted topic
+		Last uint64 `json:"last,omitempty"`
+	}
+)
+
+// append keeps a new event in topic and queues it to the subscriber of topic, returned if connected
+func (o *Outbox) append(topic string, method string, params json.RawMessage) (OutboxEvent, *outboxSub, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	last, err := o.store.LastSeq(topic)
+	if err != nil {
+		return OutboxEvent{}, nil, err
+	}
+	e := OutboxEvent{Seq: last + 1, Method: method, Params: params, Time: o.cfg.Clock.Now()}
+	if err := o.store.Append(topic, e); err != nil {
+		return OutboxEvent{}, nil, err
+	}
+	if err := o.retain(topic); err != nil {
+		return OutboxEvent{}, nil, err
+	}
+	sub := o.subs[topic]
+	if sub != nil {
+		sub.queue(e)
+	}
+	return e, sub, nil
+}
+
+// subscribe makes conn the subscriber of topic, with the events not acknowledged queued
+func (o *Outbox) subscribe(topic string, conn Notifier) (*outboxSub, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if err := o.retain(topic); err != nil {
+		return nil, err
+	}
+	events, err := o.store.Events(topic, 0)
+	if err != nil {
+		return nil, err
+	}
+	sub := &outboxSub{conn: conn}
+	sub.queue(events...)
+	o.subs[topic] = sub
+	return sub, nil
+}
+
+func (sub *outboxSub) queue(events ...OutboxEvent) {
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+	sub.pending = append(sub.pending, events...)
+}
+
+// flush sends the queued events, including those queued by the other callers while waiting for the send lock
+func (sub *outboxSub) flush(ctx context.Context) {
+	sub.send.Lock()
+	defer sub.send.Unlock()
+	for {
+		sub.mu.Lock()
+		if len(sub.pending) == 0 {
+			sub.mu.Unlock()
+			return
+		}
+		e := sub.pending[0]
+		sub.pending = sub.pending[1:]
+		sub.mu.Unlock()
+		sub.deliver(ctx, e)
+	}
+}
+
+// deliver sends e to the subscriber, the failures are left to the redelivery on reconnection
+func (sub *outboxSub) deliver(ctx context.Context, e OutboxEvent) {
+	var params interface{}
+	if e.Params != nil {
+		params = e.Params
+	}
+	_ = sub.conn.Notify(ctx, e.Method, SequencedParams{Seq: e.Seq, Params: params})
+}
+
+// retain drops the events of topic beyond OutboxConfig.MaxEvents and MaxAge, o.mu must be held
+func (o *Outbox) retain(topic string) error {
+	if o.cfg.MaxEvents <= 0 && o.cfg.MaxAge <= 0 {
+		return nil
+	}
+	events, err := o.store.Events(topic, 0)
+	if err != nil {
+		return err
+	}
+	var upTo uint64
+	if o.cfg.MaxEvents > 0 && len(events) > o.cfg.MaxEvents {
+		upTo = events[len(events)-o.cfg.MaxEvents-1].Seq
+	}
+	if o.cfg.MaxAge > 0 {
+		expired := o.cfg.Clock.Now().Add(-o.cfg.MaxAge)
+		for _, e := range events {
+			if e.Time.Before(expired) && e.Seq > upTo {
+				upTo = e.Seq
+			}
+		}
+	}
+	if upTo == 0 {
+		return nil
+	}
+	return o.store.Trim(topic, upTo)
+}
+
+func (m *MemoryOutboxStore) topic(topic string) *outboxTopic {
+	t, ok := m.topics[topic]
+	if !ok {
+		t = &outboxTopic{}
+		m.topics[topic] = t
+	}
+	return t
+}
+
+// load replays the records of the file in memory
+func (s *FileOutboxStore) load() error {
+	f, err := os.Open(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<26)
+	for line := 1; scanner.Scan(); line++ {
+		var r outboxRecord
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			return fmt.Errorf("jsonrpc2: outbox file %s line %d: %w", s.path, line, err)
+		}
+		t := s.topic(r.Topic)
+		if r.Last > t.last {
+			t.last = r.Last
+		}
+		if r.Event != nil {
+			s.MemoryOutboxStore.Append(r.Topic, *r.Event)
+		} else {
+			s.MemoryOutboxStore.Trim(r.Topic, r.Trim)
+		}
+	}
+	return scanner.Err()
+}
+
+// log appends r to the file and syncs it, so the operation survives a crash once it returns
+func (s *FileOutboxStore) log(r outboxRecord) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	if _, err := s.f.Write(append(b, '\n')); err != nil {
+		return err
+	}
+	return s.f.Sync()
+}
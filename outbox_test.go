@@ -0,0 +1,171 @@
+package jsonrpc2
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestOutbox(t *testing.T) {
+	ctx := context.Background()
+	received := func(c *testConnection) []uint64 {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		var seqs []uint64
+		for _, p := range c.params {
+			seqs = append(seqs, p.(SequencedParams).Seq)
+		}
+		return seqs
+	}
+	t.Run("redeliver on reconnection", func(t *testing.T) {
+		outbox := NewOutbox(NewMemoryOutboxStore(), OutboxConfig{})
+		server := NewServer(EnableOutboxAck(outbox))
+		for i := 1; i <= 3; i++ {
+			seq, err := outbox.Publish(ctx, "user:42", "order.created", i)
+			require.NoError(t, err)
+			require.Equal(t, uint64(i), seq)
+		}
+		c1 := &testConnection{}
+		disconnect, err := outbox.Connect(ctx, "user:42", c1)
+		require.NoError(t, err)
+		require.Equal(t, []uint64{1, 2, 3}, received(c1))
+		require.Equal(t, SequencedParams{Seq: 1, Params: json.RawMessage("1")}, c1.params[0])
+
+		// live and acknowledged
+		_, err = outbox.Publish(ctx, "user:42", "order.created", 4)
+		require.NoError(t, err)
+		require.Equal(t, []uint64{1, 2, 3, 4}, received(c1))
+		require.Nil(t, server.ServeRequest(json.RawMessage(`{"jsonrpc": "2.0", "method": "rpc.ack", "params": {"topic": "user:42", "seq": 2}}`)))
+		disconnect()
+
+		_, err = outbox.Publish(ctx, "user:42", "order.created", 5)
+		require.NoError(t, err)
+		require.Equal(t, []uint64{1, 2, 3, 4}, received(c1))
+		c2 := &testConnection{}
+		_, err = outbox.Connect(ctx, "user:42", c2)
+		require.NoError(t, err)
+		require.Equal(t, []uint64{3, 4, 5}, received(c2))
+	})
+	t.Run("failed delivery is redelivered", func(t *testing.T) {
+		outbox := NewOutbox(NewMemoryOutboxStore(), OutboxConfig{})
+		_, err := outbox.Connect(ctx, "user:42", &testConnection{err: errConnectionClosed})
+		require.NoError(t, err)
+		_, err = outbox.Publish(ctx, "user:42", "order.created", nil)
+		require.NoError(t, err)
+		c := &testConnection{}
+		_, err = outbox.Connect(ctx, "user:42", c)
+		require.NoError(t, err)
+		require.Equal(t, []string{"order.created"}, c.notifications)
+		require.Equal(t, SequencedParams{Seq: 1}, c.params[0])
+	})
+	t.Run("a slow subscriber only delays its topic", func(t *testing.T) {
+		outbox := NewOutbox(NewMemoryOutboxStore(), OutboxConfig{})
+		slow := &slowConnection{sending: make(chan struct{}), release: make(chan struct{})}
+		_, err := outbox.Connect(ctx, "user:42", slow)
+		require.NoError(t, err)
+		published := make(chan struct{})
+		go func() {
+			defer close(published)
+			for i := 1; i <= 3; i++ {
+				_, err := outbox.Publish(ctx, "user:42", "order.created", i)
+				require.NoError(t, err)
+			}
+		}()
+		<-slow.sending
+
+		c := &testConnection{}
+		_, err = outbox.Connect(ctx, "user:7", c)
+		require.NoError(t, err)
+		_, err = outbox.Publish(ctx, "user:7", "order.created", 1)
+		require.NoError(t, err)
+		require.Equal(t, []uint64{1}, received(c))
+		require.NoError(t, outbox.Ack("user:7", 1))
+
+		close(slow.release)
+		<-published
+		require.Equal(t, []uint64{1, 2, 3}, received(&slow.testConnection))
+	})
+	t.Run("invalid ack", func(t *testing.T) {
+		server := NewServer(EnableOutboxAck(NewOutbox(NewMemoryOutboxStore(), OutboxConfig{})))
+		for _, params := range []string{`{"topic": "user:42"}`, `{"seq": 1}`, `[1]`, `{"topic": "user:42", "seq": -1}`} {
+			rsp := server.ServeRequest(json.RawMessage(`{"jsonrpc": "2.0", "method": "rpc.ack", "params": ` + params + `, "id": 1}`))
+			require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "error": {"code": -32602, "message": "Invalid Params"}}`, string(rsp), params)
+		}
+	})
+	t.Run("retention", func(t *testing.T) {
+		clock := MockClock()
+		outbox := NewOutbox(NewMemoryOutboxStore(), OutboxConfig{MaxEvents: 3, MaxAge: time.Hour, Clock: clock})
+		for i := 1; i <= 5; i++ {
+			_, err := outbox.Publish(ctx, "user:42", "tick", i)
+			require.NoError(t, err)
+			clock.Advance(25 * time.Minute)
+		}
+		// 1 and 2 are beyond MaxEvents, 3 is 75 minutes old
+		c := &testConnection{}
+		_, err := outbox.Connect(ctx, "user:42", c)
+		require.NoError(t, err)
+		require.Equal(t, []uint64{4, 5}, received(c))
+	})
+	t.Run("file store", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "outbox.log")
+		store, err := OpenFileOutboxStore(path)
+		require.NoError(t, err)
+		outbox := NewOutbox(store, OutboxConfig{})
+		for i := 1; i <= 3; i++ {
+			_, err := outbox.Publish(ctx, "user:42", "order.created", i)
+			require.NoError(t, err)
+		}
+		_, err = outbox.Publish(ctx, "user:7", "order.created", 1)
+		require.NoError(t, err)
+		require.NoError(t, outbox.Ack("user:42", 1))
+		require.NoError(t, outbox.Ack("user:7", 1))
+		require.NoError(t, store.Close())
+
+		for _, compact := range []bool{false, true} {
+			store, err = OpenFileOutboxStore(path)
+			require.NoError(t, err)
+			if compact {
+				require.NoError(t, store.Compact())
+				require.NoError(t, store.Close())
+				store, err = OpenFileOutboxStore(path)
+				require.NoError(t, err)
+			}
+			events, err := store.Events("user:42", 0)
+			require.NoError(t, err)
+			require.Len(t, events, 2)
+			require.Equal(t, uint64(2), events[0].Seq)
+			require.Equal(t, json.RawMessage("2"), events[0].Params)
+			last, err := store.LastSeq("user:7")
+			require.NoError(t, err)
+			require.Equal(t, uint64(1), last, "compact %v", compact)
+			require.NoError(t, store.Close())
+		}
+
+		store, err = OpenFileOutboxStore(path)
+		require.NoError(t, err)
+		defer store.Close()
+		seq, err := NewOutbox(store, OutboxConfig{}).Publish(ctx, "user:42", "order.created", 4)
+		require.NoError(t, err)
+		require.Equal(t, uint64(4), seq)
+	})
+}
+
+// slowConnection blocks its first notification until release is closed
+type slowConnection struct {
+	testConnection
+	sending chan struct{}
+	release chan struct{}
+	once    sync.Once
+}
+
+func (c *slowConnection) Notify(ctx context.Context, method string, params interface{}) error {
+	c.once.Do(func() {
+		close(c.sending)
+		<-c.release
+	})
+	return c.testConnection.Notify(ctx, method, params)
+}
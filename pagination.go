@@ -0,0 +1,144 @@
+package jsonrpc2
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+)
+
+type (
+	// Cursor is the opaque state of a paginated method telling where the next page starts, nil after the last page.
+	Cursor []byte
+
+	// PageRequest is the page requested by the "limit" and "cursor" members of the params of a paginated method.
+	PageRequest struct {
+		// Limit is the max number of items of the page, between 1 and the max page size of the method
+		Limit int
+		// Cursor is the cursor returned with the previous page, nil for the first page
+		Cursor Cursor
+	}
+
+	// A PageOption configures a paginated method, see DefinePaginatedMethod.
+	PageOption func(cfg *pageConfig)
+)
+
+// DefaultMaxPageSize is the max page size of the paginated methods without WithMaxPageSize.
+const DefaultMaxPageSize = 100
+
+// WithMaxPageSize clamps the limit of the pages to n, which is also the limit when the request has none.
+func WithMaxPageSize(n int) PageOption {
+	return func(cfg *pageConfig) {
+		cfg.maxSize = n
+	}
+}
+
+// WithSignedCursors signs the cursors with HMAC-SHA256 and key, so the requests with a cursor which was not returned
+// by the server respond ErrInvalidParams.
+func WithSignedCursors(key []byte) PageOption {
+	return func(cfg *pageConfig) {
+		cfg.key = key
+	}
+}
+
+// DefinePaginatedMethod defines a method returning a list page by page. The page is requested by the "limit" and
+// "cursor" members of the params object, the other members are decoded to P:
+//	--> {"jsonrpc": "2.0", "method": "users.list", "params": {"team": "core", "limit": 2}, "id": 1}
+//	<-- {"jsonrpc": "2.0", "result": {"items": [{...}, {...}], "nextCursor": "MTI"}, "id": 1}
+//	--> {"jsonrpc": "2.0", "method": "users.list", "params": {"team": "core", "limit": 2, "cursor": "MTI"}, "id": 2}
+// h returns the items of the page and the cursor of the next page, nil after the last page. The cursors are the base64
+// of the state returned by h, signed with WithSignedCursors.
+// Usage:
+//	jsonrpc2.DefinePaginatedMethod(server, "users.list", func(ctx context.Context, p ListUsers, page jsonrpc2.PageRequest) ([]User, jsonrpc2.Cursor, error) {
+//		users, last, err := db.ListUsers(p.Team, string(page.Cursor), page.Limit)
+//		return users, jsonrpc2.Cursor(last), err
+//	}, jsonrpc2.WithMaxPageSize(50))
+func DefinePaginatedMethod[P, R any](s Server, method string, h func(ctx context.Context, params P, page PageRequest) (items []R, next Cursor, err error), opts ...PageOption) {
+	cfg := pageConfig{maxSize: DefaultMaxPageSize}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	s.DefineMethod(method, func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		var p P
+		var page struct {
+			Limit  int     `json:"limit"`
+			Cursor *string `json:"cursor"`
+		}
+		if len(params) > 0 {
+			if json.Unmarshal(params, &p) != nil || json.Unmarshal(params, &page) != nil {
+				return nil, ErrInvalidParams
+			}
+		}
+		req := PageRequest{Limit: page.Limit}
+		if req.Limit <= 0 || req.Limit > cfg.maxSize {
+			req.Limit = cfg.maxSize
+		}
+		if page.Cursor != nil {
+			c, ok := cfg.decode(*page.Cursor)
+			if !ok {
+				return nil, ErrInvalidParams
+			}
+			req.Cursor = c
+		}
+		items, next, err := h(ctx, p, req)
+		if err != nil {
+			return nil, err
+		}
+		if items == nil {
+			items = []R{}
+		}
+		rsp := pageResponse[R]{Items: items}
+		if next != nil {
+			rsp.NextCursor = cfg.encode(next)
+		}
+		return rsp, nil
+	})
+}
+
+// ============ Private members below =================
+
+type (
+	pageConfig struct {
+		maxSize int
+		// key signs the cursors if not nil
+		key []byte
+	}
+
+	pageResponse[R any] struct {
+		Items      []R    `json:"items"`
+		NextCursor string `json:"nextCursor,omitempty"`
+	}
+)
+
+func (cfg *pageConfig) encode(c Cursor) string {
+	if cfg.key != nil {
+		c = append(c[:len(c):len(c)], cfg.sign(c)...)
+	}
+	return base64.RawURLEncoding.EncodeToString(c)
+}
+
+// decode returns the state of the cursor s, false if it is invalid
+func (cfg *pageConfig) decode(s string) (Cursor, bool) {
+	c, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, false
+	}
+	if cfg.key == nil {
+		return c, true
+	}
+	if len(c) < sha256.Size {
+		return nil, false
+	}
+	state, mac := c[:len(c)-sha256.Size], c[len(c)-sha256.Size:]
+	if !hmac.Equal(mac, cfg.sign(state)) {
+		return nil, false
+	}
+	return state, true
+}
+
+func (cfg *pageConfig) sign(state []byte) []byte {
+	mac := hmac.New(sha256.New, cfg.key)
+	mac.Write(state)
+	return mac.Sum(nil)
+}
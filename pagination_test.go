@@ -0,0 +1,78 @@
+package jsonrpc2
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"strconv"
+	"testing"
+)
+
+func TestDefinePaginatedMethod(t *testing.T) {
+	type listParams struct {
+		From int `json:"from"`
+	}
+	var pages []PageRequest
+	list := func(ctx context.Context, p listParams, page PageRequest) ([]int, Cursor, error) {
+		pages = append(pages, page)
+		start := p.From
+		if page.Cursor != nil {
+			start, _ = strconv.Atoi(string(page.Cursor))
+		}
+		var items []int
+		for i := start; i < start+page.Limit && i < 10; i++ {
+			items = append(items, i)
+		}
+		if start+page.Limit >= 10 {
+			return items, nil, nil
+		}
+		return items, Cursor(strconv.Itoa(start + page.Limit)), nil
+	}
+	call := func(server Server, params string) string {
+		rsp := server.ServeRequest(json.RawMessage(`{"jsonrpc": "2.0", "method": "list", "params": ` + params + `, "id": 1}`))
+		result, rpcErr, err := ExtractResult(rsp)
+		require.NoError(t, err)
+		if rpcErr != nil {
+			return rpcErr.Error()
+		}
+		return string(result)
+	}
+
+	server := NewServer()
+	DefinePaginatedMethod(server, "list", list, WithMaxPageSize(4))
+	t.Run("pages", func(t *testing.T) {
+		require.JSONEq(t, `{"items": [2, 3, 4], "nextCursor": "NQ"}`, call(server, `{"from": 2, "limit": 3}`))
+		require.JSONEq(t, `{"items": [5, 6, 7], "nextCursor": "OA"}`, call(server, `{"from": 2, "limit": 3, "cursor": "NQ"}`))
+		require.JSONEq(t, `{"items": [8, 9]}`, call(server, `{"limit": 3, "cursor": "OA"}`))
+		require.JSONEq(t, `{"items": []}`, call(server, `{"from": 20}`))
+	})
+	t.Run("limit clamping", func(t *testing.T) {
+		pages = nil
+		require.JSONEq(t, `{"items": [0, 1, 2, 3], "nextCursor": "NA"}`, call(server, `{"limit": 50}`))
+		call(server, `{}`)
+		call(server, `{"limit": -1}`)
+		require.Equal(t, []PageRequest{{Limit: 4}, {Limit: 4}, {Limit: 4}}, pages)
+	})
+	t.Run("invalid params", func(t *testing.T) {
+		require.Equal(t, "Invalid Params", call(server, `{"limit": "ten"}`))
+		require.Equal(t, "Invalid Params", call(server, `{"cursor": "%%"}`))
+	})
+	t.Run("signed cursors", func(t *testing.T) {
+		server := NewServer()
+		DefinePaginatedMethod(server, "list", list, WithMaxPageSize(4), WithSignedCursors([]byte("secret")))
+		var page struct {
+			NextCursor string `json:"nextCursor"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(call(server, `{"limit": 3}`)), &page))
+		require.NotEqual(t, "Mw", page.NextCursor)
+		require.JSONEq(t, `{"items": [3, 4, 5], "nextCursor": "`+signedCursor("6")+`"}`,
+			call(server, `{"limit": 3, "cursor": "`+page.NextCursor+`"}`))
+		require.Equal(t, "Invalid Params", call(server, `{"cursor": "Mw"}`), "unsigned cursor")
+	})
+}
+
+// signedCursor returns the cursor of state signed with the key "secret"
+func signedCursor(state string) string {
+	cfg := pageConfig{key: []byte("secret")}
+	return cfg.encode(Cursor(state))
+}
@@ -0,0 +1,83 @@
+// redis shares the state of jsonrpc2 servers in Redis, e.g. the nonces of NonceMiddleware. It is imported as
+// jsonrpc2redis next to the Redis client in the examples
+package redis
+
+import (
+	"context"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"github/brianso/go-jsonrpc2"
+	"time"
+)
+
+// NewKVStore returns a jsonrpc2.KVStore keeping the entries in Redis, so the replicas of a server sharing client share
+// their state. The connections are pooled by client, see redis.Options.PoolSize, and the expiries are measured by
+// Redis. The errors of Redis are returned, the components choose to fail open or closed by their
+// jsonrpc2.StoreFailurePolicy.
+//	client := redis.NewClient(&redis.Options{Addr: "localhost:6379", PoolSize: 32})
+//	kv := jsonrpc2redis.NewKVStore(client)
+//	server.Use(jsonrpc2.NonceMiddleware(jsonrpc2.NewKVNonceStore(kv, "nonce:", jsonrpc2.FailOpen), time.Minute))
+func NewKVStore(client redis.UniversalClient) jsonrpc2.KVStore {
+	return &kvStore{client: client}
+}
+
+// ============ Private members below =================
+
+type kvStore struct {
+	client redis.UniversalClient
+}
+
+var (
+	// compareAndSwap sets KEYS[1] to ARGV[2] for ARGV[3] milliseconds, without expiry if 0, if its value is ARGV[1]
+	compareAndSwap = redis.NewScript(`
+if redis.call("GET", KEYS[1]) ~= ARGV[1] then
+	return 0
+end
+if ARGV[3] == "0" then
+	redis.call("SET", KEYS[1], ARGV[2])
+else
+	redis.call("SET", KEYS[1], ARGV[2], "PX", ARGV[3])
+end
+return 1
+`)
+	// incr adds ARGV[1] to KEYS[1], which expires after ARGV[2] milliseconds if it is created and ARGV[2] is not 0
+	incr = redis.NewScript(`
+local created = redis.call("EXISTS", KEYS[1]) == 0
+local n = redis.call("INCRBY", KEYS[1], ARGV[1])
+if created and ARGV[2] ~= "0" then
+	redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return n
+`)
+)
+
+func (s *kvStore) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, err := s.client.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+func (s *kvStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return s.client.Set(ctx, key, value, ttl).Err()
+}
+
+func (s *kvStore) CompareAndSwap(ctx context.Context, key string, old, value []byte, ttl time.Duration) (bool, error) {
+	if old == nil {
+		return s.client.SetNX(ctx, key, value, ttl).Result()
+	}
+	swapped, err := compareAndSwap.Run(ctx, s.client, []string{key}, old, value, ttl.Milliseconds()).Int()
+	return swapped == 1, err
+}
+
+func (s *kvStore) Incr(ctx context.Context, key string, delta int64, ttl time.Duration) (int64, error) {
+	return incr.Run(ctx, s.client, []string{key}, delta, ttl.Milliseconds()).Int64()
+}
+
+func (s *kvStore) Delete(ctx context.Context, key string) error {
+	return s.client.Del(ctx, key).Err()
+}
@@ -0,0 +1,92 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+	"github/brianso/go-jsonrpc2"
+	"testing"
+	"time"
+)
+
+func TestNewKVStore(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+	kv := NewKVStore(client)
+
+	_, ok, err := kv.Get(ctx, "a")
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.NoError(t, kv.Set(ctx, "a", []byte("1"), time.Minute))
+	value, ok, _ := kv.Get(ctx, "a")
+	require.True(t, ok)
+	require.Equal(t, []byte("1"), value)
+
+	// check-and-set
+	swapped, err := kv.CompareAndSwap(ctx, "a", nil, []byte("2"), 0)
+	require.NoError(t, err)
+	require.False(t, swapped, "a is set")
+	swapped, err = kv.CompareAndSwap(ctx, "a", []byte("0"), []byte("2"), 0)
+	require.NoError(t, err)
+	require.False(t, swapped)
+	swapped, err = kv.CompareAndSwap(ctx, "a", []byte("1"), []byte("2"), time.Minute)
+	require.NoError(t, err)
+	require.True(t, swapped)
+	swapped, _ = kv.CompareAndSwap(ctx, "b", nil, []byte("1"), 0)
+	require.True(t, swapped)
+
+	// counters keep their expiry
+	n, err := kv.Incr(ctx, "a", 3, time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, int64(5), n)
+	n, _ = kv.Incr(ctx, "c", -1, time.Hour)
+	require.Equal(t, int64(-1), n)
+	kv.Set(ctx, "d", []byte("x"), 0)
+	_, err = kv.Incr(ctx, "d", 1, 0)
+	require.Error(t, err)
+
+	mr.FastForward(time.Minute)
+	_, ok, _ = kv.Get(ctx, "a")
+	require.False(t, ok, "a is expired")
+	swapped, _ = kv.CompareAndSwap(ctx, "a", nil, []byte("1"), 0)
+	require.True(t, swapped)
+	n, _ = kv.Incr(ctx, "c", -1, time.Hour)
+	require.Equal(t, int64(-2), n)
+	_, ok, _ = kv.Get(ctx, "b")
+	require.True(t, ok, "b does not expire")
+
+	require.NoError(t, kv.Delete(ctx, "b"))
+	_, ok, _ = kv.Get(ctx, "b")
+	require.False(t, ok)
+}
+
+func TestNewKVStore_Nonces(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1})
+	defer client.Close()
+	kv := NewKVStore(client)
+	newServer := func(policy jsonrpc2.StoreFailurePolicy) jsonrpc2.Server {
+		server := jsonrpc2.NewServer()
+		server.Use(jsonrpc2.NonceMiddleware(jsonrpc2.NewKVNonceStore(kv, "nonce:", policy), time.Minute))
+		server.DefineMethod("transfer", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+			return "ok", nil
+		})
+		return server
+	}
+	// the replicas share the nonces
+	replicas := []jsonrpc2.Server{newServer(jsonrpc2.FailClosed), newServer(jsonrpc2.FailOpen)}
+	req := json.RawMessage(`{"jsonrpc": "2.0", "method": "transfer", "nonce": "n1", "id": 1}`)
+	require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "result": "ok"}`, string(replicas[0].ServeRequest(req)))
+	require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "error": {"code": -32016, "message": "Replay detected"}}`, string(replicas[1].ServeRequest(req)))
+	require.True(t, mr.Exists("nonce:n1"))
+
+	// Redis is down
+	mr.Close()
+	req = json.RawMessage(`{"jsonrpc": "2.0", "method": "transfer", "nonce": "n2", "id": 1}`)
+	require.Contains(t, string(replicas[0].ServeRequest(req)), `"error"`, "fail closed")
+	require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "result": "ok"}`, string(replicas[1].ServeRequest(req)), "fail open")
+}
@@ -0,0 +1,45 @@
+package jsonrpc2
+
+import "sort"
+
+func (s *server) UndefineMethod(method string) {
+	s.changeMethods(func() (added, removed []string) {
+		if _, ok := s.methods[method]; !ok {
+			return nil, nil
+		}
+		delete(s.methods, method)
+		delete(s.inits, method)
+		delete(s.adaptive, method)
+		return nil, []string{method}
+	})
+}
+
+// OnMethodsChanged calls fn after each change of the defined methods, with the sorted names of the methods added and
+// removed, e.g. to refresh a discovery cache. Redefining a method is not a change. A RegisterMethodSet is a single
+// change. The changes are serialized and fn is called before the next change, so Server.Methods called by fn returns
+// the methods after the change. fn must not define nor undefine methods.
+//	server.OnMethodsChanged(func(added, removed []string) {
+//		log.Printf("methods added: %v, removed: %v", added, removed)
+//	})
+func (s *server) OnMethodsChanged(fn func(added, removed []string)) {
+	s.changesMu.Lock()
+	defer s.changesMu.Unlock()
+	s.onMethodsChanged = fn
+}
+
+// ============ Private members below =================
+
+// changeMethods applies change to the methods, and reports its changes to OnMethodsChanged
+func (s *server) changeMethods(change func() (added, removed []string)) {
+	s.changesMu.Lock()
+	defer s.changesMu.Unlock()
+	s.methodsMu.Lock()
+	added, removed := change()
+	s.methodsMu.Unlock()
+	if s.onMethodsChanged == nil || len(added) == 0 && len(removed) == 0 {
+		return
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	s.onMethodsChanged(added, removed)
+}
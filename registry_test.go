@@ -0,0 +1,84 @@
+package jsonrpc2
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sort"
+	"sync"
+	"testing"
+)
+
+func TestServer_OnMethodsChanged(t *testing.T) {
+	h := func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		return nil, nil
+	}
+	type event struct {
+		added, removed []string
+	}
+	t.Run("events", func(t *testing.T) {
+		var events []event
+		server := NewServer()
+		server.OnMethodsChanged(func(added, removed []string) {
+			events = append(events, event{added, removed})
+		})
+		server.DefineMethod("echo", h)
+		server.DefineMethod("echo", h)
+		server.Namespace("math").RegisterMethodSet(NewMethodSet(map[string]Handler{"sub": h, "add": h}))
+		server.UndefineMethod("math.add")
+		server.UndefineMethod("unknown")
+		require.Equal(t, []event{
+			{added: []string{"echo"}},
+			{added: []string{"math.add", "math.sub"}},
+			{removed: []string{"math.add"}},
+		}, events)
+		require.Equal(t, []string{"echo", "math.sub"}, server.Methods())
+		rsp := server.ServeRequest(json.RawMessage(`{"jsonrpc": "2.0", "method": "math.add", "id": 1}`))
+		require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "error": {"code": -32601, "message": "Method not found"}}`, string(rsp))
+	})
+	t.Run("concurrent changes", func(t *testing.T) {
+		server := NewServer()
+		state := map[string]bool{}
+		server.OnMethodsChanged(func(added, removed []string) {
+			assert.False(t, len(added) > 0 && len(removed) > 0)
+			for _, m := range added {
+				assert.False(t, state[m])
+				state[m] = true
+			}
+			for _, m := range removed {
+				assert.True(t, state[m])
+				delete(state, m)
+			}
+			assert.Equal(t, methodNames(state), server.Methods(), "no torn reads")
+		})
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				for j := 0; j < 50; j++ {
+					method := fmt.Sprintf("m%d", j%5)
+					if (i+j)%2 == 0 {
+						server.DefineMethod(method, h)
+					} else {
+						server.UndefineMethod(method)
+					}
+					server.ServeRequest(json.RawMessage(`{"jsonrpc": "2.0", "method": "` + method + `", "id": 1}`))
+				}
+			}(i)
+		}
+		wg.Wait()
+		require.Equal(t, methodNames(state), server.Methods())
+	})
+}
+
+func methodNames(set map[string]bool) []string {
+	names := make([]string, 0, len(set))
+	for m := range set {
+		names = append(names, m)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,79 @@
+package jsonrpc2
+
+import (
+	"context"
+	"log/slog"
+	"runtime/debug"
+	"sync"
+)
+
+// OnRequestDone registers fn to run once the request of ctx is done: when its handler returns, even by a panic, or is
+// abandoned after its timeout, see ServerConfig.TimeoutGrace. The functions run once, in the reverse order of their
+// registration, e.g. to remove the temporary files of the handler or release its leases:
+//	f, err := os.CreateTemp("", "export")
+//	if err != nil { ... }
+//	jsonrpc2.OnRequestDone(ctx, func() { os.Remove(f.Name()) })
+// If the request is already done, fn runs at once. It returns false, without registering fn, if ctx is not the
+// context of a request.
+func OnRequestDone(ctx context.Context, fn func()) bool {
+	d, ok := ctx.Value(requestDoneContextKey{}).(*requestDone)
+	if !ok {
+		return false
+	}
+	d.mu.Lock()
+	if d.done {
+		d.mu.Unlock()
+		runCleanup(d.log, fn)
+		return true
+	}
+	d.fns = append(d.fns, fn)
+	d.mu.Unlock()
+	return true
+}
+
+// ============ Private members below =================
+
+type (
+	requestDoneContextKey struct{}
+
+	// requestDone holds the functions registered by OnRequestDone until the request is done
+	requestDone struct {
+		// log logs the panics of the functions
+		log  *slog.Logger
+		mu   sync.Mutex
+		done bool
+		fns  []func()
+	}
+)
+
+// withRequestDone returns ctx with the functions registered by OnRequestDone, run by the returned function once,
+// however many times it is called
+func withRequestDone(ctx context.Context) (context.Context, func()) {
+	d := &requestDone{log: LoggerFromContext(ctx)}
+	return context.WithValue(ctx, requestDoneContextKey{}, d), d.run
+}
+
+func (d *requestDone) run() {
+	d.mu.Lock()
+	if d.done {
+		d.mu.Unlock()
+		return
+	}
+	d.done = true
+	fns := d.fns
+	d.fns = nil
+	d.mu.Unlock()
+	for i := len(fns) - 1; i >= 0; i-- {
+		runCleanup(d.log, fns[i])
+	}
+}
+
+// runCleanup calls fn, a panic of fn is logged to log at ERROR level so the next functions still run
+func runCleanup(log *slog.Logger, fn func()) {
+	defer func() {
+		if v := recover(); v != nil {
+			log.Error("jsonrpc2: request done function panic", "panic", v, "stack", string(debug.Stack()))
+		}
+	}()
+	fn()
+}
@@ -0,0 +1,117 @@
+package jsonrpc2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestOnRequestDone(t *testing.T) {
+	var mu sync.Mutex
+	var calls []string
+	record := func(name string) func() {
+		return func() {
+			mu.Lock()
+			defer mu.Unlock()
+			calls = append(calls, name)
+		}
+	}
+	recorded := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		c := calls
+		calls = nil
+		return c
+	}
+	register := func(ctx context.Context) {
+		OnRequestDone(ctx, record("file"))
+		OnRequestDone(ctx, record("lease"))
+	}
+	clock := MockClock()
+	release := make(chan struct{})
+	registered := make(chan struct{})
+	server := NewServer(WithClock(clock), OnAbandon(func(h AbandonedHandler) {}))
+	server.DefineMethod("ok", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		register(ctx)
+		assert.Empty(t, recorded())
+		return "ok", nil
+	})
+	server.DefineMethod("fail", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		register(ctx)
+		return nil, errors.New("failed")
+	})
+	server.DefineMethod("panic", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		register(ctx)
+		panic("boom")
+	})
+	server.DefineMethod("ignoring", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		register(ctx)
+		close(registered)
+		<-release
+		return "too late", nil
+	})
+	call := func(method string) json.RawMessage {
+		return server.ServeRequest(json.RawMessage(`{"jsonrpc": "2.0", "method": "` + method + `", "id": 1}`))
+	}
+
+	for _, method := range []string{"ok", "fail", "panic"} {
+		t.Run(method, func(t *testing.T) {
+			call(method)
+			require.Equal(t, []string{"lease", "file"}, recorded())
+		})
+	}
+	t.Run("with timeout", func(t *testing.T) {
+		server.SetDefaultTimeout(time.Second)
+		defer server.SetDefaultTimeout(0)
+		call("ok")
+		require.Equal(t, []string{"lease", "file"}, recorded())
+	})
+	t.Run("abandoned", func(t *testing.T) {
+		server.SetDefaultTimeout(time.Second)
+		server.SetTimeoutGrace(100 * time.Millisecond)
+		defer server.SetDefaultTimeout(0)
+		defer server.SetTimeoutGrace(0)
+		rsp := make(chan json.RawMessage)
+		go func() {
+			rsp <- call("ignoring")
+		}()
+		<-registered
+		clock.BlockUntil(1)
+		clock.Advance(time.Second)
+		clock.BlockUntil(1) // grace timer
+		clock.Advance(100 * time.Millisecond)
+		<-rsp
+		require.Equal(t, []string{"lease", "file"}, recorded())
+		close(release)
+		server.Wait()
+		require.Empty(t, recorded())
+	})
+	t.Run("registered after done", func(t *testing.T) {
+		leaked := make(chan context.Context, 1)
+		server.DefineMethod("leak", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+			leaked <- ctx
+			return nil, nil
+		})
+		call("leak")
+		require.True(t, OnRequestDone(<-leaked, record("late")))
+		require.Equal(t, []string{"late"}, recorded())
+	})
+	t.Run("panicking function", func(t *testing.T) {
+		server.DefineMethod("cleanupPanic", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+			OnRequestDone(ctx, record("file"))
+			OnRequestDone(ctx, func() { panic("boom") })
+			return nil, nil
+		})
+		call("cleanupPanic")
+		require.Equal(t, []string{"file"}, recorded())
+	})
+	t.Run("outside a request", func(t *testing.T) {
+		require.False(t, OnRequestDone(context.Background(), record("never")))
+		require.Empty(t, recorded())
+	})
+}
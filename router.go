@@ -0,0 +1,84 @@
+package jsonrpc2
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// MethodRouter is a Server forwarding the requests of mounted prefixes to other servers, without their prefix.
+// The other requests are served by the router itself, as by NewServer.
+// Usage:
+//	math := jsonrpc2.NewServer()
+//	math.DefineMethod("add", add)
+//	router := jsonrpc2.NewMethodRouter()
+//	router.Mount("math", math) // "math.add" is served by math as "add"
+//	router.DefineMethod("version", version)
+type MethodRouter struct {
+	Server
+	mounts map[string]Requester
+}
+
+// NewMethodRouter returns a router without mounts, serving all requests by its own methods.
+func NewMethodRouter(opts ...ServerOption) *MethodRouter {
+	return &MethodRouter{Server: NewServer(opts...), mounts: map[string]Requester{}}
+}
+
+// Mount forwards the requests of the methods "<prefix>.<method>" to s as "<method>". When mounts are nested, e.g.
+// "a" and "a.b", the longest prefix is used. Mounts take precedence over the methods of the router.
+// It must not be called while serving requests.
+func (r *MethodRouter) Mount(prefix string, s Requester) {
+	r.mounts[prefix] = s
+}
+
+// ServeRequest serves the requests, and the elements of the batch requests, by the mounted server of their method or
+// by the router. The batches are handled by the router as by a server: ServerConfig.MaxBatchResponseBytes,
+// WithBatchCoalescing, OnBatchComplete and OnBatchProgress of the router apply to the whole batch, while each mounted
+// server only serves the elements of its methods as single requests.
+func (r *MethodRouter) ServeRequest(jsonString json.RawMessage) json.RawMessage {
+	return r.Server.(*server).serveRequest(jsonString, r.serveElement)
+}
+
+// ============ Private members below =================
+
+// defineMethod lets DefineMethodConfig define the methods of the router
+func (r *MethodRouter) defineMethod(cfg MethodConfig) {
+	r.Server.(*server).defineMethod(cfg)
+}
+
+// describeNotification lets DescribeNotification describe the notifications of the router
+func (r *MethodRouter) describeNotification(d NotificationDescription) {
+	r.Server.(*server).describeNotification(d)
+}
+
+// serveElement serves a request which is not a batch, by its mounted server or by the router
+func (r *MethodRouter) serveElement(raw json.RawMessage, batchIndex int) json.RawMessage {
+	s, req := r.route(raw)
+	if s == r.Server {
+		return r.Server.(*server).serveSingleRequest(raw, batchIndex)
+	}
+	return s.ServeRequest(req)
+}
+
+// route returns the server of the request raw, and raw with the method the server knows
+func (r *MethodRouter) route(raw json.RawMessage) (Requester, json.RawMessage) {
+	var req map[string]json.RawMessage
+	if len(r.mounts) == 0 || json.Unmarshal(raw, &req) != nil {
+		return r.Server, raw
+	}
+	var method string
+	if json.Unmarshal(req["method"], &method) != nil {
+		return r.Server, raw
+	}
+	for prefix := method; ; {
+		i := strings.LastIndexByte(prefix, '.')
+		if i < 0 {
+			return r.Server, raw
+		}
+		prefix = prefix[:i]
+		if s, ok := r.mounts[prefix]; ok {
+			req["method"], _ = json.Marshal(method[i+1:])
+			b, _ := json.Marshal(req)
+			return s, b
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package jsonrpc2
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestMethodRouter(t *testing.T) {
+	name := func(name string) Handler {
+		return func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+			return name, nil
+		}
+	}
+	math := NewServer()
+	math.DefineMethod("add", name("math add"))
+	nested := NewServer()
+	nested.DefineMethod("c", name("a.b c"))
+	// a mount only needs to serve requests
+	legacy := RequesterFunc(func(jsonString json.RawMessage) json.RawMessage {
+		return json.RawMessage(`{"id": 1, "jsonrpc": "2.0", "result": "legacy"}`)
+	})
+	a := NewServer()
+	a.DefineMethod("b.c", name("a b.c"))
+	a.DefineMethod("x", name("a x"))
+	router := NewMethodRouter()
+	router.DefineMethod("version", name("router version"))
+	router.DefineMethod("math.sub", name("router math.sub"))
+	router.Mount("math", math)
+	router.Mount("a", a)
+	router.Mount("a.b", nested)
+	router.Mount("legacy", legacy)
+	DefineMethodConfig(router, MethodConfig{Name: "status", Handler: name("router status")})
+
+	t.Run("single", func(t *testing.T) {
+		for method, expected := range map[string]string{
+			"math.add": `{"id": 1, "jsonrpc": "2.0", "result": "math add"}`,
+			"a.b.c":    `{"id": 1, "jsonrpc": "2.0", "result": "a.b c"}`,
+			"a.x":      `{"id": 1, "jsonrpc": "2.0", "result": "a x"}`,
+			"version":  `{"id": 1, "jsonrpc": "2.0", "result": "router version"}`,
+			"status":   `{"id": 1, "jsonrpc": "2.0", "result": "router status"}`,
+			"legacy.x": `{"id": 1, "jsonrpc": "2.0", "result": "legacy"}`,
+			"math.sub": `{"id": 1, "jsonrpc": "2.0", "error": {"code": -32601, "message": "Method not found"}}`,
+		} {
+			rsp := router.ServeRequest(json.RawMessage(`{"jsonrpc": "2.0", "method": "` + method + `", "id": 1}`))
+			require.JSONEq(t, expected, string(rsp), method)
+		}
+	})
+	t.Run("batch", func(t *testing.T) {
+		rsp := router.ServeRequest(json.RawMessage(`[
+			{"jsonrpc": "2.0", "method": "math.add", "id": 1},
+			{"jsonrpc": "2.0", "method": "version", "id": 2},
+			{"jsonrpc": "2.0", "method": "a.x"},
+			{"foo": "boo"}
+		]`))
+		require.JSONEq(t, `[
+			{"id": 1, "jsonrpc": "2.0", "result": "math add"},
+			{"id": 2, "jsonrpc": "2.0", "result": "router version"},
+			{"id": null, "jsonrpc": "2.0", "error": {"code": -32600, "message": "Invalid request"}}
+		]`, string(rsp))
+		require.Nil(t, router.ServeRequest(json.RawMessage(`[{"jsonrpc": "2.0", "method": "a.x"}]`)))
+	})
+	t.Run("invalid", func(t *testing.T) {
+		rsp := router.ServeRequest(json.RawMessage(`{"jsonrpc": "2.0", "method": 1, "id": 1}`))
+		require.JSONEq(t, `{"id": null, "jsonrpc": "2.0", "error": {"code": -32700, "message": "Parse error"}}`, string(rsp))
+		rsp = router.ServeRequest(json.RawMessage(`[]`))
+		require.JSONEq(t, `{"id": null, "jsonrpc": "2.0", "error": {"code": -32600, "message": "Invalid request"}}`, string(rsp))
+	})
+	t.Run("batch handling of the router", func(t *testing.T) {
+		var summaries []BatchSummary
+		router := NewMethodRouter(WithBatchCoalescing(), OnBatchComplete(func(b BatchSummary) {
+			summaries = append(summaries, b)
+		}))
+		calls := 0
+		counted := NewServer()
+		counted.DefineMethod("get", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+			calls++
+			return "counted", nil
+		})
+		router.Mount("counted", counted)
+		rsp := router.ServeRequest(json.RawMessage(`[
+			{"jsonrpc": "2.0", "method": "counted.get", "id": 1},
+			{"jsonrpc": "2.0", "method": "counted.get", "id": 2}
+		]`))
+		require.JSONEq(t, `[
+			{"id": 1, "jsonrpc": "2.0", "result": "counted"},
+			{"id": 2, "jsonrpc": "2.0", "result": "counted"}
+		]`, string(rsp))
+		require.Equal(t, 1, calls, "the identical elements are coalesced")
+		require.Len(t, summaries, 1)
+		require.Equal(t, 2, summaries[0].Successes)
+
+		router.SetMaxBatchResponseBytes(10)
+		rsp = router.ServeRequest(json.RawMessage(`[{"jsonrpc": "2.0", "method": "counted.get", "id": 1}]`))
+		require.Contains(t, string(rsp), `"code":-32018`)
+
+		router.SetValidateUTF8(true)
+		rsp = router.ServeRequest(json.RawMessage("[{\"jsonrpc\": \"2.0\", \"method\": \"counted.\xff\", \"id\": 1}]"))
+		require.Contains(t, string(rsp), `"code":-32700`)
+	})
+}
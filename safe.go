@@ -0,0 +1,46 @@
+package jsonrpc2
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"runtime/debug"
+)
+
+// SafeHandler wraps h so its errors which are not jsonrpc2.Error, e.g. database errors revealing queries, are replaced by
+// sanitize(err). As any Handler, it can be wrapped by middlewares.
+//	server.DefineMethod("users.get", jsonrpc2.SafeHandler(getUser, jsonrpc2.DefaultSanitizer()))
+func SafeHandler(h Handler, sanitize func(error) Error) Handler {
+	return func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		result, err := h(ctx, params)
+		if err == nil {
+			return result, nil
+		}
+		if _, ok := CodeOf(err); ok {
+			return result, err
+		}
+		return nil, sanitize(err)
+	}
+}
+
+// DefaultSanitizer logs the errors to slog at ERROR level and replaces them by ErrInternalServerError.
+func DefaultSanitizer() func(error) Error {
+	return func(err error) Error {
+		slog.Error("jsonrpc2: handler error", "error", err)
+		return ErrInternalServerError
+	}
+}
+
+// ============ Private members below =================
+
+// callSafely calls h, a panic of h is logged at ERROR level by the logger of the request and returned as ErrInternalServerError,
+// so it only fails its own request, not the server nor the other requests of its batch
+func callSafely(ctx context.Context, h Handler, params json.RawMessage) (result interface{}, err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			LoggerFromContext(ctx).Error("jsonrpc2: handler panic", "panic", v, "stack", string(debug.Stack()))
+			result, err = nil, ErrInternalServerError
+		}
+	}()
+	return h(ctx, params)
+}
@@ -0,0 +1,66 @@
+package jsonrpc2
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/require"
+	"log/slog"
+	"testing"
+)
+
+func TestSafeHandler(t *testing.T) {
+	var logs bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+
+	server := NewServer()
+	server.DefineMethod("query", SafeHandler(func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		var fail string
+		json.Unmarshal(params, &fail)
+		switch fail {
+		case "sql":
+			return nil, errors.New("pq: syntax error at SELECT * FROM users")
+		case "rpc":
+			return nil, ErrInvalidParams
+		}
+		return "ok", nil
+	}, DefaultSanitizer()))
+	t.Run("result", func(t *testing.T) {
+		rsp := server.ServeRequest(json.RawMessage(`{ "jsonrpc": "2.0", "method": "query", "id": 1 }`))
+		require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "result": "ok"}`, string(rsp))
+	})
+	t.Run("sanitized error", func(t *testing.T) {
+		logs.Reset()
+		rsp := server.ServeRequest(json.RawMessage(`{ "jsonrpc": "2.0", "method": "query", "params": "sql", "id": 1 }`))
+		require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "error": {"code": -32603, "message": "Internal server error"}}`, string(rsp))
+		require.Contains(t, logs.String(), "level=ERROR")
+		require.Contains(t, logs.String(), "SELECT * FROM users")
+	})
+	t.Run("rpc error is kept", func(t *testing.T) {
+		logs.Reset()
+		rsp := server.ServeRequest(json.RawMessage(`{ "jsonrpc": "2.0", "method": "query", "params": "rpc", "id": 1 }`))
+		require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "error": {"code": -32602, "message": "Invalid Params"}}`, string(rsp))
+		require.Empty(t, logs.String())
+	})
+	t.Run("custom sanitizer inside middleware", func(t *testing.T) {
+		var calls []string
+		server := NewServer()
+		server.Use(func(next Handler) Handler {
+			return func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+				result, err := next(ctx, params)
+				calls = append(calls, err.Error())
+				return result, err
+			}
+		})
+		server.DefineMethod("fail", SafeHandler(func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+			return nil, errors.New("open /etc/secret: permission denied")
+		}, func(err error) Error {
+			return NewError(-32001, "Unavailable")
+		}))
+		rsp := server.ServeRequest(json.RawMessage(`{ "jsonrpc": "2.0", "method": "fail", "id": 1 }`))
+		require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "error": {"code": -32001, "message": "Unavailable"}}`, string(rsp))
+		require.Equal(t, []string{"Unavailable"}, calls)
+	})
+}
@@ -0,0 +1,163 @@
+package jsonrpc2
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// SetValidateResponses validates the results of the methods with a MethodConfig.ResultSchema, see
+// ServerConfig.ValidateResponses.
+func (s *server) SetValidateResponses(enabled bool) {
+	s.updateConfig(func(cfg *ServerConfig) {
+		cfg.ValidateResponses = enabled
+	})
+}
+
+// ============ Private members below =================
+
+// validateResult returns ErrInternalServerError with the validation detail as data if result does not match
+// the result schema of m. The mismatches are logged at ERROR level by the logger of the request.
+func validateResult(ctx context.Context, m MethodConfig, result interface{}) error {
+	if len(m.ResultSchema) == 0 {
+		return nil
+	}
+	b, err := json.Marshal(result)
+	if err == nil {
+		err = validateSchema(m.ResultSchema, b)
+	}
+	if err == nil {
+		return nil
+	}
+	LoggerFromContext(ctx).Error("jsonrpc2: invalid result", "error", err)
+	return NewErrorWithData(CodeInternalError, ErrInternalServerError.Error(), map[string]string{
+		"validation": err.Error(),
+	})
+}
+
+// validateSchema checks data against a JSON schema. It supports the keywords type, enum, properties, required,
+// additionalProperties and items, the other keywords are ignored.
+func validateSchema(schema, data json.RawMessage) error {
+	var s jsonSchema
+	if err := json.Unmarshal(schema, &s); err != nil {
+		return fmt.Errorf("invalid schema: %w", err)
+	}
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	var v interface{}
+	if err := d.Decode(&v); err != nil {
+		return err
+	}
+	return s.validate("$", v)
+}
+
+type jsonSchema struct {
+	Type                 schemaType             `json:"type"`
+	Enum                 []interface{}          `json:"enum"`
+	Properties           map[string]*jsonSchema `json:"properties"`
+	Required             []string               `json:"required"`
+	AdditionalProperties *bool                  `json:"additionalProperties"`
+	Items                *jsonSchema            `json:"items"`
+}
+
+// schemaType is the "type" keyword, a type or a list of types
+type schemaType []string
+
+func (t *schemaType) UnmarshalJSON(b []byte) error {
+	var one string
+	if err := json.Unmarshal(b, &one); err == nil {
+		*t = schemaType{one}
+		return nil
+	}
+	return json.Unmarshal(b, (*[]string)(t))
+}
+
+func (s *jsonSchema) validate(path string, v interface{}) error {
+	if len(s.Type) > 0 && !s.Type.matches(v) {
+		return fmt.Errorf("%s: expected %s, got %s", path, strings.Join(s.Type, " or "), jsonType(v))
+	}
+	if len(s.Enum) > 0 && !inEnum(s.Enum, v) {
+		return fmt.Errorf("%s: not in enum", path)
+	}
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				return fmt.Errorf("%s: missing property %q", path, name)
+			}
+		}
+		for name, value := range v {
+			p, ok := s.Properties[name]
+			if !ok {
+				if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+					return fmt.Errorf("%s: unexpected property %q", path, name)
+				}
+				continue
+			}
+			if err := p.validate(path+"."+name, value); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		if s.Items == nil {
+			return nil
+		}
+		for i, item := range v {
+			if err := s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (t schemaType) matches(v interface{}) bool {
+	actual := jsonType(v)
+	for _, expected := range t {
+		if expected == actual || expected == "number" && actual == "integer" {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonType returns the JSON schema type of a value decoded with json.Decoder.UseNumber
+func jsonType(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+func inEnum(enum []interface{}, v interface{}) bool {
+	for _, e := range enum {
+		if n, ok := v.(json.Number); ok {
+			if f, ok := e.(float64); ok {
+				if nf, err := n.Float64(); err == nil && nf == f {
+					return true
+				}
+			}
+			continue
+		}
+		if reflect.DeepEqual(e, v) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+package jsonrpc2
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestServer_SetValidateResponses(t *testing.T) {
+	type user struct {
+		ID   interface{} `json:"id"`
+		Name string      `json:"name"`
+	}
+	schema := json.RawMessage(`{
+		"type": "object",
+		"required": ["id", "name"],
+		"properties": {"id": {"type": "integer"}, "name": {"type": "string"}}
+	}`)
+	server := NewServer()
+	DefineMethodWithSchema(server, "users.get", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		var id interface{}
+		json.Unmarshal(params, &id)
+		return user{ID: id, Name: "Brian"}, nil
+	}, nil, schema)
+	valid := json.RawMessage(`{ "jsonrpc": "2.0", "method": "users.get", "params": 1, "id": 1 }`)
+	invalid := json.RawMessage(`{ "jsonrpc": "2.0", "method": "users.get", "params": "1", "id": 1 }`)
+
+	require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "result": {"id": "1", "name": "Brian"}}`, string(server.ServeRequest(invalid)),
+		"disabled by default")
+	server.SetValidateResponses(true)
+	require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "result": {"id": 1, "name": "Brian"}}`, string(server.ServeRequest(valid)))
+	require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "error": {
+		"code": -32603,
+		"message": "Internal server error",
+		"data": {"validation": "$.id: expected integer, got string"}
+	}}`, string(server.ServeRequest(invalid)))
+}
+
+func TestValidateSchema(t *testing.T) {
+	schema := json.RawMessage(`{
+		"type": "object",
+		"required": ["items"],
+		"additionalProperties": false,
+		"properties": {
+			"items": {"type": "array", "items": {"type": ["number", "null"]}},
+			"status": {"enum": ["open", "closed", 1]}
+		}
+	}`)
+	for data, expected := range map[string]string{
+		`{"items": [1, 2.5, null], "status": "open"}`: "",
+		`{"items": [], "status": 1}`:                  "",
+		`[]`:                                          "$: expected object, got array",
+		`{}`:                                          `$: missing property "items"`,
+		`{"items": [1, "2"]}`:                         "$.items[1]: expected number or null, got string",
+		`{"items": [], "status": "lost"}`:             "$.status: not in enum",
+		`{"items": [], "other": 1}`:                   `$: unexpected property "other"`,
+	} {
+		err := validateSchema(schema, json.RawMessage(data))
+		if expected == "" {
+			require.NoError(t, err, data)
+		} else {
+			require.EqualError(t, err, expected, data)
+		}
+	}
+}
@@ -2,9 +2,14 @@
 package jsonrpc2
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -19,38 +24,249 @@ type (
 	// rsp, err := server.ServeRequest(`{ "jsonrpc": "2.0", "method": "echo", "params": "hi", "id": 1 }`)
 	// // rsp is a json string in jsonrpc2.0 format
 	// // send your rsp through your transport (e.g. http)
+	//
+	// The helpers only require the part of the Server they use, so they accept mocks and wrappers:
+	//	- Requester: NewLongPollHandler, ServeStdioMultiplex, WithShadow, MethodRouter.Mount, jsonrpc2test.Golden
+	//	  and the jsonrpc2test benchmarks
+	//	- Introspector: jsonrpc2test.RequireMethods
+	//	- Notifier: NotificationBus.Register
 	Server interface{
+		Requester
+		Registrar
+		Introspector
 		SetDefaultTimeout(timeout time.Duration)
-		DefineMethod(method string, h Handler)
+		// Responses larger than the limits are replaced by ErrResponseTooLarge. 0 means no limit.
+		SetMaxResponseBytes(n int)
+		SetMaxBatchResponseBytes(n int)
+		// SetStreamingBatchResponses streams the responses of the batch elements over connections, see
+		// ServerConfig.StreamingBatchResponses.
+		SetStreamingBatchResponses(enabled bool)
+		// SetValidateUTF8 rejects the requests with invalid UTF-8, see ServerConfig.ValidateUTF8.
+		SetValidateUTF8(enabled bool)
+		// SetServerTiming adds the "serverTiming" extension member to the responses, see ServerConfig.ServerTiming.
+		SetServerTiming(enabled bool)
+		// SetValidateResponses checks the results against their schema, see ServerConfig.ValidateResponses.
+		SetValidateResponses(enabled bool)
+		// SetSlowThreshold reports the requests handled in more than d, see OnSlowRequest.
+		SetSlowThreshold(d time.Duration)
+		// SetMinQueueBudget rejects the queued requests left with less than d before their timeout, see
+		// ServerConfig.MinQueueBudget.
+		SetMinQueueBudget(d time.Duration)
+		// SetLogNotificationErrors logs the suppressed error responses of the notifications, see
+		// ServerConfig.LogNotificationErrors.
+		SetLogNotificationErrors(enabled bool)
+		// SetTimeoutGrace lets the handlers return after their timeout before they are abandoned, see
+		// ServerConfig.TimeoutGrace.
+		SetTimeoutGrace(d time.Duration)
+		// SetClock replaces the real time used for timeouts, e.g. by a fake clock in tests.
+		SetClock(c Clock)
+		SetErrorCatalog(catalog ErrorCatalog, localeFromContext func(ctx context.Context) string)
+		// OnMethodsChanged calls fn after the methods are defined or undefined, see its doc.
+		OnMethodsChanged(fn func(added, removed []string))
+		Use(mw ...Middleware)
+		// RegisterCapability adds a capability negotiated by the built-in "rpc.initialize" method.
+		RegisterCapability(cap Capability)
+		// WarmUp runs the initializers of methods, or of all methods if none given, and waits for them to finish.
+		WarmUp(ctx context.Context, methods ...string) error
+		// RetryInit lets the next call of a method whose initializer failed run the initializer again.
+		RetryInit(method string)
+		// ApplyConfig validates and applies cfg at once, while serving requests.
+		ApplyConfig(cfg ServerConfig) error
+		// Config returns the current configuration.
+		Config() ServerConfig
+		// Close cancels the contexts of all requests in flight and stops the threads of the methods with
+		// MethodConfig.LockOSThread. Requests served after Close respond ErrShuttingDown.
+		Close()
+		// Wait blocks until all handlers have returned.
+		Wait()
+		// Stats returns the stats of the methods called, by method, see MethodStats.
+		Stats() map[string]MethodStats
+		// Reset returns the server to its state after NewServer, e.g. between tests sharing a server.
+		// It must not be called while serving requests.
+		Reset()
+	}
+
+	// A Requester serves jsonrpc 2.0 json string requests, e.g. a Server. It is all a transport needs.
+	Requester interface {
 		ServeRequest(jsonString json.RawMessage) json.RawMessage
 	}
 
+	// RequesterFunc is a function serving requests, e.g. a mock transport in tests.
+	RequesterFunc func(jsonString json.RawMessage) json.RawMessage
+
+	// A Registrar defines the methods of a server, e.g. the Register functions of the packages composing a server.
+	// The methods are checked at registration, which panics on:
+	//	- a nil handler or an empty name
+	//	- a name rejected by the method validator, DefaultMethodValidator unless set by WithMethodValidator
+	//	- a name starting with "rpc.", reserved by the specification for the built-in methods, unless AllowReserved
+	Registrar interface {
+		DefineMethod(method string, h Handler)
+		// UndefineMethod removes a method, the requests being handled are not affected.
+		UndefineMethod(method string)
+		RegisterMethodSet(ms MethodSet)
+		Namespace(name string) Namespace
+	}
+
+	// An Introspector tells the methods of a server, e.g. for tooling.
+	Introspector interface {
+		MethodExists(method string) bool
+		// Methods returns the names of the defined methods, sorted.
+		Methods() []string
+		// VerifyMethods returns an error listing the differences between the defined methods and manifest,
+		// see VerifyManifest.
+		VerifyMethods(manifest []string) error
+		// ExportManifest returns the methods with their options and the configuration as JSON, see DiffManifests.
+		ExportManifest() ([]byte, error)
+	}
+
+	// A Notifier pushes notifications to clients, e.g. a client Connection or a NotificationBus.
+	Notifier interface {
+		Notify(ctx context.Context, method string, params interface{}) error
+	}
 
 	// The handler of your server methods. If error returned is jsonrpc2.Error, the code will be used.
 	Handler func(ctx context.Context, params json.RawMessage) (result interface{}, error error)
+
+	// Middleware wraps a Handler. Middlewares are applied in the order they are added, the first one being the outermost.
+	//	server.Use(func(next jsonrpc2.Handler) jsonrpc2.Handler {
+	//		return func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+	//			// before handler
+	//			return next(ctx, params)
+	//		}
+	//	})
+	Middleware func(next Handler) Handler
+
+	// ServerOption configures the server created by NewServer.
+	ServerOption func(s *server)
 )
 
-func NewServer() Server {
-	return &server{
-		handlers: map[string]Handler{},
-		timeout:  0,
+func (f RequesterFunc) ServeRequest(jsonString json.RawMessage) json.RawMessage {
+	return f(jsonString)
+}
+
+func NewServer(opts ...ServerOption) Server {
+	s := &server{opts: opts}
+	s.Reset()
+	return s
+}
+
+// WithProtocolVersion sets the "jsonrpc" version accepted in requests and used in responses, "2.0" by default.
+// It allows experimental extensions of the protocol, e.g. "2.1".
+func WithProtocolVersion(version string) ServerOption {
+	return func(s *server) {
+		s.version = version
+	}
+}
+
+// WithBaseContext sets the context all request contexts derive from, context.Background() by default.
+// Cancelling ctx has the same effect as Server.Close.
+func WithBaseContext(ctx context.Context) ServerOption {
+	return func(s *server) {
+		s.base = ctx
 	}
 }
 
+// WithParseErrorDetail adds the position and cause of the parse errors to their response, e.g.
+//	{"code": -32700, "message": "Parse error", "data": {"offset": 12, "detail": "unexpected end of JSON input"}}
+// The offset is in bytes from the start of the request, or of the batch element.
+func WithParseErrorDetail() ServerOption {
+	return func(s *server) {
+		s.parseErrorDetail = true
+	}
+}
+
+// RawRequestFromContext returns the raw json of the request served with ctx, in a batch the json of its element,
+// e.g. to verify a signature. It refers to the bytes given to ServeRequest and is only valid until the handler returns.
+func RawRequestFromContext(ctx context.Context) json.RawMessage {
+	raw, _ := ctx.Value(requestContextKey{}).(json.RawMessage)
+	return raw
+}
+
 // ============ Private members below =================
 
 type (
 	server struct {
-		handlers map[string]Handler
-		timeout  time.Duration
+		// opts are the options of NewServer, applied again by Reset
+		opts       []ServerOption
+		methods    map[string]MethodConfig
+		inits      map[string]*initializer
+		adaptive   map[string]*adaptiveTimeout
+		version    string
+		middleware []Middleware
+		// capabilities are negotiated by "rpc.initialize", guarded by methodsMu
+		capabilities []Capability
+		clock        Clock
+		// coalesce executes the identical elements of a batch once
+		coalesce bool
+		// catalog translates the error messages to the locale of the request, see SetErrorCatalog
+		catalog           ErrorCatalog
+		localeFromContext func(ctx context.Context) string
+		// parseErrorDetail adds the cause of the parse errors to their data, see WithParseErrorDetail
+		parseErrorDetail bool
+		// methodsMu guards methods, inits, adaptive and capabilities, which can change while serving requests
+		methodsMu sync.RWMutex
+		// changesMu serializes the changes of methods and their OnMethodsChanged events
+		changesMu        sync.Mutex
+		onMethodsChanged func(added, removed []string)
+		// onAbandon is called with the handlers abandoned after a timeout, see OnAbandon
+		onAbandon func(AbandonedHandler)
+		// onSlowRequest is called with the slow requests, see OnSlowRequest
+		onSlowRequest func(SlowRequest)
+		// logger is the base of the loggers of the requests, see WithLogger
+		logger *slog.Logger
+		// onError is called with the requests responded with an error, see OnError
+		onError func(FailedRequest)
+		// timeoutHint caps the timeouts at the hint of the requests, see WithTimeoutHint
+		timeoutHint bool
+		// onBatchComplete is called with the summary of each batch, see OnBatchComplete
+		onBatchComplete func(BatchSummary)
+		// onBatchProgress is called with the progress of the batches, see OnBatchProgress
+		onBatchProgress *batchProgressHook
+		// metrics are published by WithExpvarMetrics, nil if not
+		metrics *expvarMetrics
+		// concurrency holds the semaphores of the methods with a concurrency limit
+		concurrency map[string]chan struct{}
+		// base is the parent context of root, the context all requests derive from
+		base    context.Context
+		root    context.Context
+		cancel  func()
+		running sync.WaitGroup
+		// config holds the current *ServerConfig, replaced as a whole by configMu holders
+		config   atomic.Value
+		configMu sync.Mutex
+		// validateMethod checks the method names at registration
+		validateMethod func(method string) error
+		// allowReserved lets the "rpc." methods be defined, see AllowReserved
+		allowReserved bool
+		transformers  []ResponseTransformer
+		// pinned are the workers of the methods running on a dedicated thread, see MethodConfig.LockOSThread
+		pinnedMu sync.Mutex
+		pinned   map[string]*pinnedWorker
+		// notifications are described by DescribeNotification, guarded by methodsMu
+		notifications map[string]NotificationDescription
+		// migrations are the params migrations by method, see WithParamsMigration
+		migrations map[string][]ParamsMigration
+		// onAccounting is called with the sizes of the requests, see OnAccounting
+		onAccounting func(Accounting)
+		// stats are the stats of the methods called, by method, see Stats
+		statsMu sync.Mutex
+		stats   map[string]*methodStats
 	}
 
+	// requestContextKey is the context key of the raw json of the request being served.
+	requestContextKey struct{}
+
+	// serveFunc serves a request which is not a batch, batchIndex is its index in the batch request or -1
+	serveFunc func(jsonString json.RawMessage, batchIndex int) json.RawMessage
+
 	// A request represents a JSON-RPC request received by the server.
 	request struct {
 		ID      json.RawMessage `json:"id"`
 		Version string          `json:"jsonrpc"`
 		Method  string          `json:"method"`
 		Params  json.RawMessage `json:"params"`
+		// TimeoutMs is the remaining time of the client, see WithTimeoutHint
+		TimeoutMs json.RawMessage `json:"timeoutMs,omitempty"`
 	}
 
 	// A response represents a JSON-RPC Resp returned by the server.
@@ -59,62 +275,309 @@ type (
 		Version string          `json:"jsonrpc"`
 		Result  interface{}     `json:"result,omitempty"`
 		Error   Error          `json:"error,omitempty"`
+		// ServerTiming is an extension member, only set if enabled
+		ServerTiming *serverTiming `json:"serverTiming,omitempty"`
+	}
+
+	// serverTiming tells the client where the time of a request was spent on the server
+	serverTiming struct {
+		// QueueMs is the time from the reception of the request to the call of its handler, e.g. initializing the method
+		QueueMs float64 `json:"queueMs"`
+		// HandlerMs is the time spent in the handler and the middlewares
+		HandlerMs float64 `json:"handlerMs"`
 	}
 )
 
+func (s *server) SetServerTiming(enabled bool) {
+	s.updateConfig(func(cfg *ServerConfig) {
+		cfg.ServerTiming = enabled
+	})
+}
+
 func (s *server) SetDefaultTimeout(timeout time.Duration) {
-	s.timeout = timeout
+	s.updateConfig(func(cfg *ServerConfig) {
+		cfg.DefaultTimeout = timeout
+	})
+}
+
+func (s *server) SetMaxResponseBytes(n int) {
+	s.updateConfig(func(cfg *ServerConfig) {
+		cfg.MaxResponseBytes = n
+	})
+}
+
+func (s *server) SetMaxBatchResponseBytes(n int) {
+	s.updateConfig(func(cfg *ServerConfig) {
+		cfg.MaxBatchResponseBytes = n
+	})
+}
+
+func (s *server) Reset() {
+	s.methods = map[string]MethodConfig{}
+	s.inits = map[string]*initializer{}
+	s.adaptive = map[string]*adaptiveTimeout{}
+	s.version = "2.0"
+	s.middleware = nil
+	s.capabilities = nil
+	s.clock = realClock{}
+	s.concurrency = map[string]chan struct{}{}
+	s.coalesce = false
+	s.catalog = nil
+	s.localeFromContext = nil
+	s.metrics = nil
+	s.onBatchComplete = nil
+	s.onBatchProgress = nil
+	s.parseErrorDetail = false
+	s.timeoutHint = false
+	s.onSlowRequest = nil
+	s.onError = nil
+	s.logger = nil
+	s.onAbandon = nil
+	s.onMethodsChanged = nil
+	s.validateMethod = DefaultMethodValidator()
+	s.allowReserved = false
+	s.transformers = nil
+	s.migrations = map[string][]ParamsMigration{}
+	s.notifications = map[string]NotificationDescription{}
+	s.onAccounting = nil
+	s.stats = map[string]*methodStats{}
+	s.stopPinnedWorkers()
+	s.config.Store(&ServerConfig{})
+	s.base = context.Background()
+	for _, opt := range s.opts {
+		opt(s)
+	}
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.root, s.cancel = context.WithCancel(s.base)
+}
+
+func (s *server) Close() {
+	s.cancel()
+	s.stopPinnedWorkers()
+}
+
+func (s *server) Wait() {
+	s.running.Wait()
 }
 
 func (s *server) DefineMethod(method string, h Handler) {
-	s.handlers[method] = h
+	s.defineMethod(MethodConfig{Name: method, Handler: h})
+}
+
+func (s *server) MethodExists(method string) bool {
+	s.methodsMu.RLock()
+	defer s.methodsMu.RUnlock()
+	_, ok := s.methods[method]
+	return ok
+}
+
+func (s *server) defineMethod(cfg MethodConfig) {
+	s.defineMethods([]MethodConfig{cfg})
+}
+
+// defineBuiltins defines the methods of the package, which may have reserved names
+func (s *server) defineBuiltins(methods ...MethodConfig) {
+	for i := range methods {
+		methods[i].builtin = true
+	}
+	s.defineMethods(methods)
+}
+
+// defineMethods defines methods at once, with a single OnMethodsChanged event.
+// It panics before defining any method if a name is invalid.
+func (s *server) defineMethods(methods []MethodConfig) {
+	for _, cfg := range methods {
+		if err := s.checkMethod(cfg); err != nil {
+			panic(fmt.Sprintf("jsonrpc2: %v", err))
+		}
+	}
+	s.changeMethods(func() (added, removed []string) {
+		for _, cfg := range methods {
+			if _, ok := s.methods[cfg.Name]; !ok {
+				added = append(added, cfg.Name)
+			}
+			s.methods[cfg.Name] = cfg
+			delete(s.inits, cfg.Name)
+			if cfg.Initializer != nil {
+				s.inits[cfg.Name] = &initializer{init: cfg.Initializer, expected: cfg.InitDuration}
+			}
+			delete(s.adaptive, cfg.Name)
+			if cfg.AdaptiveTimeout != nil {
+				s.adaptive[cfg.Name] = newAdaptiveTimeout(*cfg.AdaptiveTimeout)
+			}
+		}
+		return added, nil
+	})
+}
+
+func (s *server) Use(mw ...Middleware) {
+	s.middleware = append(s.middleware, mw...)
 }
 
 // Receive a jsonrpc 2.0 json string request and return a jsonrpc 2.0 json string response
 func (s *server) ServeRequest(jsonString json.RawMessage) json.RawMessage {
+	return s.serveRequest(jsonString, s.serveSingleRequest)
+}
+
+// serveRequest serves a request or batch request, the elements of a batch by serve
+func (s *server) serveRequest(jsonString json.RawMessage, serve serveFunc) json.RawMessage {
+	if rsp := s.invalidUTF8(jsonString); rsp != nil {
+		return rsp
+	}
 	var arr []json.RawMessage
 	if err := json.Unmarshal(jsonString, &arr); err == nil {
 		if len(arr) == 0 {
-			return makeResponseJson(request{}, nil, ErrInvalidRequest)
+			return s.makeResponseJson(request{}, nil, ErrInvalidRequest)
 		}
-		return s.serveBatchRequest(arr)
+		sliceBatch(jsonString, arr)
+		return s.serveBatchRequest(arr, serve)
 	}
-	return s.serveSingleRequest(jsonString)
+	return serve(jsonString, -1)
 }
 
-func (s *server) serveSingleRequest(jsonString json.RawMessage) json.RawMessage {
+// serveSingleRequest serves a request, batchIndex is its index in the batch request or -1
+func (s *server) serveSingleRequest(jsonString json.RawMessage, batchIndex int) (served json.RawMessage) {
+	received := s.clock.Now()
+	s.metrics.received()
+	ctx := context.WithValue(s.root, requestContextKey{}, jsonString)
 	r := &request{}
 	if err := json.Unmarshal(jsonString, r); err != nil {
-		return makeResponseJson(request{}, nil, ErrParseError)
+		if s.parseErrorDetail {
+			return s.fail(ctx, request{}, newParseError(err))
+		}
+		return s.fail(ctx, request{}, ErrParseError)
+	}
+	if err := s.validateRequest(*r); err != nil {
+		return s.fail(ctx, *r, err)
 	}
-	if err := validateRequest(*r); err != nil {
-		return makeResponseJson(*r, nil, err)
+	if s.root.Err() != nil {
+		return s.fail(ctx, *r, ErrShuttingDown)
 	}
-	h, ok := s.handlers[r.Method]
+	s.methodsMu.RLock()
+	m, ok := s.methods[r.Method]
+	init, hasInit := s.inits[r.Method]
+	adaptive := s.adaptive[r.Method]
+	s.methodsMu.RUnlock()
 	if !ok {
-		return makeResponseJson(*r, nil, ErrMethodNotFound)
+		return s.fail(ctx, *r, ErrMethodNotFound)
+	}
+	defer func() { s.account(*r, jsonString, served) }()
+	if hasInit {
+		if err := init.ready(s.clock.Now()); err != nil {
+			return s.fail(ctx, *r, err)
+		}
 	}
-	ctx := context.Background()
-	if s.timeout > 0 {
+	h := m.handler(s.migrations[r.Method])
+	for i := len(s.middleware) - 1; i >= 0; i-- {
+		h = s.middleware[i](h)
+	}
+	if m.Detached {
+		h = detach(h)
+	}
+	if m.LockOSThread {
+		h = s.lockOSThread(r.Method, h)
+	}
+	cfg := s.loadConfig()
+	timeout := cfg.DefaultTimeout
+	if m.Timeout > 0 {
+		timeout = m.Timeout
+	}
+	if adaptive != nil {
+		timeout = adaptive.get(timeout)
+	}
+	if hint := timeoutHint(r.TimeoutMs); s.timeoutHint && hint > 0 && (timeout == 0 || hint < timeout) {
+		timeout = hint
+	}
+	ctx = context.WithValue(ctx, clockContextKey{}, s.clock)
+	ctx = s.withRequestLogger(ctx, *r)
+	ctx = context.WithValue(ctx, callInfoContextKey{}, &CallInfo{
+		Method:     r.Method,
+		RequestID:  r.ID,
+		Attempt:    1,
+		BatchIndex: batchIndex,
+		StartTime:  s.clock.Now(),
+		Timeout:    timeout,
+	})
+	if timeout > 0 {
 		var cancel func()
-		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		ctx, cancel = withTimeout(ctx, s.clock, timeout)
 		defer cancel()
 	}
-	result, err := handleAsync(ctx, h, r.Params)
-	return makeResponseJson(*r, result, err)
+	s.metrics.queue(1)
+	h, err := s.limitConcurrency(ctx, r.Method, h, cfg.MinQueueBudget)
+	s.metrics.queue(-1)
+	if err != nil {
+		return s.fail(ctx, *r, err)
+	}
+	start := s.clock.Now()
+	s.metrics.handle(1)
+	result, err := s.handleAsync(ctx, h, r.Params, cfg.TimeoutGrace, m.Detached)
+	s.metrics.handle(-1)
+	now := s.clock.Now()
+	s.observeDuration(r.Method, now.Sub(start))
+	if adaptive != nil {
+		adaptive.observe(now.Sub(start), now)
+	}
+	slow := cfg.SlowThreshold
+	if m.SlowThreshold > 0 {
+		slow = m.SlowThreshold
+	}
+	if elapsed := s.clock.Now().Sub(start); slow > 0 && elapsed > slow {
+		s.reportSlow(ctx, SlowRequest{Method: r.Method, ID: r.ID, Params: r.Params, Duration: elapsed})
+	}
+	if m.Detached && err == context.DeadlineExceeded {
+		err = ErrStillRunning
+	}
+	if cfg.ValidateResponses && err == nil {
+		err = validateResult(ctx, m, result)
+	}
+	if len(s.transformers) > 0 && err == nil {
+		result, err = s.transform(ctx, r.Method, result)
+	}
+	var timing *serverTiming
+	if cfg.ServerTiming {
+		timing = &serverTiming{
+			QueueMs:   milliseconds(start.Sub(received)),
+			HandlerMs: milliseconds(s.clock.Now().Sub(start)),
+		}
+	}
+	rsp := s.makeTimedResponseJson(*r, result, s.localize(ctx, err), timing)
+	if cfg.MaxResponseBytes > 0 && len(rsp) > cfg.MaxResponseBytes {
+		return s.fail(ctx, *r, newResponseTooLargeError(len(rsp), cfg.MaxResponseBytes))
+	}
+	if err != nil {
+		s.metrics.failed()
+		s.reportError(ctx, *r, s.localize(ctx, err))
+	}
+	return rsp
 }
 
-func (s *server) serveBatchRequest(rs []json.RawMessage) json.RawMessage {
-	rsps := make([]json.RawMessage, len(rs))
-	var wg sync.WaitGroup
-	for i := range rs {
-		wg.Add(1)
-		go func(i int) {
-			rsps[i] = s.serveSingleRequest(rs[i])
-			wg.Done()
-		}(i)
+// sliceBatch replaces the decoded elements of batch by the slices of batch they were decoded from,
+// so the raw requests refer to the bytes received without copy
+func sliceBatch(batch json.RawMessage, elems []json.RawMessage) {
+	pos := skipSpace(batch, 0) + 1 // '['
+	for i, e := range elems {
+		pos = skipSpace(batch, pos)
+		if !bytes.HasPrefix(batch[pos:], e) {
+			return
+		}
+		elems[i] = batch[pos : pos+len(e) : pos+len(e)]
+		pos = skipSpace(batch, pos+len(e)) + 1 // ',' or ']'
 	}
-	wg.Wait()
+}
+
+// skipSpace returns the position of the first non whitespace byte of data from pos
+func skipSpace(data []byte, pos int) int {
+	for pos < len(data) && (data[pos] == ' ' || data[pos] == '\t' || data[pos] == '\r' || data[pos] == '\n') {
+		pos++
+	}
+	return pos
+}
+
+func (s *server) serveBatchRequest(rs []json.RawMessage, serve serveFunc) json.RawMessage {
+	rsps := s.serveBatch(rs, serve, nil)
 
 	// construct response
 	result := make([]json.RawMessage, 0)
@@ -127,43 +590,127 @@ func (s *server) serveBatchRequest(rs []json.RawMessage) json.RawMessage {
 		return nil
 	}
 	rsp, _ := json.Marshal(result)
+	if limit := s.loadConfig().MaxBatchResponseBytes; limit > 0 && len(rsp) > limit {
+		return s.makeResponseJson(request{}, nil, newResponseTooLargeError(len(rsp), limit))
+	}
 	return rsp
 }
 
-// Rpc Handler is called with a timeout timer. If timed out, throw context deadline exceed error
-func handleAsync(ctx context.Context, h Handler, params json.RawMessage) (resp interface{}, err error) {
+// serveBatch serves the elements of a batch concurrently by serve and returns their responses, nil for the
+// notifications. If emit is not nil, it is called with the response of each element as soon as it is ready,
+// concurrently.
+func (s *server) serveBatch(rs []json.RawMessage, serve serveFunc, emit func(rsp json.RawMessage)) []json.RawMessage {
+	rsps := make([]json.RawMessage, len(rs))
+	leaders := s.batchLeaders(rs)
+	followers := map[int][]int{}
+	for i, l := range leaders {
+		if l != i {
+			followers[l] = append(followers[l], i)
+		}
+	}
+	var durations []time.Duration
+	start := s.clock.Now()
+	if s.onBatchComplete != nil {
+		durations = make([]time.Duration, len(rs))
+	}
+	progress := s.newBatchProgress(len(rs))
+	var wg sync.WaitGroup
+	for i := range rs {
+		if leaders[i] != i {
+			continue
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			served := s.clock.Now()
+			rsps[i] = serve(rs[i], i)
+			if durations != nil {
+				durations[i] = s.clock.Now().Sub(served)
+			}
+			for _, f := range followers[i] {
+				rsps[f] = withID(rsps[i], requestID(rs[f]))
+			}
+			if progress != nil {
+				progress.complete(1 + len(followers[i]))
+			}
+			if emit == nil {
+				return
+			}
+			for _, j := range append([]int{i}, followers[i]...) {
+				if rsps[j] != nil {
+					emit(rsps[j])
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	if s.onBatchComplete != nil {
+		s.onBatchComplete(summarizeBatch(rs, rsps, durations, s.clock.Now().Sub(start)))
+	}
+	return rsps
+}
+
+// Rpc Handler is called with a timeout timer. If timed out, throw context deadline exceed error.
+// With a grace, the handler has grace to return after its context is done before it is abandoned, unless it is detached.
+// The functions registered by OnRequestDone run when the handler returns or is abandoned.
+func (s *server) handleAsync(ctx context.Context, h Handler, params json.RawMessage, grace time.Duration, detached bool) (resp interface{}, err error) {
+	s.running.Add(1)
+	ctx, requestDone := withRequestDone(ctx)
+
 	// no timeout
-	deadline, ok := ctx.Deadline()
-	if !ok {
-		return h(ctx, params)
+	if _, ok := ctx.Deadline(); !ok {
+		defer s.running.Done()
+		defer requestDone()
+		return s.shuttingDown(callSafely(ctx, h, params))
 	}
 
 	// with timeout
-	done := make(chan int)
+	type result struct {
+		resp interface{}
+		err  error
+	}
+	done := make(chan result, 1)
 
-	// timeout timer
+	// main handler, it keeps running after a timeout until it returns
 	go func() {
-		timeout := deadline.Sub(time.Now())
-		if timeout > 0 {
-			time.Sleep(timeout)
-		}
-		err = context.DeadlineExceeded
-		done <- 1
+		defer s.running.Done()
+		resp, err := callSafely(ctx, h, params)
+		requestDone()
+		done <- result{resp, err}
 	}()
 
-	// main handler
-	go func() {
-		resp, err = h(ctx, params)
-		done <- 1
-	}()
+	// wait for the handler or the timeout
+	select {
+	case r := <-done:
+		return s.shuttingDown(r.resp, r.err)
+	case <-ctx.Done():
+	}
+	if detached || grace <= 0 {
+		return s.shuttingDown(nil, ctx.Err())
+	}
+	// wait for the handler to return after the cancellation
+	timer := s.clock.NewTimer(grace)
+	select {
+	case r := <-done:
+		timer.Stop()
+		return s.shuttingDown(r.resp, r.err)
+	case <-timer.C():
+	}
+	s.abandon(ctx, func() { <-done })
+	requestDone()
+	return s.shuttingDown(nil, ctx.Err())
+}
 
-	// wait for 1 of the goroutine finish
-	<-done
+// shuttingDown replaces the cancellation error of the handlers cancelled by Close by ErrShuttingDown
+func (s *server) shuttingDown(resp interface{}, err error) (interface{}, error) {
+	if err == context.Canceled && s.root.Err() != nil {
+		return nil, ErrShuttingDown
+	}
 	return resp, err
 }
 
-func validateRequest(req request) error {
-	if req.Version != "2.0" {
+func (s *server) validateRequest(req request) error {
+	if req.Version != s.version {
 		return ErrInvalidRequest
 	}
 	if req.Method == "" {
@@ -172,24 +719,46 @@ func validateRequest(req request) error {
 	return nil
 }
 
-func makeResponseJson(request request, result interface{}, error error) json.RawMessage {
+// fail makes the error response of a request which is not handled
+func (s *server) fail(ctx context.Context, request request, error error) json.RawMessage {
+	s.metrics.failed()
+	error = s.localize(ctx, error)
+	s.reportError(ctx, request, error)
+	return s.makeResponseJson(request, nil, error)
+}
+
+func (s *server) makeResponseJson(request request, result interface{}, error error) json.RawMessage {
+	return s.makeTimedResponseJson(request, result, error, nil)
+}
+
+// makeTimedResponseJson makes the response with the "serverTiming" extension member if timing is not nil
+func (s *server) makeTimedResponseJson(request request, result interface{}, error error, timing *serverTiming) json.RawMessage {
 	// if notification request
-	if validateRequest(request) == nil && request.ID == nil {
+	if s.validateRequest(request) == nil && request.ID == nil {
 		return nil
 	}
 	r := response{
-		ID:      request.ID,
-		Version: "2.0",
+		ID:           request.ID,
+		Version:      s.version,
+		ServerTiming: timing,
 	}
 	if error != nil {
-		if e, ok := error.(Error); ok {
+		// the result is discarded, skip marshaling it
+		var e Error
+		if errors.As(error, &e) {
 			// reconstruct to use private rpcError for json.Marshall
-			r.Error = NewError(e.Code(), e.Error())
+			r.Error = NewErrorWithData(e.Code(), e.Error(), errorData(e))
 		} else {
 			r.Error = NewInternalError(error.Error())
 		}
+	} else {
+		r.Result = result
 	}
-	r.Result = result
 	respStr, _ := json.Marshal(r)
 	return respStr
 }
+
+// milliseconds returns d in fractional milliseconds
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
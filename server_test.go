@@ -4,10 +4,24 @@ import (
 	"context"
 	"encoding/json"
 	"github.com/stretchr/testify/require"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 )
 
+// waitFor fails the test if cond is not true within a second
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); !cond(); time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met")
+		}
+	}
+}
+
 func TestServer_ServeRequest(t *testing.T) {
 	server := NewServer()
 	server.DefineMethod("echo", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
@@ -43,6 +57,13 @@ func TestServer_ServeRequest(t *testing.T) {
 			"error": {"code": -32601, "message": "Method not found"}
 		}`, string(rsp))
 	})
+	t.Run("error with data", func(t *testing.T) {
+		server.DefineMethod("fail", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+			return nil, NewErrorWithData(-32001, "Failed", "why")
+		})
+		rsp := server.ServeRequest(json.RawMessage(`{ "jsonrpc": "2.0", "method": "fail", "id": 1 }`))
+		require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "error": {"code": -32001, "message": "Failed", "data": "why"}}`, string(rsp))
+	})
 	t.Run("invalid json", func(t *testing.T) {
 		rsp := server.ServeRequest(json.RawMessage(`{qqqq}`))
 		require.JSONEq(t, `{
@@ -61,47 +82,13 @@ func TestServer_ServeRequest(t *testing.T) {
 	})
 }
 
-func TestServer_ServeRequestWithTimeout(t *testing.T) {
-	server := NewServer()
-	server.SetDefaultTimeout(5 * time.Millisecond)
-	server.DefineMethod("wait", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
-		var n float64
-		json.Unmarshal(params, &n)
-		time.Sleep(time.Duration(int(n)) * time.Millisecond)
-		return "ok", nil
-	})
-	t.Run("should not timeout", func(t *testing.T) {
-		rsp := server.ServeRequest(json.RawMessage(`{ "jsonrpc": "2.0", "method": "wait", "params": 1, "id": "1" }`))
-		require.JSONEq(t, `{
-			"id": "1",
-			"jsonrpc": "2.0",
-			"result": "ok"
-		}`, string(rsp))
-	})
-	t.Run("should timeout", func(t *testing.T) {
-		rsp := server.ServeRequest(json.RawMessage(`{ "jsonrpc": "2.0", "method": "wait", "params": 100, "id": "1" }`))
-		require.JSONEq(t, `{
-			"id": "1",
-			"jsonrpc": "2.0",
-			"error": { "code": -32000, "message":"context deadline exceeded" }
-		}`, string(rsp))
-	})
-}
-
 func TestServer_ServeBatchRequest(t *testing.T) {
 	server := NewServer()
-	server.SetDefaultTimeout(5 * time.Millisecond)
 	server.DefineMethod("echo", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
 		return struct {
 			EchoResult interface{}
 		}{EchoResult: params}, nil
 	})
-	server.DefineMethod("wait", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
-		var n float64
-		json.Unmarshal(params, &n)
-		time.Sleep(time.Duration(int(n)) * time.Millisecond)
-		return "ok", nil
-	})
 	t.Run("success", func(t *testing.T) {
 		rsp := server.ServeRequest(json.RawMessage(`[{ "jsonrpc": "2.0", "method": "echo", "params": "hi", "id": 1 }]`))
 		require.JSONEq(t, `[{
@@ -126,21 +113,6 @@ func TestServer_ServeBatchRequest(t *testing.T) {
 			{"jsonrpc": "2.0", "result": { "EchoResult": "hi" }, "id": "1"}
 		]`, string(rsp))
 	})
-	t.Run("should 1 timeout and 1 success", func(t *testing.T) {
-		rsp := server.ServeRequest(json.RawMessage(`[
-			{ "jsonrpc": "2.0", "method": "wait", "params": 100, "id": "1" },
-			{ "jsonrpc": "2.0", "method": "wait", "params": 1, "id": "2" }
-		]`))
-		require.JSONEq(t, `[{
-			"id": "1",
-			"jsonrpc": "2.0",
-			"error": { "code": -32000, "message":"context deadline exceeded" }
-		}, {
-			"id": "2",
-			"jsonrpc": "2.0",
-			"result": "ok"
-		}]`, string(rsp))
-	})
 	t.Run("1 notification and 1 success", func(t *testing.T) {
 		rsp := server.ServeRequest(json.RawMessage(`[
 			{ "jsonrpc": "2.0", "method": "echo", "params": 100 },
@@ -160,3 +132,246 @@ func TestServer_ServeBatchRequest(t *testing.T) {
 		require.Equal(t, "", string(rsp))
 	})
 }
+
+func TestServer_MaxResponseBytes(t *testing.T) {
+	server := NewServer()
+	server.DefineMethod("repeat", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		var n int
+		json.Unmarshal(params, &n)
+		return strings.Repeat("a", n), nil
+	})
+	// {"id":1,"jsonrpc":"2.0","result":""} is 36 bytes
+	server.SetMaxResponseBytes(40)
+	server.SetMaxBatchResponseBytes(82)
+	t.Run("response within limit", func(t *testing.T) {
+		rsp := server.ServeRequest(json.RawMessage(`{ "jsonrpc": "2.0", "method": "repeat", "params": 4, "id": 1 }`))
+		require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "result": "aaaa"}`, string(rsp))
+	})
+	t.Run("response just over limit", func(t *testing.T) {
+		rsp := server.ServeRequest(json.RawMessage(`{ "jsonrpc": "2.0", "method": "repeat", "params": 5, "id": 1 }`))
+		require.JSONEq(t, `{
+			"id": 1,
+			"jsonrpc": "2.0",
+			"error": {"code": -32018, "message": "Response too large", "data": {"size": 41, "limit": 40}}
+		}`, string(rsp))
+	})
+	t.Run("batch response just over limit", func(t *testing.T) {
+		rsp := server.ServeRequest(json.RawMessage(`[
+			{ "jsonrpc": "2.0", "method": "repeat", "params": 4, "id": 1 },
+			{ "jsonrpc": "2.0", "method": "repeat", "params": 3, "id": 2 }
+		]`))
+		require.JSONEq(t, `[
+			{"id": 1, "jsonrpc": "2.0", "result": "aaaa"},
+			{"id": 2, "jsonrpc": "2.0", "result": "aaa"}
+		]`, string(rsp))
+		rsp = server.ServeRequest(json.RawMessage(`[
+			{ "jsonrpc": "2.0", "method": "repeat", "params": 4, "id": 1 },
+			{ "jsonrpc": "2.0", "method": "repeat", "params": 4, "id": 2 }
+		]`))
+		require.JSONEq(t, `{
+			"id": null,
+			"jsonrpc": "2.0",
+			"error": {"code": -32018, "message": "Response too large", "data": {"size": 83, "limit": 82}}
+		}`, string(rsp))
+	})
+}
+
+func TestWithProtocolVersion(t *testing.T) {
+	server := NewServer(WithProtocolVersion("2.1"))
+	server.DefineMethod("echo", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		return params, nil
+	})
+	t.Run("configured version", func(t *testing.T) {
+		rsp := server.ServeRequest(json.RawMessage(`{ "jsonrpc": "2.1", "method": "echo", "params": "hi", "id": 1 }`))
+		require.JSONEq(t, `{"id": 1, "jsonrpc": "2.1", "result": "hi"}`, string(rsp))
+	})
+	t.Run("other version is invalid", func(t *testing.T) {
+		rsp := server.ServeRequest(json.RawMessage(`{ "jsonrpc": "2.0", "method": "echo", "params": "hi", "id": 1 }`))
+		require.JSONEq(t, `{
+			"id": 1,
+			"jsonrpc": "2.1",
+			"error": {"code": -32600, "message": "Invalid request"}
+		}`, string(rsp))
+	})
+	t.Run("notification", func(t *testing.T) {
+		rsp := server.ServeRequest(json.RawMessage(`{ "jsonrpc": "2.1", "method": "echo", "params": "hi" }`))
+		require.Equal(t, "", string(rsp))
+	})
+}
+
+type countingMarshaler struct {
+	count *int
+}
+
+func (m countingMarshaler) MarshalJSON() ([]byte, error) {
+	*m.count++
+	return []byte(`"result"`), nil
+}
+
+func TestServer_ResultIsNotMarshaledOnError(t *testing.T) {
+	count := 0
+	server := NewServer()
+	server.DefineMethod("reject", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		return countingMarshaler{count: &count}, NewError(-32001, "Rejected")
+	})
+	server.DefineMethod("accept", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		return countingMarshaler{count: &count}, nil
+	})
+	rsp := server.ServeRequest(json.RawMessage(`{ "jsonrpc": "2.0", "method": "reject", "id": 1 }`))
+	require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "error": {"code": -32001, "message": "Rejected"}}`, string(rsp))
+	require.Equal(t, 0, count)
+	rsp = server.ServeRequest(json.RawMessage(`{ "jsonrpc": "2.0", "method": "accept", "id": 1 }`))
+	require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "result": "result"}`, string(rsp))
+	require.Equal(t, 1, count)
+}
+
+func TestServer_Reset(t *testing.T) {
+	echo := func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		return params, nil
+	}
+	server := NewServer(WithProtocolVersion("2.1"))
+	server.DefineMethod("echo", echo)
+	DefineMethodConfig(server, MethodConfig{Name: "math.add", Handler: echo, Timeout: time.Second})
+	server.Use(NonceMiddleware(NewMemoryNonceStore(10), time.Minute))
+	server.SetDefaultTimeout(time.Second)
+	require.True(t, server.MethodExists("echo"))
+	require.True(t, server.MethodExists("math.add"))
+	require.False(t, server.MethodExists("math"))
+
+	server.Reset()
+	require.False(t, server.MethodExists("echo"))
+	require.False(t, server.MethodExists("math.add"))
+	require.Equal(t, ServerConfig{}, server.Config())
+
+	// options are kept, middlewares are removed
+	server.DefineMethod("echo", echo)
+	rsp := server.ServeRequest(json.RawMessage(`{ "jsonrpc": "2.1", "method": "echo", "params": "hi", "id": 1 }`))
+	require.JSONEq(t, `{"id": 1, "jsonrpc": "2.1", "result": "hi"}`, string(rsp))
+}
+
+func TestServer_Close(t *testing.T) {
+	before := runtime.NumGoroutine()
+	started := make(chan struct{}, 3)
+	cancelled := make(chan error, 3)
+	slow := func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		started <- struct{}{}
+		<-ctx.Done()
+		cancelled <- ctx.Err()
+		return nil, ctx.Err()
+	}
+	server := NewServer()
+	server.DefineMethod("slow", slow)
+	DefineMethodConfig(server, MethodConfig{Name: "slowWithTimeout", Handler: slow, Timeout: time.Hour})
+
+	rsps := make(chan json.RawMessage, 2)
+	go func() {
+		rsps <- server.ServeRequest(json.RawMessage(`{ "jsonrpc": "2.0", "method": "slow", "id": 1 }`))
+	}()
+	go func() {
+		rsps <- server.ServeRequest(json.RawMessage(`[
+			{ "jsonrpc": "2.0", "method": "slowWithTimeout", "id": 2 },
+			{ "jsonrpc": "2.0", "method": "slow" }
+		]`))
+	}()
+	for i := 0; i < 3; i++ {
+		<-started
+	}
+
+	server.Close()
+	for i := 0; i < 3; i++ {
+		require.Equal(t, context.Canceled, <-cancelled)
+	}
+	waited := make(chan struct{})
+	go func() {
+		server.Wait()
+		close(waited)
+	}()
+	select {
+	case <-waited:
+	case <-time.After(time.Second):
+		t.Fatal("Wait did not return")
+	}
+	for i := 0; i < 2; i++ {
+		_, rpcErrs, err := ExtractResults(json.RawMessage("[" + strings.Trim(string(<-rsps), "[]") + "]"))
+		require.NoError(t, err)
+		require.Len(t, rpcErrs, 1)
+		require.Equal(t, ErrShuttingDown.Code(), rpcErrs[0].Code())
+	}
+
+	rsp := server.ServeRequest(json.RawMessage(`{ "jsonrpc": "2.0", "method": "slow", "id": 1 }`))
+	require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "error": {"code": -32020, "message": "Shutting down"}}`, string(rsp))
+	waitFor(t, func() bool { return runtime.NumGoroutine() <= before })
+
+	// Reset makes the server usable again
+	server.Reset()
+	server.DefineMethod("echo", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		return params, nil
+	})
+	rsp = server.ServeRequest(json.RawMessage(`{ "jsonrpc": "2.0", "method": "echo", "params": "hi", "id": 1 }`))
+	require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "result": "hi"}`, string(rsp))
+}
+
+func TestWithBaseContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	server := NewServer(WithBaseContext(ctx))
+	server.DefineMethod("echo", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		return params, nil
+	})
+	cancel()
+	rsp := server.ServeRequest(json.RawMessage(`{ "jsonrpc": "2.0", "method": "echo", "params": "hi", "id": 1 }`))
+	require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "error": {"code": -32020, "message": "Shutting down"}}`, string(rsp))
+}
+
+func TestRawRequestFromContext(t *testing.T) {
+	var raws []json.RawMessage
+	var mu sync.Mutex
+	server := NewServer()
+	server.DefineMethod("raw", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		mu.Lock()
+		defer mu.Unlock()
+		raws = append(raws, RawRequestFromContext(ctx))
+		return nil, nil
+	})
+	t.Run("single request", func(t *testing.T) {
+		raws = nil
+		req := json.RawMessage(` { "jsonrpc": "2.0", "method": "raw", "id": 1 } `)
+		server.ServeRequest(req)
+		require.Len(t, raws, 1)
+		require.Equal(t, req, raws[0])
+	})
+	t.Run("batch request", func(t *testing.T) {
+		raws = nil
+		req := json.RawMessage("[\n\t{ \"jsonrpc\": \"2.0\", \"method\": \"raw\", \"id\": 1 } ,{\"jsonrpc\":\"2.0\",\"method\":\"raw\"}\n]")
+		server.ServeRequest(req)
+		require.Len(t, raws, 2)
+		sort.Slice(raws, func(i, j int) bool { return len(raws[i]) > len(raws[j]) })
+		require.Equal(t, string(req[3:49]), string(raws[0]))
+		require.Equal(t, string(req[51:83]), string(raws[1]))
+		// sliced from the request without copy
+		require.True(t, &req[3] == &raws[0][0])
+		require.True(t, &req[51] == &raws[1][0])
+	})
+	t.Run("no request", func(t *testing.T) {
+		require.Nil(t, RawRequestFromContext(context.Background()))
+	})
+}
+
+func TestWithParseErrorDetail(t *testing.T) {
+	server := NewServer(WithParseErrorDetail())
+	for req, data := range map[string]string{
+		`{"jsonrpc": "2.0", "method": "foobar`:                `{"offset": 36, "detail": "unexpected end of JSON input"}`,
+		`{"jsonrpc": "2.0", "method": "foobar", "id": 1,}`:    `{"offset": 48, "detail": "invalid character '}' looking for beginning of object key string"}`,
+		`{"jsonrpc": "2.0", "method": 1, "id": 1}`:            `{"offset": 30, "detail": "cannot unmarshal number into \"method\" of type string"}`,
+		`"foobar"`:                                            `{"offset": 8, "detail": "cannot unmarshal string into request object"}`,
+		`{"jsonrpc": "2.0", "method": "foobar"} {"id": 1}`:   `{"offset": 40, "detail": "invalid character '{' after top-level value"}`,
+	} {
+		rsp := server.ServeRequest(json.RawMessage(req))
+		require.JSONEq(t, `{
+			"id": null,
+			"jsonrpc": "2.0",
+			"error": {"code": -32700, "message": "Parse error", "data": `+data+`}
+		}`, string(rsp), req)
+	}
+	rsp := NewServer().ServeRequest(json.RawMessage(`{"jsonrpc": "2.0", "method": "foobar`))
+	require.JSONEq(t, `{"id": null, "jsonrpc": "2.0", "error": {"code": -32700, "message": "Parse error"}}`, string(rsp))
+}
@@ -0,0 +1,62 @@
+package jsonrpc2
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math/rand"
+	"runtime/debug"
+)
+
+// WithShadow mirrors a sample of the requests to target, e.g. a rewritten backend, to compare the responses offline.
+// Each non-notification request is mirrored with probability sampleRate, asynchronously after the handler returns,
+// and report is called with both responses. The shadow requests never block nor affect the responses of the server:
+// when too many shadow requests are in flight, the request is not mirrored. A panic of target or report is logged to
+// slog at ERROR level.
+//	server := jsonrpc2.NewServer(jsonrpc2.WithShadow(newServer, 0.01, func(orig, shadow json.RawMessage, method string) {
+//		if !bytes.Equal(orig, shadow) {
+//			log.Printf("%s: %s != %s", method, orig, shadow)
+//		}
+//	}))
+func WithShadow(target Requester, sampleRate float64, report func(orig, shadow json.RawMessage, method string)) ServerOption {
+	inFlight := make(chan struct{}, shadowQueueSize)
+	return func(s *server) {
+		s.Use(func(next Handler) Handler {
+			return func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+				result, err := next(ctx, params)
+				raw := RawRequestFromContext(ctx)
+				var r request
+				if json.Unmarshal(raw, &r) != nil || r.ID == nil || rand.Float64() >= sampleRate {
+					return result, err
+				}
+				select {
+				case inFlight <- struct{}{}:
+				default:
+					return result, err
+				}
+				orig := s.makeResponseJson(r, result, err)
+				log := LoggerFromContext(ctx)
+				// raw is only valid until the handler returns
+				raw = bytes.Clone(raw)
+				go func() {
+					defer func() {
+						if v := recover(); v != nil {
+							log.Error("jsonrpc2: shadow request panic", "panic", v, "stack", string(debug.Stack()))
+						}
+						<-inFlight
+					}()
+					shadow := target.ServeRequest(raw)
+					if report != nil {
+						report(orig, shadow, r.Method)
+					}
+				}()
+				return result, err
+			}
+		})
+	}
+}
+
+// ============ Private members below =================
+
+// shadowQueueSize is the max number of shadow requests in flight
+const shadowQueueSize = 100
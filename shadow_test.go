@@ -0,0 +1,138 @@
+package jsonrpc2
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testRequester func(jsonString json.RawMessage) json.RawMessage
+
+func (f testRequester) ServeRequest(jsonString json.RawMessage) json.RawMessage {
+	return f(jsonString)
+}
+
+// syncBuffer is a bytes.Buffer safe for concurrent use, e.g. written by a logger
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestWithShadow(t *testing.T) {
+	echo := func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		return params, nil
+	}
+	t.Run("report both responses", func(t *testing.T) {
+		target := NewServer()
+		target.DefineMethod("echo", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+			return "shadow", nil
+		})
+		reports := make(chan [3]string, 1)
+		server := NewServer(WithShadow(target, 1, func(orig, shadow json.RawMessage, method string) {
+			reports <- [3]string{string(orig), string(shadow), method}
+		}))
+		server.DefineMethod("echo", echo)
+		rsp := server.ServeRequest(json.RawMessage(`{ "jsonrpc": "2.0", "method": "echo", "params": "hi", "id": 1 }`))
+		require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "result": "hi"}`, string(rsp))
+		report := <-reports
+		require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "result": "hi"}`, report[0])
+		require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "result": "shadow"}`, report[1])
+		require.Equal(t, "echo", report[2])
+	})
+	t.Run("mirrors a copy of the request", func(t *testing.T) {
+		mirrored := make(chan string, 1)
+		release := make(chan struct{})
+		target := testRequester(func(jsonString json.RawMessage) json.RawMessage {
+			<-release
+			mirrored <- string(jsonString)
+			return nil
+		})
+		server := NewServer(WithShadow(target, 1, nil))
+		server.DefineMethod("echo", echo)
+		req := []byte(`[{ "jsonrpc": "2.0", "method": "echo", "params": "hi", "id": 1 }]`)
+		server.ServeRequest(req)
+		// the caller reuses its buffer once the response is returned
+		copy(req, bytes.Repeat([]byte(" "), len(req)))
+		close(release)
+		require.JSONEq(t, `{ "jsonrpc": "2.0", "method": "echo", "params": "hi", "id": 1 }`, <-mirrored)
+	})
+	t.Run("sampling", func(t *testing.T) {
+		var mu sync.Mutex
+		count := 0
+		target := testRequester(func(jsonString json.RawMessage) json.RawMessage {
+			mu.Lock()
+			count++
+			mu.Unlock()
+			return nil
+		})
+		var wg sync.WaitGroup
+		server := NewServer(WithShadow(target, 0, func(orig, shadow json.RawMessage, method string) {}))
+		server.DefineMethod("echo", echo)
+		server.ServeRequest(json.RawMessage(`{ "jsonrpc": "2.0", "method": "echo", "params": "hi", "id": 1 }`))
+
+		server = NewServer(WithShadow(target, 1, func(orig, shadow json.RawMessage, method string) { wg.Done() }))
+		server.DefineMethod("echo", echo)
+		wg.Add(2)
+		server.ServeRequest(json.RawMessage(`[
+			{ "jsonrpc": "2.0", "method": "echo", "params": "hi", "id": 1 },
+			{ "jsonrpc": "2.0", "method": "echo", "params": "hi" },
+			{ "jsonrpc": "2.0", "method": "echo", "params": "hi", "id": 2 }
+		]`))
+		wg.Wait()
+		require.Equal(t, 2, count)
+	})
+	t.Run("does not block nor affect the server", func(t *testing.T) {
+		block := make(chan struct{})
+		var mu sync.Mutex
+		count := 0
+		target := testRequester(func(jsonString json.RawMessage) json.RawMessage {
+			mu.Lock()
+			count++
+			mu.Unlock()
+			<-block
+			panic("shadow failure")
+		})
+		server := NewServer(WithShadow(target, 1, func(orig, shadow json.RawMessage, method string) {}))
+		server.DefineMethod("echo", echo)
+		start := time.Now()
+		for i := 0; i < shadowQueueSize+10; i++ {
+			rsp := server.ServeRequest(json.RawMessage(`{ "jsonrpc": "2.0", "method": "echo", "params": "hi", "id": 1 }`))
+			require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "result": "hi"}`, string(rsp))
+		}
+		require.Less(t, int64(time.Since(start)), int64(time.Second))
+		shadowed := func() int {
+			mu.Lock()
+			defer mu.Unlock()
+			return count
+		}
+		waitFor(t, func() bool { return shadowed() == shadowQueueSize })
+		var logs syncBuffer
+		defer slog.SetDefault(slog.Default())
+		slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+		close(block)
+		// the panicking shadows release their slot and are logged
+		waitFor(t, func() bool {
+			server.ServeRequest(json.RawMessage(`{ "jsonrpc": "2.0", "method": "echo", "params": "hi", "id": 1 }`))
+			return shadowed() > shadowQueueSize
+		})
+		waitFor(t, func() bool { return strings.Contains(logs.String(), `msg="jsonrpc2: shadow request panic" method=echo id=1 panic="shadow failure"`) })
+	})
+}
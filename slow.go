@@ -0,0 +1,47 @@
+package jsonrpc2
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// SlowRequest is a request whose handler took longer than the slow threshold, see OnSlowRequest.
+type SlowRequest struct {
+	Method string
+	// ID is nil for a notification
+	ID json.RawMessage
+	// Params are the params of the request, which may have secrets to redact before logging them
+	Params   json.RawMessage
+	Duration time.Duration
+}
+
+// OnSlowRequest calls fn with the requests whose handler took longer than ServerConfig.SlowThreshold, or the
+// MethodConfig.SlowThreshold of their method. Without OnSlowRequest, they are logged at WARN level by the logger of the
+// request, see LoggerFromContext, with the size of their params instead of the params.
+//	server := jsonrpc2.NewServer(jsonrpc2.OnSlowRequest(func(r jsonrpc2.SlowRequest) {
+//		slowRequests.WithLabelValues(r.Method).Inc()
+//	}))
+//	server.SetSlowThreshold(500 * time.Millisecond)
+// fn is called by the goroutine serving the request, before its response is returned.
+func OnSlowRequest(fn func(SlowRequest)) ServerOption {
+	return func(s *server) {
+		s.onSlowRequest = fn
+	}
+}
+
+func (s *server) SetSlowThreshold(d time.Duration) {
+	s.updateConfig(func(cfg *ServerConfig) {
+		cfg.SlowThreshold = d
+	})
+}
+
+// ============ Private members below =================
+
+func (s *server) reportSlow(ctx context.Context, r SlowRequest) {
+	if s.onSlowRequest != nil {
+		s.onSlowRequest(r)
+		return
+	}
+	LoggerFromContext(ctx).Warn("jsonrpc2: slow request", "duration", r.Duration, "params_bytes", len(r.Params))
+}
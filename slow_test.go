@@ -0,0 +1,50 @@
+package jsonrpc2
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestOnSlowRequest(t *testing.T) {
+	clock := MockClock()
+	reports := make(chan SlowRequest, 1)
+	sleep := func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		var ms int
+		json.Unmarshal(params, &ms)
+		ClockFromContext(ctx).Sleep(time.Duration(ms) * time.Millisecond)
+		return "ok", nil
+	}
+	server := NewServer(WithClock(clock), OnSlowRequest(func(r SlowRequest) {
+		reports <- r
+	}))
+	server.SetSlowThreshold(100 * time.Millisecond)
+	server.DefineMethod("sleep", sleep)
+	DefineMethodConfig(server, MethodConfig{Name: "sleep.long", Handler: sleep, SlowThreshold: time.Second})
+	serve := func(method string, ms int) {
+		rsp := make(chan json.RawMessage)
+		go func() {
+			rsp <- server.ServeRequest(json.RawMessage(`{"jsonrpc": "2.0", "method": "` + method + `", "params": ` + strconv.Itoa(ms) + `, "id": 1}`))
+		}()
+		clock.BlockUntil(1)
+		clock.Advance(time.Duration(ms) * time.Millisecond)
+		require.JSONEq(t, `{"jsonrpc": "2.0", "result": "ok", "id": 1}`, string(<-rsp))
+	}
+
+	serve("sleep", 100)
+	require.Empty(t, reports, "not crossing the threshold")
+	serve("sleep", 150)
+	require.Equal(t, SlowRequest{
+		Method:   "sleep",
+		ID:       json.RawMessage(`1`),
+		Params:   json.RawMessage(`150`),
+		Duration: 150 * time.Millisecond,
+	}, <-reports)
+	serve("sleep.long", 500)
+	require.Empty(t, reports, "method threshold")
+	serve("sleep.long", 1500)
+	require.Equal(t, 1500*time.Millisecond, (<-reports).Duration)
+}
@@ -0,0 +1,95 @@
+package jsonrpc2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// NewSSEHandler returns a http.Handler streaming the notifications published on bus as server-sent events.
+// Each event is a jsonrpc 2.0 notification object:
+//	data: {"jsonrpc":"2.0","method":"event","params":{...}}
+func NewSSEHandler(bus *NotificationBus) http.Handler {
+	return &sseHandler{bus: bus}
+}
+
+// ============ Private members below =================
+
+type (
+	sseHandler struct {
+		bus *NotificationBus
+	}
+
+	// sseConnection forwards the notifications to the goroutine serving the http request
+	sseConnection struct {
+		events chan []byte
+		done   <-chan struct{}
+	}
+
+	// A notification represents a JSON-RPC notification sent by the server.
+	notification struct {
+		Version string      `json:"jsonrpc"`
+		Method  string      `json:"method"`
+		Params  interface{} `json:"params,omitempty"`
+	}
+)
+
+var errConnectionClosed = errors.New("connection closed")
+
+func (h *sseHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	conn := &sseConnection{
+		events: make(chan []byte),
+		done:   r.Context().Done(),
+	}
+	unregister := h.bus.Register(conn)
+	defer unregister()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event := <-conn.events:
+			w.Write([]byte("data: "))
+			w.Write(event)
+			w.Write([]byte("\n\n"))
+			flusher.Flush()
+		}
+	}
+}
+
+func (c *sseConnection) Notify(ctx context.Context, method string, params interface{}) error {
+	event, err := json.Marshal(notification{
+		Version: "2.0",
+		Method:  method,
+		Params:  params,
+	})
+	if err != nil {
+		return err
+	}
+	select {
+	case c.events <- event:
+		return nil
+	case <-c.done:
+		return errConnectionClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
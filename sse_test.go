@@ -0,0 +1,51 @@
+package jsonrpc2
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// flushRecorder signals every flush so the test can read the body written so far
+type flushRecorder struct {
+	*httptest.ResponseRecorder
+	flushed chan string
+}
+
+func (r *flushRecorder) Flush() {
+	r.flushed <- r.Body.String()
+	r.Body.Reset()
+}
+
+func TestSSEHandler(t *testing.T) {
+	t.Run("stream notifications", func(t *testing.T) {
+		bus := NewNotificationBus()
+		ctx, cancel := context.WithCancel(context.Background())
+		w := &flushRecorder{ResponseRecorder: httptest.NewRecorder(), flushed: make(chan string)}
+		r := httptest.NewRequest(http.MethodGet, "/events", nil).WithContext(ctx)
+		done := make(chan struct{})
+		go func() {
+			NewSSEHandler(bus).ServeHTTP(w, r)
+			close(done)
+		}()
+
+		require.Equal(t, "", <-w.flushed)
+		require.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+		require.Equal(t, "no-cache", w.Header().Get("Cache-Control"))
+		require.Equal(t, "keep-alive", w.Header().Get("Connection"))
+
+		go bus.Publish(context.Background(), "event", []int{1, 2})
+		require.Equal(t, "data: {\"jsonrpc\":\"2.0\",\"method\":\"event\",\"params\":[1,2]}\n\n", <-w.flushed)
+
+		cancel()
+		<-done
+		require.Empty(t, bus.conns)
+	})
+	t.Run("method not allowed", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		NewSSEHandler(NewNotificationBus()).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/events", nil))
+		require.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	})
+}
@@ -0,0 +1,136 @@
+package jsonrpc2
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+type (
+	// MethodStats are the figures of the requests of a method since the server was created or reset, see
+	// Server.Stats. The elements of a batch are accounted separately.
+	MethodStats struct {
+		Calls int64 `json:"calls"`
+		// RequestBytes is the size of the requests, of their element in a batch
+		RequestBytes int64 `json:"requestBytes"`
+		// ParamsBytes is the size of the params of the requests, as decoded
+		ParamsBytes int64 `json:"paramsBytes"`
+		// ResponseBytes is the size of the responses, 0 for the notifications
+		ResponseBytes int64 `json:"responseBytes"`
+		// P50Duration, P95Duration and P99Duration are the percentiles of the durations of the handlers in the last
+		// 1000 calls, zero if no handler was called
+		P50Duration time.Duration `json:"p50DurationNs"`
+		P95Duration time.Duration `json:"p95DurationNs"`
+		P99Duration time.Duration `json:"p99DurationNs"`
+	}
+
+	// Accounting is the size of a request of a defined method and of its response, see OnAccounting.
+	Accounting struct {
+		Method string
+		// ID is nil for a notification
+		ID json.RawMessage
+		// RequestBytes is the size of the request, of its element in a batch
+		RequestBytes int
+		// ParamsBytes is the size of the params, 0 if none
+		ParamsBytes int
+		// ResponseBytes is the size of the response, 0 for a notification
+		ResponseBytes int
+	}
+)
+
+// OnAccounting calls fn with the sizes of each request of a defined method and of its response, e.g. for capacity
+// planning. The sizes are the lengths of the bytes received and responded, the elements of a batch are reported
+// separately. They are also added to the stats of the method, see Server.Stats.
+//	server := jsonrpc2.NewServer(jsonrpc2.OnAccounting(func(a jsonrpc2.Accounting) {
+//		responseBytes.WithLabelValues(a.Method).Observe(float64(a.ResponseBytes))
+//	}))
+// fn is called by the goroutine serving the request, before its response is returned.
+func OnAccounting(fn func(Accounting)) ServerOption {
+	return func(s *server) {
+		s.onAccounting = fn
+	}
+}
+
+// EnableMethodStats defines the "rpc.methodStats" method, responding the stats of the methods called by method, see
+// Server.Stats:
+//	rpc.methodStats() -> {"orders.get": {"calls": 120, "requestBytes": 7320, ..., "p99DurationNs": 8200000}}
+// The stats tell the methods used and their load, the method should not be exposed to untrusted clients.
+func EnableMethodStats() ServerOption {
+	return func(s *server) {
+		s.defineBuiltins(MethodConfig{
+			Name: methodStatsMethod,
+			Handler: func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+				return s.Stats(), nil
+			},
+			Doc:          "Respond the stats of the methods called, by method",
+			ResultSchema: json.RawMessage(`{"type":"object","additionalProperties":{"type":"object"}}`),
+		})
+	}
+}
+
+// Stats returns the stats of the methods called since the server was created or reset, by method.
+func (s *server) Stats() map[string]MethodStats {
+	s.statsMu.Lock()
+	defer s.statsMu.Unlock()
+	stats := make(map[string]MethodStats, len(s.stats))
+	for method, m := range s.stats {
+		ps := m.durations.percentiles(0.5, 0.95, 0.99)
+		m.P50Duration, m.P95Duration, m.P99Duration = ps[0], ps[1], ps[2]
+		stats[method] = m.MethodStats
+	}
+	return stats
+}
+
+// ============ Private members below =================
+
+const (
+	methodStatsMethod = "rpc.methodStats"
+
+	// statsWindow is the number of the last durations of a method its percentiles are estimated from
+	statsWindow = 1000
+)
+
+// methodStats are the stats of a method, with the last durations of its handlers
+type methodStats struct {
+	MethodStats
+	durations *latencyWindow
+}
+
+// account adds the sizes of a request of a defined method and of its response to the stats of the method
+func (s *server) account(r request, raw, rsp json.RawMessage) {
+	a := Accounting{
+		Method:        r.Method,
+		ID:            r.ID,
+		RequestBytes:  len(raw),
+		ParamsBytes:   len(r.Params),
+		ResponseBytes: len(rsp),
+	}
+	s.statsMu.Lock()
+	m := s.methodStats(a.Method)
+	m.Calls++
+	m.RequestBytes += int64(a.RequestBytes)
+	m.ParamsBytes += int64(a.ParamsBytes)
+	m.ResponseBytes += int64(a.ResponseBytes)
+	s.statsMu.Unlock()
+	s.metrics.transfer(a.Method, a.RequestBytes, a.ResponseBytes)
+	if s.onAccounting != nil {
+		s.onAccounting(a)
+	}
+}
+
+// observeDuration adds the duration of a handler of method to the stats of the method
+func (s *server) observeDuration(method string, d time.Duration) {
+	s.statsMu.Lock()
+	defer s.statsMu.Unlock()
+	s.methodStats(method).durations.add(d)
+}
+
+// methodStats returns the stats of method, created on first use, s.statsMu must be held
+func (s *server) methodStats(method string) *methodStats {
+	m, ok := s.stats[method]
+	if !ok {
+		m = &methodStats{durations: newLatencyWindow(statsWindow)}
+		s.stats[method] = m
+	}
+	return m
+}
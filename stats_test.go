@@ -0,0 +1,87 @@
+package jsonrpc2
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestOnAccounting(t *testing.T) {
+	var mu sync.Mutex
+	var accounted []Accounting
+	namespace := uniqueExpvarName("test.rpc")
+	server := NewServer(WithExpvarMetrics(namespace), OnAccounting(func(a Accounting) {
+		mu.Lock()
+		defer mu.Unlock()
+		accounted = append(accounted, a)
+	}))
+	server.DefineMethod("echo", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		return params, nil
+	})
+
+	req := `{"jsonrpc": "2.0", "method": "echo", "params": "hi", "id": 1}`
+	rsp := server.ServeRequest(json.RawMessage(req))
+	require.Equal(t, `{"id":1,"jsonrpc":"2.0","result":"hi"}`, string(rsp))
+	require.Equal(t, []Accounting{
+		{Method: "echo", ID: json.RawMessage("1"), RequestBytes: 61, ParamsBytes: 4, ResponseBytes: 38},
+	}, accounted)
+
+	// each element of a batch is accounted, the unknown methods are not
+	accounted = nil
+	rsp = server.ServeRequest(json.RawMessage(`[
+		{"jsonrpc": "2.0", "method": "echo", "params": [1, 2], "id": 2},
+		{"jsonrpc": "2.0", "method": "echo", "params": {"a": "b"}},
+		{"jsonrpc": "2.0", "method": "missing", "id": 3}
+	]`))
+	require.Contains(t, string(rsp), `{"id":2,"jsonrpc":"2.0","result":[1,2]}`)
+	require.ElementsMatch(t, []Accounting{
+		{Method: "echo", ID: json.RawMessage("2"), RequestBytes: 63, ParamsBytes: 6, ResponseBytes: 39},
+		{Method: "echo", RequestBytes: 58, ParamsBytes: 10},
+	}, accounted)
+
+	stats := server.Stats()
+	require.Len(t, stats, 1)
+	echo := stats["echo"]
+	require.Equal(t, int64(3), echo.Calls)
+	require.Equal(t, int64(61+63+58), echo.RequestBytes)
+	require.Equal(t, int64(4+6+10), echo.ParamsBytes)
+	require.Equal(t, int64(38+39), echo.ResponseBytes)
+	require.JSONEq(t, `{"echo": {"in": 182, "out": 77}}`, expvar.Get(namespace+".method_bytes").String())
+
+	server.Reset()
+	require.Empty(t, server.Stats())
+}
+
+func TestEnableMethodStats(t *testing.T) {
+	clock := MockClock()
+	server := NewServer(WithClock(clock), EnableMethodStats())
+	server.DefineMethod("sleep", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		var ms int
+		json.Unmarshal(params, &ms)
+		clock.Advance(time.Duration(ms) * time.Millisecond)
+		return nil, nil
+	})
+	// 1ms to 100ms, in a shuffled order
+	for _, i := range rand.Perm(100) {
+		server.ServeRequest(json.RawMessage(fmt.Sprintf(`{"jsonrpc": "2.0", "method": "sleep", "params": %d, "id": 1}`, i+1)))
+	}
+	stats := server.Stats()["sleep"]
+	require.Equal(t, int64(100), stats.Calls)
+	require.InEpsilon(t, 50*time.Millisecond, stats.P50Duration, 0.2)
+	require.InEpsilon(t, 95*time.Millisecond, stats.P95Duration, 0.2)
+	require.InEpsilon(t, 99*time.Millisecond, stats.P99Duration, 0.2)
+
+	rsp := server.ServeRequest(json.RawMessage(`{"jsonrpc": "2.0", "method": "rpc.methodStats", "id": 1}`))
+	var result struct {
+		Result map[string]MethodStats `json:"result"`
+	}
+	require.NoError(t, json.Unmarshal(rsp, &result))
+	require.Equal(t, stats, result.Result["sleep"])
+	require.Contains(t, string(rsp), `"p95DurationNs":95000000`)
+}
@@ -0,0 +1,111 @@
+package jsonrpc2
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+)
+
+// ServeStdioMultiplex serves the requests read from stdin, one per line, and writes their responses to stdout, one
+// per line. The requests are read by a single goroutine and served by concurrency workers, so a slow handler does not
+// block the next requests: as in a batch, the responses are written in the order they complete, not in the order of
+// the requests. The responses of the elements of a batch are streamed, if ServerConfig.StreamingBatchResponses is set.
+// It returns nil when stdin is closed and the responses of all its requests are written, or ctx.Err()
+// when ctx is done, after the requests being served are written.
+//	if err := jsonrpc2.ServeStdioMultiplex(ctx, server, 8); err != nil { ... }
+func ServeStdioMultiplex(ctx context.Context, server Requester, concurrency int) error {
+	return serveStreamMultiplex(ctx, server, concurrency, os.Stdin, os.Stdout)
+}
+
+// ============ Private members below =================
+
+// serveStreamMultiplex is ServeStdioMultiplex reading from r and writing to w.
+// The reading goroutine is left blocked on r if ctx is done first.
+func serveStreamMultiplex(ctx context.Context, server Requester, concurrency int, r io.Reader, w io.Writer) error {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	lines := make(chan []byte)
+	readErr := make(chan error, 1)
+	go func() {
+		br := bufio.NewReader(r)
+		for {
+			line, err := br.ReadBytes('\n')
+			if len(bytes.TrimSpace(line)) > 0 {
+				select {
+				case lines <- line:
+				case <-ctx.Done():
+					return
+				}
+			}
+			if err != nil {
+				if err == io.EOF {
+					err = nil
+				}
+				readErr <- err
+				return
+			}
+		}
+	}()
+
+	// workers serve the requests, the writer writes their responses
+	requests := make(chan []byte)
+	responses := make(chan json.RawMessage)
+	serve := func(req json.RawMessage) {
+		if rsp := server.ServeRequest(req); rsp != nil {
+			responses <- rsp
+		}
+	}
+	if streaming, ok := server.(streamingRequester); ok {
+		serve = func(req json.RawMessage) {
+			streaming.serveRequestStream(req, func(rsp json.RawMessage) {
+				responses <- rsp
+			})
+		}
+	}
+	var workers sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for req := range requests {
+				serve(bytes.TrimSpace(req))
+			}
+		}()
+	}
+	writeErr := make(chan error, 1)
+	go func() {
+		var err error
+		for rsp := range responses {
+			if err == nil {
+				_, err = w.Write(append(rsp, '\n'))
+			}
+		}
+		writeErr <- err
+	}()
+
+	var err error
+dispatch:
+	for {
+		select {
+		case line := <-lines:
+			requests <- line
+		case err = <-readErr:
+			break dispatch
+		case <-ctx.Done():
+			err = ctx.Err()
+			break dispatch
+		}
+	}
+	close(requests)
+	workers.Wait()
+	close(responses)
+	if werr := <-writeErr; err == nil {
+		err = werr
+	}
+	return err
+}
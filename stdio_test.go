@@ -0,0 +1,92 @@
+package jsonrpc2
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"io"
+	"testing"
+)
+
+func TestServeStdioMultiplex(t *testing.T) {
+	release := make(chan struct{})
+	server := NewServer()
+	server.DefineMethod("echo", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		return params, nil
+	})
+	server.DefineMethod("wait", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		<-release
+		return "released", nil
+	})
+	t.Run("responses in completion order", func(t *testing.T) {
+		stdin, in := io.Pipe()
+		out, stdout := io.Pipe()
+		done := make(chan error, 1)
+		go func() {
+			done <- serveStreamMultiplex(context.Background(), server, 2, stdin, stdout)
+		}()
+		responses := bufio.NewScanner(out)
+
+		in.Write([]byte(`{"jsonrpc": "2.0", "method": "wait", "id": 1}` + "\n"))
+		in.Write([]byte(`{"jsonrpc": "2.0", "method": "echo", "params": "hi", "id": 2}` + "\r\n"))
+		require.True(t, responses.Scan())
+		require.JSONEq(t, `{"jsonrpc": "2.0", "result": "hi", "id": 2}`, responses.Text())
+		close(release)
+		require.True(t, responses.Scan())
+		require.JSONEq(t, `{"jsonrpc": "2.0", "result": "released", "id": 1}`, responses.Text())
+
+		in.Write([]byte(`{"jsonrpc": "2.0", "method": "echo", "params": "notified"}` + "\n\n"))
+		in.Write([]byte(`[{"jsonrpc": "2.0", "method": "echo", "params": 1, "id": 3}, {"jsonrpc`))
+		in.Close()
+		require.True(t, responses.Scan())
+		require.JSONEq(t, `{"jsonrpc": "2.0", "error": {"code": -32700, "message": "Parse error"}, "id": null}`, responses.Text())
+		require.NoError(t, <-done)
+	})
+	t.Run("streaming batch responses", func(t *testing.T) {
+		release := make(chan struct{})
+		server := NewServer()
+		server.DefineMethod("echo", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+			return params, nil
+		})
+		server.DefineMethod("wait", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+			<-release
+			return "released", nil
+		})
+		server.SetStreamingBatchResponses(true)
+		cfg := server.Config()
+		cfg.BatchEndMarker = true
+		require.NoError(t, server.ApplyConfig(cfg))
+		stdin, in := io.Pipe()
+		out, stdout := io.Pipe()
+		done := make(chan error, 1)
+		go func() {
+			done <- serveStreamMultiplex(context.Background(), server, 1, stdin, stdout)
+		}()
+		responses := bufio.NewScanner(out)
+
+		in.Write([]byte(`[{"jsonrpc": "2.0", "method": "wait", "id": 1}, ` +
+			`{"jsonrpc": "2.0", "method": "echo", "params": "fast", "id": 2}, ` +
+			`{"jsonrpc": "2.0", "method": "echo", "params": "notified"}]` + "\n"))
+		// the fast element arrives while the slow one is still running
+		require.True(t, responses.Scan())
+		require.JSONEq(t, `{"jsonrpc": "2.0", "result": "fast", "id": 2}`, responses.Text())
+		close(release)
+		require.True(t, responses.Scan())
+		require.JSONEq(t, `{"jsonrpc": "2.0", "result": "released", "id": 1}`, responses.Text())
+		require.True(t, responses.Scan())
+		require.JSONEq(t, `{"jsonrpc": "2.0", "method": "rpc.batchEnd", "params": {"size": 3}}`, responses.Text())
+
+		// ServeRequest keeps responding whole batches
+		rsp := server.ServeRequest(json.RawMessage(`[{"jsonrpc": "2.0", "method": "echo", "params": 1, "id": 3}]`))
+		require.JSONEq(t, `[{"jsonrpc": "2.0", "result": 1, "id": 3}]`, string(rsp))
+		in.Close()
+		require.NoError(t, <-done)
+	})
+	t.Run("context done", func(t *testing.T) {
+		stdin, _ := io.Pipe()
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		require.Equal(t, context.Canceled, serveStreamMultiplex(ctx, server, 2, stdin, io.Discard))
+	})
+}
@@ -0,0 +1,51 @@
+package jsonrpc2
+
+import (
+	"encoding/json"
+)
+
+// SetStreamingBatchResponses streams the responses of the batch elements over connections, see
+// ServerConfig.StreamingBatchResponses.
+func (s *server) SetStreamingBatchResponses(enabled bool) {
+	s.updateConfig(func(cfg *ServerConfig) {
+		cfg.StreamingBatchResponses = enabled
+	})
+}
+
+// ============ Private members below =================
+
+// batchEndMethod is the notification sent after the streamed responses of a batch, see ServerConfig.BatchEndMarker
+const batchEndMethod = "rpc.batchEnd"
+
+// streamingRequester is implemented by the servers able to stream the responses of batches, the connection-oriented
+// transports use it instead of ServeRequest
+type streamingRequester interface {
+	serveRequestStream(jsonString json.RawMessage, emit func(rsp json.RawMessage))
+}
+
+// serveRequestStream calls emit with the response of each element of a batch as soon as it is ready, if
+// ServerConfig.StreamingBatchResponses is set, or with the response of ServeRequest. emit may be called concurrently.
+func (s *server) serveRequestStream(jsonString json.RawMessage, emit func(rsp json.RawMessage)) {
+	cfg := s.loadConfig()
+	var arr []json.RawMessage
+	if !cfg.StreamingBatchResponses || json.Unmarshal(jsonString, &arr) != nil || len(arr) == 0 {
+		if rsp := s.ServeRequest(jsonString); rsp != nil {
+			emit(rsp)
+		}
+		return
+	}
+	if rsp := s.invalidUTF8(jsonString); rsp != nil {
+		emit(rsp)
+		return
+	}
+	sliceBatch(jsonString, arr)
+	s.serveBatch(arr, s.serveSingleRequest, emit)
+	if cfg.BatchEndMarker {
+		end, _ := json.Marshal(map[string]interface{}{
+			"jsonrpc": s.version,
+			"method":  batchEndMethod,
+			"params":  map[string]int{"size": len(arr)},
+		})
+		emit(end)
+	}
+}
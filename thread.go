@@ -0,0 +1,90 @@
+package jsonrpc2
+
+import (
+	"context"
+	"encoding/json"
+	"runtime"
+)
+
+// ============ Private members below =================
+
+type (
+	// pinnedWorker runs the handlers of a method on a goroutine locked to its OS thread, see MethodConfig.LockOSThread
+	pinnedWorker struct {
+		jobs chan func()
+		// done is closed to stop the worker, jobs is never closed as requests may be handing off
+		done chan struct{}
+	}
+)
+
+// lockOSThread returns h running with runtime.LockOSThread. The handlers of the methods limited to 1 request at once by
+// WithMethodConcurrency run on the pinned worker of the method, so always on the same OS thread.
+func (s *server) lockOSThread(method string, h Handler) Handler {
+	if cap(s.concurrency[method]) != 1 {
+		return func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+			runtime.LockOSThread()
+			defer runtime.UnlockOSThread()
+			return h(ctx, params)
+		}
+	}
+	return func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+		w := s.pinnedWorker(method)
+		if w == nil {
+			return nil, ErrShuttingDown
+		}
+		var result interface{}
+		var err error
+		done := make(chan struct{})
+		select {
+		case w.jobs <- func() {
+			defer close(done)
+			result, err = callSafely(ctx, h, params)
+		}:
+		case <-w.done:
+			return nil, ErrShuttingDown
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		<-done
+		return result, err
+	}
+}
+
+// pinnedWorker returns the worker of method, started on first use, or nil once the server is closed
+func (s *server) pinnedWorker(method string) *pinnedWorker {
+	s.pinnedMu.Lock()
+	defer s.pinnedMu.Unlock()
+	if s.root.Err() != nil {
+		return nil
+	}
+	w, ok := s.pinned[method]
+	if !ok {
+		w = &pinnedWorker{jobs: make(chan func()), done: make(chan struct{})}
+		s.pinned[method] = w
+		go w.run()
+	}
+	return w
+}
+
+// run runs the jobs until the worker is stopped. The goroutine stays locked, so its thread exits with it.
+func (w *pinnedWorker) run() {
+	runtime.LockOSThread()
+	for {
+		select {
+		case job := <-w.jobs:
+			job()
+		case <-w.done:
+			return
+		}
+	}
+}
+
+// stopPinnedWorkers stops the workers of the methods once their current job is done
+func (s *server) stopPinnedWorkers() {
+	s.pinnedMu.Lock()
+	defer s.pinnedMu.Unlock()
+	for _, w := range s.pinned {
+		close(w.done)
+	}
+	s.pinned = map[string]*pinnedWorker{}
+}
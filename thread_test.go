@@ -0,0 +1,72 @@
+//go:build linux
+
+package jsonrpc2
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestMethodConfig_LockOSThread(t *testing.T) {
+	tid := func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		return syscall.Gettid(), nil
+	}
+	call := func(server Server, method string) int {
+		var rsp struct{ Result int }
+		require.NoError(t, json.Unmarshal(server.ServeRequest(json.RawMessage(`{"jsonrpc": "2.0", "method": "`+method+`", "id": 1}`)), &rsp))
+		require.NotZero(t, rsp.Result)
+		return rsp.Result
+	}
+
+	server := NewServer(WithMethodConcurrency("pinned", 1))
+	DefineMethodConfig(server, MethodConfig{Name: "pinned", Handler: tid, LockOSThread: true})
+	DefineMethodConfig(server, MethodConfig{Name: "locked", Handler: tid, LockOSThread: true})
+	DefineMethodConfig(server, MethodConfig{Name: "panic", LockOSThread: true, Handler: func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		panic("boom")
+	}})
+
+	first := call(server, "pinned")
+	for i := 0; i < 10; i++ {
+		require.Equal(t, first, call(server, "pinned"))
+		call(server, "locked")
+	}
+	rsp := server.ServeRequest(json.RawMessage(`{"jsonrpc": "2.0", "method": "panic", "id": 1}`))
+	require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "error": {"code": -32603, "message": "Internal server error"}}`, string(rsp))
+}
+
+func TestMethodConfig_LockOSThread_Close(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	s := NewServer(WithMethodConcurrency("stuck", 1)).(*server)
+	DefineMethodConfig(s, MethodConfig{Name: "stuck", Timeout: 20 * time.Millisecond, LockOSThread: true, Handler: func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		<-release
+		return nil, nil
+	}})
+	timeout := `{"id": 1, "jsonrpc": "2.0", "error": {"code": -32000, "message": "context deadline exceeded"}}`
+	request := json.RawMessage(`{"jsonrpc": "2.0", "method": "stuck", "id": 1}`)
+	require.JSONEq(t, timeout, string(s.ServeRequest(request)))
+	// the abandoned handler still holds the slot of the method
+	require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "error": {"code": -32000, "message": "Service busy"}}`, string(s.ServeRequest(request)))
+	// the worker is still running the abandoned handler, the hand-off gives up with the context
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := s.lockOSThread("stuck", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		return nil, nil
+	})(ctx, nil)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	w := s.pinnedWorker("stuck")
+	s.Close()
+	require.Nil(t, s.pinnedWorker("stuck"), "no worker is started once closed")
+	select {
+	case <-w.done:
+	default:
+		t.Fatal("the worker is not stopped")
+	}
+	rsp := s.ServeRequest(request)
+	require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "error": {"code": -32020, "message": "Shutting down"}}`, string(rsp))
+}
@@ -0,0 +1,31 @@
+package jsonrpc2
+
+import (
+	"encoding/json"
+	"math"
+	"time"
+)
+
+// WithTimeoutHint caps the timeout of the requests at the remaining time of the client, sent as the "timeoutMs"
+// extension member, so the handlers do not work past the deadline of the client:
+//	{"jsonrpc": "2.0", "method": "report.build", "id": 1, "timeoutMs": 1500}
+// The hint is advisory: it never extends the timeout of the method, and is ignored unless it is a positive number.
+func WithTimeoutHint() ServerOption {
+	return func(s *server) {
+		s.timeoutHint = true
+	}
+}
+
+// ============ Private members below =================
+
+// timeoutHint returns the timeout of the "timeoutMs" member raw, 0 if there is none or it is invalid
+func timeoutHint(raw json.RawMessage) time.Duration {
+	if raw == nil {
+		return 0
+	}
+	var ms float64
+	if err := json.Unmarshal(raw, &ms); err != nil || ms <= 0 || ms > math.MaxInt64/float64(time.Millisecond) {
+		return 0
+	}
+	return time.Duration(ms * float64(time.Millisecond))
+}
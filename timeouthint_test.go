@@ -0,0 +1,43 @@
+package jsonrpc2
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestWithTimeoutHint(t *testing.T) {
+	clock := MockClock()
+	remaining := func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		deadline, ok := ctx.Deadline()
+		if !ok {
+			return "none", nil
+		}
+		return deadline.Sub(clock.Now()).String(), nil
+	}
+	server := NewServer(WithClock(clock), WithTimeoutHint())
+	server.DefineMethod("remaining", remaining)
+	DefineMethodConfig(server, MethodConfig{Name: "remaining.short", Handler: remaining, Timeout: time.Second})
+	for req, expected := range map[string]string{
+		`{"jsonrpc": "2.0", "method": "remaining", "id": 1}`:                           "none",
+		`{"jsonrpc": "2.0", "method": "remaining", "id": 1, "timeoutMs": 1500}`:        "1.5s",
+		`{"jsonrpc": "2.0", "method": "remaining", "id": 1, "timeoutMs": 2.5}`:         "2.5ms",
+		`{"jsonrpc": "2.0", "method": "remaining.short", "id": 1, "timeoutMs": 500}`:   "500ms",
+		`{"jsonrpc": "2.0", "method": "remaining.short", "id": 1, "timeoutMs": 5000}`:  "1s",
+		`{"jsonrpc": "2.0", "method": "remaining.short", "id": 1, "timeoutMs": "500"}`: "1s",
+		`{"jsonrpc": "2.0", "method": "remaining.short", "id": 1, "timeoutMs": -1}`:    "1s",
+		`{"jsonrpc": "2.0", "method": "remaining.short", "id": 1, "timeoutMs": 1e300}`: "1s",
+	} {
+		result, rpcErr, err := ExtractResult(server.ServeRequest(json.RawMessage(req)))
+		require.NoError(t, err, req)
+		require.Nil(t, rpcErr, req)
+		require.JSONEq(t, `"`+expected+`"`, string(result), req)
+	}
+
+	server = NewServer(WithClock(clock))
+	server.DefineMethod("remaining", remaining)
+	rsp := server.ServeRequest(json.RawMessage(`{"jsonrpc": "2.0", "method": "remaining", "id": 1, "timeoutMs": 1500}`))
+	require.JSONEq(t, `{"jsonrpc": "2.0", "result": "none", "id": 1}`, string(rsp), "disabled by default")
+}
@@ -0,0 +1,96 @@
+package jsonrpc2
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"unicode"
+)
+
+// A ResponseTransformer changes the result of a method before it is encoded, e.g. to the shape of an older version of
+// the API. Its errors are responded as ErrInternalServerError.
+type ResponseTransformer func(ctx context.Context, method string, result interface{}) (interface{}, error)
+
+// WithResponseTransformer transforms the results of the successful requests, after ServerConfig.ValidateResponses.
+// The transformers run in the order given. To serve several versions of the API from the same methods, mount a server
+// per version with its transformers in a MethodRouter, or select the version from the request in ctx, see
+// RawRequestFromContext:
+//	v1 := jsonrpc2.NewServer(jsonrpc2.WithResponseTransformer(jsonrpc2.RenameFields(jsonrpc2.SnakeCase)))
+//	v1.RegisterMethodSet(api)
+//	v2 := jsonrpc2.NewServer()
+//	v2.RegisterMethodSet(api)
+//	router := jsonrpc2.NewMethodRouter()
+//	router.Mount("v1", v1)
+//	router.Mount("v2", v2)
+func WithResponseTransformer(t ResponseTransformer) ServerOption {
+	return func(s *server) {
+		s.transformers = append(s.transformers, t)
+	}
+}
+
+// RenameFields returns a ResponseTransformer renaming the members of the objects of the results, at any depth.
+func RenameFields(rename func(field string) string) ResponseTransformer {
+	return func(ctx context.Context, method string, result interface{}) (interface{}, error) {
+		b, err := json.Marshal(result)
+		if err != nil {
+			return nil, err
+		}
+		d := json.NewDecoder(bytes.NewReader(b))
+		d.UseNumber()
+		var v interface{}
+		if err := d.Decode(&v); err != nil {
+			return nil, err
+		}
+		return renameFields(v, rename), nil
+	}
+}
+
+// SnakeCase returns field in snake case, e.g. "userID" and "UserId" are "user_id", "HTTPServer" is "http_server".
+func SnakeCase(field string) string {
+	rs := []rune(field)
+	var b strings.Builder
+	for i, r := range rs {
+		if unicode.IsUpper(r) {
+			prevLower := i > 0 && (unicode.IsLower(rs[i-1]) || unicode.IsDigit(rs[i-1]))
+			acronymEnd := i > 0 && unicode.IsUpper(rs[i-1]) && i+1 < len(rs) && unicode.IsLower(rs[i+1])
+			if prevLower || acronymEnd {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// ============ Private members below =================
+
+// transform applies the transformers of the server to result, their errors are logged at ERROR level by the logger
+// of the request
+func (s *server) transform(ctx context.Context, method string, result interface{}) (interface{}, error) {
+	for _, t := range s.transformers {
+		var err error
+		if result, err = t(ctx, method, result); err != nil {
+			LoggerFromContext(ctx).Error("jsonrpc2: response transformer failed", "error", err)
+			return nil, ErrInternalServerError
+		}
+	}
+	return result, nil
+}
+
+func renameFields(v interface{}, rename func(field string) string) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		renamed := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			renamed[rename(k)] = renameFields(e, rename)
+		}
+		return renamed
+	case []interface{}:
+		for i, e := range v {
+			v[i] = renameFields(e, rename)
+		}
+	}
+	return v
+}
@@ -0,0 +1,98 @@
+package jsonrpc2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestWithResponseTransformer(t *testing.T) {
+	type address struct {
+		ZipCode string
+	}
+	type user struct {
+		UserID    int       `json:"userID"`
+		FirstName string    `json:"firstName"`
+		Addresses []address `json:"addresses"`
+	}
+	api := NewMethodSet(map[string]Handler{
+		"getUser": func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+			return user{UserID: 1, FirstName: "Ada", Addresses: []address{{ZipCode: "75001"}}}, nil
+		},
+		"fail": func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+			return nil, NewError(-32050, "Out of stock")
+		},
+	})
+	// v1 clients expect snake case fields in a legacy envelope
+	envelope := func(ctx context.Context, method string, result interface{}) (interface{}, error) {
+		return map[string]interface{}{"status": "ok", "data": result}, nil
+	}
+	t.Run("per mount", func(t *testing.T) {
+		v1 := NewServer(WithResponseTransformer(RenameFields(SnakeCase)), WithResponseTransformer(envelope))
+		v1.RegisterMethodSet(api)
+		v2 := NewServer()
+		v2.RegisterMethodSet(api)
+		router := NewMethodRouter()
+		router.Mount("v1", v1)
+		router.Mount("v2", v2)
+
+		rsp := router.ServeRequest(json.RawMessage(`{"jsonrpc": "2.0", "method": "v1.getUser", "id": 1}`))
+		require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "result": {
+			"status": "ok",
+			"data": {"user_id": 1, "first_name": "Ada", "addresses": [{"zip_code": "75001"}]}
+		}}`, string(rsp))
+		rsp = router.ServeRequest(json.RawMessage(`{"jsonrpc": "2.0", "method": "v2.getUser", "id": 1}`))
+		require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "result": {
+			"userID": 1, "firstName": "Ada", "addresses": [{"ZipCode": "75001"}]
+		}}`, string(rsp))
+		// errors are not transformed
+		rsp = router.ServeRequest(json.RawMessage(`{"jsonrpc": "2.0", "method": "v1.fail", "id": 1}`))
+		require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "error": {"code": -32050, "message": "Out of stock"}}`, string(rsp))
+	})
+	t.Run("per request", func(t *testing.T) {
+		rename := RenameFields(SnakeCase)
+		server := NewServer(WithResponseTransformer(func(ctx context.Context, method string, result interface{}) (interface{}, error) {
+			var req struct {
+				APIVersion int `json:"apiVersion"`
+			}
+			json.Unmarshal(RawRequestFromContext(ctx), &req)
+			if req.APIVersion == 1 {
+				return rename(ctx, method, result)
+			}
+			return result, nil
+		}))
+		server.RegisterMethodSet(api)
+		rsp := server.ServeRequest(json.RawMessage(`{"jsonrpc": "2.0", "method": "getUser", "id": 1, "apiVersion": 1}`))
+		require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "result": {
+			"user_id": 1, "first_name": "Ada", "addresses": [{"zip_code": "75001"}]
+		}}`, string(rsp))
+		rsp = server.ServeRequest(json.RawMessage(`{"jsonrpc": "2.0", "method": "getUser", "id": 1}`))
+		require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "result": {
+			"userID": 1, "firstName": "Ada", "addresses": [{"ZipCode": "75001"}]
+		}}`, string(rsp))
+	})
+	t.Run("transformer error", func(t *testing.T) {
+		server := NewServer(WithResponseTransformer(func(ctx context.Context, method string, result interface{}) (interface{}, error) {
+			return nil, errors.New("unsupported")
+		}))
+		server.RegisterMethodSet(api)
+		rsp := server.ServeRequest(json.RawMessage(`{"jsonrpc": "2.0", "method": "getUser", "id": 1}`))
+		require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "error": {"code": -32603, "message": "Internal server error"}}`, string(rsp))
+	})
+}
+
+func TestSnakeCase(t *testing.T) {
+	for field, expected := range map[string]string{
+		"userID":     "user_id",
+		"UserId":     "user_id",
+		"HTTPServer": "http_server",
+		"zip_code":   "zip_code",
+		"address2":   "address2",
+		"v2Name":     "v2_name",
+		"":           "",
+	} {
+		require.Equal(t, expected, SnakeCase(field), field)
+	}
+}
@@ -0,0 +1,37 @@
+package jsonrpc2
+
+import (
+	"encoding/json"
+	"unicode/utf8"
+)
+
+// SetValidateUTF8 rejects the requests with invalid UTF-8, see ServerConfig.ValidateUTF8.
+func (s *server) SetValidateUTF8(enabled bool) {
+	s.updateConfig(func(cfg *ServerConfig) {
+		cfg.ValidateUTF8 = enabled
+	})
+}
+
+// ============ Private members below =================
+
+// invalidUTF8 returns the response of ErrParseError with the offset of the first invalid UTF-8 sequence as data, if
+// ServerConfig.ValidateUTF8 is set and the request has one
+func (s *server) invalidUTF8(jsonString json.RawMessage) json.RawMessage {
+	if !s.loadConfig().ValidateUTF8 || utf8.Valid(jsonString) {
+		return nil
+	}
+	offset := 0
+	for offset < len(jsonString) {
+		r, size := utf8.DecodeRune(jsonString[offset:])
+		if r == utf8.RuneError && size <= 1 {
+			break
+		}
+		offset += size
+	}
+	s.metrics.received()
+	s.metrics.failed()
+	return s.makeResponseJson(request{}, nil, NewErrorWithData(CodeParseError, ErrParseError.Error(), parseErrorData{
+		Offset: int64(offset),
+		Detail: "invalid UTF-8",
+	}))
+}
@@ -0,0 +1,47 @@
+package jsonrpc2
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"strconv"
+	"testing"
+)
+
+func TestSetValidateUTF8(t *testing.T) {
+	server := NewServer()
+	server.DefineMethod("echo", func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		var s string
+		json.Unmarshal(params, &s)
+		return s, nil
+	})
+	invalid := json.RawMessage("{\"jsonrpc\": \"2.0\", \"method\": \"echo\", \"params\": \"caf\xc3\", \"id\": 1}")
+	t.Run("disabled", func(t *testing.T) {
+		rsp := server.ServeRequest(invalid)
+		require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "result": "caf�"}`, string(rsp))
+	})
+	t.Run("enabled", func(t *testing.T) {
+		server.SetValidateUTF8(true)
+		defer server.SetValidateUTF8(false)
+		for _, req := range []json.RawMessage{
+			invalid,
+			json.RawMessage("[{\"jsonrpc\": \"2.0\", \"method\": \"echo\", \"params\": \"ok\", \"id\": 1}, \"caf\xc3\"]"),
+		} {
+			rsp := server.ServeRequest(req)
+			offset := len(req) - len("\", \"id\": 1}") - 1
+			if req[0] == '[' {
+				offset = len(req) - len("\"]") - 1
+			}
+			require.JSONEq(t, `{"id": null, "jsonrpc": "2.0", "error": {
+				"code": -32700,
+				"message": "Parse error",
+				"data": {"offset": `+strconv.Itoa(offset)+`, "detail": "invalid UTF-8"}
+			}}`, string(rsp))
+		}
+		// composed and decomposed é are both valid, and kept as sent
+		for _, s := range []string{"caf\u00e9", "cafe\u0301"} {
+			rsp := server.ServeRequest(json.RawMessage(`{"jsonrpc": "2.0", "method": "echo", "params": "` + s + `", "id": 1}`))
+			require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "result": "`+s+`"}`, string(rsp))
+		}
+	})
+}
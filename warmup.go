@@ -0,0 +1,132 @@
+package jsonrpc2
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+func (s *server) WarmUp(ctx context.Context, methods ...string) error {
+	s.methodsMu.RLock()
+	if len(methods) == 0 {
+		for method := range s.inits {
+			methods = append(methods, method)
+		}
+		sort.Strings(methods)
+	}
+	inits := make([]*initializer, len(methods))
+	for i, method := range methods {
+		init, ok := s.inits[method]
+		if !ok {
+			s.methodsMu.RUnlock()
+			return fmt.Errorf("jsonrpc2: method %q has no initializer", method)
+		}
+		inits[i] = init
+	}
+	s.methodsMu.RUnlock()
+	for _, init := range inits {
+		select {
+		case <-init.start(s.clock.Now()):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if _, err := init.state(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *server) RetryInit(method string) {
+	s.methodsMu.RLock()
+	init, ok := s.inits[method]
+	s.methodsMu.RUnlock()
+	if ok {
+		init.reset()
+	}
+}
+
+// ============ Private members below =================
+
+// initializer runs a method initializer once at a time and keeps its outcome
+type initializer struct {
+	init     func(ctx context.Context) error
+	expected time.Duration
+
+	mu      sync.Mutex
+	started time.Time
+	// done is closed when the current run finishes, nil if not started
+	done chan struct{}
+	err  error
+}
+
+// ready starts the initializer if needed and returns nil if it succeeded, ErrWarmingUp if running or its error
+func (i *initializer) ready(now time.Time) error {
+	i.start(now)
+	running, err := i.state()
+	if running {
+		eta := i.expected - now.Sub(i.startTime())
+		if eta < 0 {
+			eta = 0
+		}
+		return NewErrorWithData(CodeWarmingUp, ErrWarmingUp.Error(), map[string]int64{
+			"etaMs": eta.Milliseconds(),
+		})
+	}
+	return err
+}
+
+func (i *initializer) start(now time.Time) <-chan struct{} {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.done != nil {
+		return i.done
+	}
+	done := make(chan struct{})
+	i.done = done
+	i.started = now
+	go func() {
+		err := i.init(context.Background())
+		i.mu.Lock()
+		i.err = err
+		i.mu.Unlock()
+		close(done)
+	}()
+	return done
+}
+
+func (i *initializer) state() (running bool, err error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	select {
+	case <-i.done:
+		return false, i.err
+	default:
+		return true, nil
+	}
+}
+
+func (i *initializer) startTime() time.Time {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.started
+}
+
+// reset lets a failed initializer run again
+func (i *initializer) reset() {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.done == nil {
+		return
+	}
+	select {
+	case <-i.done:
+		if i.err != nil {
+			i.done = nil
+			i.err = nil
+		}
+	default:
+	}
+}
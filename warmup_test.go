@@ -0,0 +1,97 @@
+package jsonrpc2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMethodInitializer(t *testing.T) {
+	echo := func(ctx context.Context, params json.RawMessage) (result interface{}, error error) {
+		return params, nil
+	}
+	req := json.RawMessage(`{ "jsonrpc": "2.0", "method": "predict", "params": "hi", "id": 1 }`)
+	t.Run("concurrent first calls", func(t *testing.T) {
+		var count int32
+		release := make(chan struct{})
+		server := NewServer()
+		DefineMethodConfig(server, MethodConfig{
+			Name:    "predict",
+			Handler: echo,
+			Initializer: func(ctx context.Context) error {
+				atomic.AddInt32(&count, 1)
+				<-release
+				return nil
+			},
+			InitDuration: time.Hour,
+		})
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, rpcErr, err := ExtractResult(server.ServeRequest(req))
+				if assert.NoError(t, err) && assert.Equal(t, ErrWarmingUp.Code(), rpcErr.Code()) {
+					data, _ := errorData(rpcErr).(map[string]interface{})
+					assert.InDelta(t, time.Hour.Milliseconds(), data["etaMs"], float64(time.Minute.Milliseconds()))
+				}
+			}()
+		}
+		wg.Wait()
+		close(release)
+		require.NoError(t, server.WarmUp(context.Background(), "predict"))
+		require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "result": "hi"}`, string(server.ServeRequest(req)))
+		require.Equal(t, int32(1), atomic.LoadInt32(&count))
+	})
+	t.Run("init failure and retry", func(t *testing.T) {
+		var count int32
+		server := NewServer()
+		DefineMethodConfig(server, MethodConfig{
+			Name:    "predict",
+			Handler: echo,
+			Initializer: func(ctx context.Context) error {
+				if atomic.AddInt32(&count, 1) == 1 {
+					return errors.New("model not found")
+				}
+				return nil
+			},
+		})
+		require.EqualError(t, server.WarmUp(context.Background()), "model not found")
+		for i := 0; i < 2; i++ {
+			require.JSONEq(t, `{
+				"id": 1,
+				"jsonrpc": "2.0",
+				"error": {"code": -32000, "message": "model not found"}
+			}`, string(server.ServeRequest(req)))
+		}
+		require.Equal(t, int32(1), atomic.LoadInt32(&count))
+
+		server.RetryInit("predict")
+		require.NoError(t, server.WarmUp(context.Background()))
+		require.JSONEq(t, `{"id": 1, "jsonrpc": "2.0", "result": "hi"}`, string(server.ServeRequest(req)))
+		require.Equal(t, int32(2), atomic.LoadInt32(&count))
+	})
+	t.Run("warm up", func(t *testing.T) {
+		server := NewServer()
+		server.DefineMethod("echo", echo)
+		require.Error(t, server.WarmUp(context.Background(), "echo"))
+
+		DefineMethodConfig(server, MethodConfig{
+			Name:    "predict",
+			Handler: echo,
+			Initializer: func(ctx context.Context) error {
+				time.Sleep(time.Second)
+				return nil
+			},
+		})
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+		defer cancel()
+		require.Equal(t, context.DeadlineExceeded, server.WarmUp(ctx))
+	})
+}